@@ -0,0 +1,86 @@
+// Package apperror classifies errors by kind, so deliveries can answer with
+// the right status without knowing every module's errors. Domains declare
+// their errors with New and callers keep matching them with errors.Is; the
+// kind is matched the same way. It only uses the standard library, so the
+// domain packages can depend on it; delivery/problem maps kinds to statuses.
+package apperror
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kinds of errors.
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the request clashes with stored data, e.g. a
+	// unique field already in use.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalid means input the client can correct.
+	ErrInvalid            = errors.New("invalid input")
+	ErrTooLarge           = errors.New("too large")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInactive           = errors.New("inactive")
+	ErrUnauthenticated    = errors.New("unauthenticated")
+	// ErrPreconditionRequired means a conditional request was expected.
+	ErrPreconditionRequired = errors.New("precondition required")
+	// ErrPreconditionFailed means the condition of a request did not hold,
+	// e.g. a stale version.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is an error of a kind with its own message.
+type Error struct {
+	kind    error
+	message string
+}
+
+// New returns an error reading message that matches kind with errors.Is.
+func New(kind error, message string) *Error {
+	return &Error{kind: kind, message: message}
+}
+
+func (e *Error) Error() string { return e.message }
+
+func (e *Error) Unwrap() error { return e.kind }
+
+// Wrap returns an error that reads as err and matches both err and cause
+// with errors.Is. It keeps the cause of a domain error, e.g. a driver
+// error, without showing it to clients.
+func Wrap(err, cause error) error {
+	return &wrapped{err: err, cause: cause}
+}
+
+type wrapped struct {
+	err, cause error
+}
+
+func (w *wrapped) Error() string { return w.err.Error() }
+
+func (w *wrapped) Unwrap() []error { return []error{w.err, w.cause} }
+
+// FieldError is one violation of a validation rule. Field is the path of
+// the field in the request's JSON, e.g. "email" or "translations[id].title";
+// Rule and Param are the rule broken and its argument, e.g. "min" and "8".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is an ErrInvalid listing the fields at fault. Its message
+// sums them up for logs and clients that only read the detail.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, ", ")
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalid }
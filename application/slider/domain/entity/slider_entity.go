@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"io"
+	"time"
+)
+
+type Slider struct {
+	ID          string
+	Title       string
+	Description string
+	// File is the URL of the slider image, either an uploaded one or an
+	// external address given by the client.
+	File string
+	// ImageKey is the storage key of an uploaded original, empty when File
+	// points elsewhere.
+	ImageKey string
+	// Images lists the stored copies of an uploaded image: the original and
+	// every format of every variant. It is empty when File points elsewhere.
+	Images []StoredImage
+	// LinkURL is where the slide leads when clicked, if anywhere.
+	LinkURL string
+	// Position orders the carousel, lowest first.
+	Position  int
+	Published bool
+	// StartsAt and EndsAt limit when a published slide is shown; nil means
+	// no limit on that side.
+	StartsAt *time.Time
+	EndsAt   *time.Time
+	// Translations holds the title and description per locale, such as "id".
+	Translations map[string]Translation
+	// Locale is the locale Title and Description are in, set when the
+	// slider was localized for a reader.
+	Locale    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+type Translation struct {
+	Title       string
+	Description string
+}
+
+// ActiveAt reports whether the slider is published and scheduled at t.
+func (s *Slider) ActiveAt(t time.Time) bool {
+	if !s.Published {
+		return false
+	}
+	if s.StartsAt != nil && t.Before(*s.StartsAt) {
+		return false
+	}
+	if s.EndsAt != nil && !t.Before(*s.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// SliderQuery selects how sliders are read. Public reads only return
+// sliders that are active now, localized to Locale.
+type SliderQuery struct {
+	Public bool
+	Locale string
+}
+
+// StoredImage is one stored copy of an uploaded slider image.
+type StoredImage struct {
+	// Variant is "original" or the name of a resized copy, such as
+	// "thumbnail".
+	Variant string
+	// Format is the encoding of the copy: "jpeg" or "webp" for variants,
+	// the uploaded format for the original.
+	Format string
+	URL    string
+}
+
+// Image is an uploaded slider image that has not been stored yet.
+type Image struct {
+	Filename string
+	Size     int64
+	Content  io.Reader
+}
+
+// UpdateSliderPayload changes the fields that are not nil. Image replaces
+// the slider image, and so does File, with an external URL. An empty
+// LinkURL removes the link, and a zero StartsAt or EndsAt removes that end
+// of the schedule. Translations replaces all translations when not nil.
+type UpdateSliderPayload struct {
+	ID           string
+	Title        *string
+	Description  *string
+	File         *string
+	Image        *Image
+	LinkURL      *string
+	StartsAt     *time.Time
+	EndsAt       *time.Time
+	Translations map[string]Translation
+}
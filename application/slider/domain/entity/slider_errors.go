@@ -0,0 +1,27 @@
+package entity
+
+import "github.com/celpung/gocleanarch/application/apperror"
+
+var (
+	// ErrSliderNotFound is returned for missing and soft deleted sliders,
+	// and for inactive ones in public reads.
+	ErrSliderNotFound = apperror.New(apperror.ErrNotFound, "slider not found")
+	// ErrImageRequired is returned when a slider has neither an uploaded
+	// image nor a file URL.
+	ErrImageRequired = apperror.New(apperror.ErrInvalid, "an image upload or a file URL is required")
+	// ErrImageTooLarge is returned for uploads over the size limit.
+	ErrImageTooLarge = apperror.New(apperror.ErrTooLarge, "image exceeds the upload size limit")
+	// ErrInvalidImage is returned for uploads that are not a supported image.
+	ErrInvalidImage = apperror.New(apperror.ErrInvalid, "invalid image")
+	// ErrInvalidSchedule is returned when a slider would end before it starts.
+	ErrInvalidSchedule = apperror.New(apperror.ErrInvalid, "ends_at must be after starts_at")
+	// ErrInvalidLink is returned for link targets that are neither an
+	// http(s) URL nor a path on this site.
+	ErrInvalidLink = apperror.New(apperror.ErrInvalid, "link_url must be an http(s) URL or start with /")
+	// ErrInvalidLocale is returned for translation keys that are not a
+	// locale such as "id" or "pt-br".
+	ErrInvalidLocale = apperror.New(apperror.ErrInvalid, "invalid locale")
+	// ErrOrderMismatch is returned when a reorder does not list every
+	// slider exactly once.
+	ErrOrderMismatch = apperror.New(apperror.ErrInvalid, "the order must list every slider exactly once")
+)
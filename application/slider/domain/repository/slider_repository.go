@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+)
+
+type SliderRepository interface {
+	// Create appends slider after the last one and stores its translations.
+	Create(ctx context.Context, slider *model.Slider) (*model.Slider, error)
+	// Read lists sliders by position. With activeAt set it only returns
+	// sliders that are published and scheduled at that time.
+	Read(ctx context.Context, page, limit uint, activeAt *time.Time) ([]*model.Slider, int64, error)
+	ReadByID(ctx context.Context, sliderID string) (*model.Slider, error)
+	// UpdateFields applies fields to the slider and returns it as stored.
+	UpdateFields(ctx context.Context, sliderID string, fields map[string]any) (*model.Slider, error)
+	// ReplaceTranslations swaps all translations of a slider for translations.
+	ReplaceTranslations(ctx context.Context, sliderID string, translations []model.SliderTranslation) error
+	// Reorder gives the sliders in ids the positions 1, 2, 3 and so on. ids
+	// must list every slider exactly once, or entity.ErrOrderMismatch is
+	// returned and nothing changes.
+	Reorder(ctx context.Context, ids []string) error
+	SoftDelete(ctx context.Context, sliderID string) error
+}
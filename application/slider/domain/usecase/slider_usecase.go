@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+)
+
+type SliderUsecase interface {
+	// Create stores slider, with image as its picture when it is not nil,
+	// after the last slider.
+	Create(ctx context.Context, slider *entity.Slider, image *entity.Image) (*entity.Slider, error)
+	Read(ctx context.Context, query entity.SliderQuery, page, limit uint) ([]*entity.Slider, int64, error)
+	ReadByID(ctx context.Context, sliderID string, query entity.SliderQuery) (*entity.Slider, error)
+	Update(ctx context.Context, payload *entity.UpdateSliderPayload) (*entity.Slider, error)
+	SetPublished(ctx context.Context, sliderID string, published bool) (*entity.Slider, error)
+	// Reorder places the sliders in the order of ids, which must list every
+	// slider exactly once.
+	Reorder(ctx context.Context, ids []string) error
+	SoftDelete(ctx context.Context, sliderID string) error
+}
@@ -0,0 +1,215 @@
+package repository_impl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+	"github.com/celpung/gocleanarch/application/slider/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+)
+
+// SliderRepositoryStruct reports missing and soft deleted sliders as
+// entity.ErrSliderNotFound. Reads include the translations of each slider.
+type SliderRepositoryStruct struct {
+	DB *gorm.DB
+}
+
+func (r *SliderRepositoryStruct) Create(ctx context.Context, m *model.Slider) (*model.Slider, error) {
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		last, err := lastPosition(tx)
+		if err != nil {
+			return err
+		}
+		m.Position = last + 1
+
+		return tx.Create(m).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *SliderRepositoryStruct) Read(ctx context.Context, page, limit uint, activeAt *time.Time) ([]*model.Slider, int64, error) {
+	var (
+		sliders []*model.Slider
+		total   int64
+	)
+
+	const (
+		defaultLimit uint = 10
+		maxLimit     uint = 100
+	)
+
+	if page == 0 {
+		page = 1
+	}
+
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	offset := int((page - 1) * limit)
+
+	base := r.db(ctx).Model(&model.Slider{})
+	if activeAt != nil {
+		base = base.
+			Where("sliders.published = ?", true).
+			Where("sliders.starts_at IS NULL OR sliders.starts_at <= ?", *activeAt).
+			Where("sliders.ends_at IS NULL OR sliders.ends_at > ?", *activeAt)
+	}
+
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := base.Session(&gorm.Session{}).
+		Preload("Translations").
+		Order("sliders.position, sliders.created_at DESC, sliders.id").
+		Offset(offset).
+		Limit(int(limit)).
+		Find(&sliders).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return sliders, total, nil
+}
+
+func (r *SliderRepositoryStruct) ReadByID(ctx context.Context, sliderID string) (*model.Slider, error) {
+	slider := &model.Slider{}
+
+	if err := r.primary(ctx).Preload("Translations").First(slider, "id = ?", sliderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrSliderNotFound
+		}
+		return nil, err
+	}
+
+	return slider, nil
+}
+
+func (r *SliderRepositoryStruct) UpdateFields(ctx context.Context, sliderID string, fields map[string]any) (*model.Slider, error) {
+	tx := r.db(ctx).Model(&model.Slider{}).Where("id = ?", sliderID).Updates(fields)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return nil, entity.ErrSliderNotFound
+	}
+
+	return r.ReadByID(ctx, sliderID)
+}
+
+func (r *SliderRepositoryStruct) ReplaceTranslations(ctx context.Context, sliderID string, translations []model.SliderTranslation) error {
+	return r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("slider_id = ?", sliderID).Delete(&model.SliderTranslation{}).Error; err != nil {
+			return err
+		}
+		if len(translations) == 0 {
+			return nil
+		}
+
+		for i := range translations {
+			translations[i].SliderID = sliderID
+		}
+		return tx.Create(&translations).Error
+	})
+}
+
+func (r *SliderRepositoryStruct) Reorder(ctx context.Context, ids []string) error {
+	return r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []string
+		if err := tx.Model(&model.Slider{}).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+
+		if len(existing) != len(ids) {
+			return entity.ErrOrderMismatch
+		}
+		known := make(map[string]bool, len(existing))
+		for _, id := range existing {
+			known[id] = true
+		}
+		for _, id := range ids {
+			if !known[id] {
+				return entity.ErrOrderMismatch
+			}
+			/* Seeing an ID twice means another one is missing. */
+			delete(known, id)
+		}
+
+		for i, id := range ids {
+			if err := tx.Model(&model.Slider{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SliderRepositoryStruct) SoftDelete(ctx context.Context, sliderID string) error {
+	tx := r.db(ctx).Where("id = ?", sliderID).Delete(&model.Slider{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return entity.ErrSliderNotFound
+	}
+
+	return nil
+}
+
+// sliderPositionLockKey is the PostgreSQL advisory lock key guarding slider
+// positions, "sliders.position" hashed to a bigint once.
+const sliderPositionLockKey int64 = 4290318837714502219
+
+// lastPosition returns the highest position in use and keeps any other
+// Create from reading it until tx ends, so two sliders never get the same
+// position. PostgreSQL takes a transaction scoped advisory lock, MySQL locks
+// the scanned rows and the gap after them, and SQLite already runs
+// transactions one writer at a time.
+func lastPosition(tx *gorm.DB) (int, error) {
+	q := tx.Model(&model.Slider{}).Select("COALESCE(MAX(position), 0)")
+
+	switch tx.Dialector.Name() {
+	case "postgres":
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", sliderPositionLockKey).Error; err != nil {
+			return 0, err
+		}
+	case "mysql":
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var last int
+	if err := q.Scan(&last).Error; err != nil {
+		return 0, err
+	}
+	return last, nil
+}
+
+func (r *SliderRepositoryStruct) db(ctx context.Context) *gorm.DB {
+	return uow_impl.DB(ctx, r.DB)
+}
+
+// primary is db pinned to the primary database, so a slider reads back
+// right after it was written.
+func (r *SliderRepositoryStruct) primary(ctx context.Context) *gorm.DB {
+	return r.db(ctx).Clauses(dbresolver.Write)
+}
+
+func NewSliderRepository(db *gorm.DB) repository.SliderRepository {
+	return &SliderRepositoryStruct{DB: db}
+}
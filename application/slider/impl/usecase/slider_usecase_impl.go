@@ -0,0 +1,498 @@
+package usecase_impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+	"github.com/celpung/gocleanarch/application/slider/domain/repository"
+	"github.com/celpung/gocleanarch/application/slider/domain/usecase"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/mapper"
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+	"github.com/celpung/gocleanarch/infrastructure/thumbnail"
+	"github.com/celpung/gocleanarch/infrastructure/uow"
+	"github.com/google/uuid"
+)
+
+// SliderVariants are the resized copies stored next to every uploaded
+// slider image. WebP copies are lossless and would outweigh the JPEG at
+// full width, so only the smaller variants get one.
+var SliderVariants = []thumbnail.Variant{
+	{Name: "large", Width: 1920, Formats: []thumbnail.Format{thumbnail.JPEG}},
+	{Name: "medium", Width: 1024, Formats: []thumbnail.Format{thumbnail.JPEG, thumbnail.WebP}},
+	{Name: "thumbnail", Width: 320, Formats: []thumbnail.Format{thumbnail.JPEG, thumbnail.WebP}},
+}
+
+// SliderImagePolicy is what slider uploads accept. The use case sets
+// MaxSize to the upload limit it is built with.
+var SliderImagePolicy = checker.Policy{
+	Name:          "slider images",
+	Types:         []checker.FileType{checker.JPEG, checker.PNG, checker.GIF, checker.WebP},
+	MaxWidth:      10_000,
+	MaxHeight:     10_000,
+	MaxPixels:     40_000_000,
+	StripMetadata: true,
+}
+
+// SliderUsecaseStruct stores uploaded images below
+// "sliders/<slider id>/<upload id>/" in Storage: the original as uploaded,
+// minus its metadata, and every format of every entry in Variants. Uploads
+// must pass Inspector under ImagePolicy first. Files are removed again when the
+// image is replaced, the slider is deleted or saving the slider fails.
+type SliderUsecaseStruct struct {
+	Repo        repository.SliderRepository
+	UoW         uow.UnitOfWork
+	Storage     storage.Storage
+	Inspector   *checker.Inspector
+	ImagePolicy checker.Policy
+	Variants    []thumbnail.Variant
+	JPEGQuality int
+	Now         func() time.Time
+}
+
+// localePattern matches lowercase locales such as "id", "en-us" or "pt-br".
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// imageExtensions maps content types to the extension the original is
+// stored with.
+var imageExtensions = map[string]string{
+	checker.JPEG.MIME: ".jpg",
+	checker.PNG.MIME:  ".png",
+	checker.GIF.MIME:  ".gif",
+	checker.WebP.MIME: ".webp",
+}
+
+// imageFormats names the format of an original by its extension.
+var imageFormats = map[string]string{
+	".jpg":  "jpeg",
+	".png":  "png",
+	".gif":  "gif",
+	".webp": "webp",
+}
+
+func (u *SliderUsecaseStruct) Create(ctx context.Context, slider *entity.Slider, image *entity.Image) (*entity.Slider, error) {
+	m := model.Slider{
+		Title:       strings.TrimSpace(slider.Title),
+		Description: strings.TrimSpace(slider.Description),
+		File:        strings.TrimSpace(slider.File),
+		LinkURL:     strings.TrimSpace(slider.LinkURL),
+		Published:   slider.Published,
+	}
+	if slider.StartsAt != nil {
+		m.StartsAt = optionalTime(*slider.StartsAt)
+	}
+	if slider.EndsAt != nil {
+		m.EndsAt = optionalTime(*slider.EndsAt)
+	}
+
+	if err := validateLink(m.LinkURL); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(m.StartsAt, m.EndsAt); err != nil {
+		return nil, err
+	}
+	translations, err := toTranslationModels(slider.Translations)
+	if err != nil {
+		return nil, err
+	}
+	m.Translations = translations
+
+	var stored []string
+	if image != nil {
+		m.ID = uuid.NewString()
+
+		key, keys, err := u.storeImage(ctx, m.ID, image)
+		if err != nil {
+			return nil, err
+		}
+		stored = keys
+		m.ImageKey = key
+		m.File = u.Storage.URL(key)
+	}
+
+	if m.File == "" {
+		return nil, entity.ErrImageRequired
+	}
+
+	created, err := u.Repo.Create(ctx, &m)
+	if err != nil {
+		storage.DeleteQuietly(ctx, u.Storage, "sliders", stored...)
+		return nil, err
+	}
+
+	return u.toEntity(created)
+}
+
+func (u *SliderUsecaseStruct) Read(ctx context.Context, query entity.SliderQuery, page, limit uint) ([]*entity.Slider, int64, error) {
+	var activeAt *time.Time
+	if query.Public {
+		now := u.Now().UTC()
+		activeAt = &now
+	}
+
+	ms, total, err := u.Repo.Read(ctx, page, limit, activeAt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	es := make([]*entity.Slider, 0, len(ms))
+	for _, m := range ms {
+		e, err := u.toEntity(m)
+		if err != nil {
+			return nil, 0, err
+		}
+		if query.Public {
+			localize(e, query.Locale)
+		}
+		es = append(es, e)
+	}
+	return es, total, nil
+}
+
+func (u *SliderUsecaseStruct) ReadByID(ctx context.Context, sliderID string, query entity.SliderQuery) (*entity.Slider, error) {
+	m, err := u.Repo.ReadByID(ctx, sliderID)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := u.toEntity(m)
+	if err != nil {
+		return nil, err
+	}
+	if query.Public {
+		if !e.ActiveAt(u.Now()) {
+			return nil, entity.ErrSliderNotFound
+		}
+		localize(e, query.Locale)
+	}
+	return e, nil
+}
+
+func (u *SliderUsecaseStruct) Update(ctx context.Context, payload *entity.UpdateSliderPayload) (*entity.Slider, error) {
+	changes := make(map[string]any)
+
+	if payload.Title != nil {
+		changes["title"] = strings.TrimSpace(*payload.Title)
+	}
+	if payload.Description != nil {
+		changes["description"] = strings.TrimSpace(*payload.Description)
+	}
+	if payload.LinkURL != nil {
+		link := strings.TrimSpace(*payload.LinkURL)
+		if err := validateLink(link); err != nil {
+			return nil, err
+		}
+		changes["link_url"] = link
+	}
+
+	translations, err := toTranslationModels(payload.Translations)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *model.Slider
+	if payload.Image != nil || payload.File != nil || payload.StartsAt != nil || payload.EndsAt != nil {
+		if current, err = u.Repo.ReadByID(ctx, payload.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	/* A zero time clears that end of the schedule, and the result is checked together with the end that stays. */
+	if payload.StartsAt != nil || payload.EndsAt != nil {
+		startsAt, endsAt := current.StartsAt, current.EndsAt
+		if payload.StartsAt != nil {
+			startsAt = optionalTime(*payload.StartsAt)
+			changes["starts_at"] = startsAt
+		}
+		if payload.EndsAt != nil {
+			endsAt = optionalTime(*payload.EndsAt)
+			changes["ends_at"] = endsAt
+		}
+		if err := validateSchedule(startsAt, endsAt); err != nil {
+			return nil, err
+		}
+	}
+
+	/* A new image or file URL replaces the current picture, so the files of an uploaded one become orphans once the update is saved. */
+	var stored, orphans []string
+	if payload.Image != nil || payload.File != nil {
+		orphans = u.imageKeys(current.ImageKey)
+
+		if payload.Image != nil {
+			key, keys, err := u.storeImage(ctx, payload.ID, payload.Image)
+			if err != nil {
+				return nil, err
+			}
+			stored = keys
+			changes["image_key"] = key
+			changes["file"] = u.Storage.URL(key)
+		} else {
+			file := strings.TrimSpace(*payload.File)
+			if file == "" {
+				return nil, entity.ErrImageRequired
+			}
+			changes["image_key"] = ""
+			changes["file"] = file
+		}
+	}
+
+	if len(changes) == 0 && payload.Translations == nil {
+		return u.ReadByID(ctx, payload.ID, entity.SliderQuery{})
+	}
+
+	var m *model.Slider
+	err = u.UoW.Do(ctx, func(ctx context.Context) error {
+		if len(changes) > 0 {
+			if _, err := u.Repo.UpdateFields(ctx, payload.ID, changes); err != nil {
+				return err
+			}
+		}
+		if payload.Translations != nil {
+			if len(changes) == 0 {
+				/* Only translations change, so check the slider exists before replacing them. */
+				if _, err := u.Repo.ReadByID(ctx, payload.ID); err != nil {
+					return err
+				}
+			}
+			if err := u.Repo.ReplaceTranslations(ctx, payload.ID, translations); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		m, err = u.Repo.ReadByID(ctx, payload.ID)
+		return err
+	})
+	if err != nil {
+		storage.DeleteQuietly(ctx, u.Storage, "sliders", stored...)
+		return nil, err
+	}
+	storage.DeleteQuietly(ctx, u.Storage, "sliders", orphans...)
+
+	return u.toEntity(m)
+}
+
+func (u *SliderUsecaseStruct) SetPublished(ctx context.Context, sliderID string, published bool) (*entity.Slider, error) {
+	m, err := u.Repo.UpdateFields(ctx, sliderID, map[string]any{"published": published})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.toEntity(m)
+}
+
+func (u *SliderUsecaseStruct) Reorder(ctx context.Context, ids []string) error {
+	return u.Repo.Reorder(ctx, ids)
+}
+
+func (u *SliderUsecaseStruct) SoftDelete(ctx context.Context, sliderID string) error {
+	current, err := u.Repo.ReadByID(ctx, sliderID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.Repo.SoftDelete(ctx, sliderID); err != nil {
+		return err
+	}
+
+	storage.DeleteQuietly(ctx, u.Storage, "sliders", u.imageKeys(current.ImageKey)...)
+	return nil
+}
+
+// storeImage inspects image and writes the original and its variants. It
+// returns the key of the original and every key written.
+func (u *SliderUsecaseStruct) storeImage(ctx context.Context, sliderID string, image *entity.Image) (string, []string, error) {
+	file, err := u.Inspector.Inspect(ctx, u.ImagePolicy, image.Filename, image.Content, image.Size)
+	switch {
+	case errors.Is(err, checker.ErrTooLarge):
+		return "", nil, entity.ErrImageTooLarge
+	case errors.Is(err, checker.ErrRejected):
+		return "", nil, fmt.Errorf("%w: %w", entity.ErrInvalidImage, err)
+	case err != nil:
+		return "", nil, err
+	}
+
+	img, _, err := thumbnail.Decode(file.Content, u.ImagePolicy.MaxPixels)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", entity.ErrInvalidImage, err)
+	}
+
+	dir := path.Join("sliders", sliderID, uuid.NewString())
+	original := path.Join(dir, "original"+imageExtensions[file.ContentType])
+
+	var written []string
+	put := func(key string, body []byte, contentType string) error {
+		if err := u.Storage.Put(ctx, key, bytes.NewReader(body), contentType); err != nil {
+			return err
+		}
+		written = append(written, key)
+		return nil
+	}
+
+	if err := put(original, file.Content, file.ContentType); err != nil {
+		storage.DeleteQuietly(ctx, u.Storage, "sliders", written...)
+		return "", nil, err
+	}
+
+	for _, v := range u.Variants {
+		resized := thumbnail.Fit(img, v.Width)
+		for _, f := range v.Encodings() {
+			var buf bytes.Buffer
+			if err := thumbnail.Encode(&buf, resized, f, u.JPEGQuality); err != nil {
+				storage.DeleteQuietly(ctx, u.Storage, "sliders", written...)
+				return "", nil, err
+			}
+			if err := put(path.Join(dir, v.Name+f.Ext()), buf.Bytes(), f.MIME()); err != nil {
+				storage.DeleteQuietly(ctx, u.Storage, "sliders", written...)
+				return "", nil, err
+			}
+		}
+	}
+
+	return original, written, nil
+}
+
+// imageKeys lists the original and variant keys of an uploaded image.
+func (u *SliderUsecaseStruct) imageKeys(original string) []string {
+	if original == "" {
+		return nil
+	}
+
+	keys := []string{original}
+	for _, v := range u.Variants {
+		for _, f := range v.Encodings() {
+			keys = append(keys, path.Join(path.Dir(original), v.Name+f.Ext()))
+		}
+	}
+	return keys
+}
+
+func (u *SliderUsecaseStruct) toEntity(m *model.Slider) (*entity.Slider, error) {
+	var out entity.Slider
+	if err := mapper.CopyTo(m, &out); err != nil {
+		return nil, err
+	}
+
+	out.Translations = nil
+	if len(m.Translations) > 0 {
+		out.Translations = make(map[string]entity.Translation, len(m.Translations))
+		for _, t := range m.Translations {
+			out.Translations[t.Locale] = entity.Translation{Title: t.Title, Description: t.Description}
+		}
+	}
+
+	if m.ImageKey != "" {
+		out.Images = []entity.StoredImage{{Variant: "original", Format: imageFormats[path.Ext(m.ImageKey)], URL: u.Storage.URL(m.ImageKey)}}
+		for _, v := range u.Variants {
+			for _, f := range v.Encodings() {
+				key := path.Join(path.Dir(m.ImageKey), v.Name+f.Ext())
+				out.Images = append(out.Images, entity.StoredImage{Variant: v.Name, Format: string(f), URL: u.Storage.URL(key)})
+			}
+		}
+	}
+	return &out, nil
+}
+
+// localize sets Title and Description to the best translation for locale:
+// an exact match, then its language ("id" for "id-id"), then the default.
+// Translations are dropped, readers only get the localized text.
+func localize(slider *entity.Slider, locale string) {
+	translations := slider.Translations
+	slider.Translations = nil
+
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if locale == "" {
+		return
+	}
+
+	for _, candidate := range []string{locale, strings.SplitN(locale, "-", 2)[0]} {
+		if t, ok := translations[candidate]; ok {
+			slider.Title = t.Title
+			slider.Description = t.Description
+			slider.Locale = candidate
+			return
+		}
+	}
+}
+
+// toTranslationModels checks and normalizes translations keyed by locale.
+func toTranslationModels(translations map[string]entity.Translation) ([]model.SliderTranslation, error) {
+	out := make([]model.SliderTranslation, 0, len(translations))
+	seen := make(map[string]bool, len(translations))
+	for locale, t := range translations {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if !localePattern.MatchString(locale) || seen[locale] {
+			return nil, fmt.Errorf("%w: %q", entity.ErrInvalidLocale, locale)
+		}
+		seen[locale] = true
+
+		out = append(out, model.SliderTranslation{
+			Locale:      locale,
+			Title:       strings.TrimSpace(t.Title),
+			Description: strings.TrimSpace(t.Description),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Locale < out[j].Locale })
+	return out, nil
+}
+
+// validateLink accepts an empty link, an absolute http(s) URL or a path on
+// this site.
+func validateLink(link string) error {
+	if link == "" {
+		return nil
+	}
+	if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") {
+		return nil
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return entity.ErrInvalidLink
+	}
+	return nil
+}
+
+func validateSchedule(startsAt, endsAt *time.Time) error {
+	if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+		return entity.ErrInvalidSchedule
+	}
+	return nil
+}
+
+// optionalTime turns the zero time into nil. Times are kept in UTC so the
+// schedule compares correctly on databases that store them as text.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+func NewSliderUsecase(repo repository.SliderRepository, unitOfWork uow.UnitOfWork, storage storage.Storage, inspector *checker.Inspector, maxImageSize int64) usecase.SliderUsecase {
+	policy := SliderImagePolicy
+	policy.MaxSize = maxImageSize
+
+	return &SliderUsecaseStruct{
+		Repo:        repo,
+		UoW:         unitOfWork,
+		Storage:     storage,
+		Inspector:   inspector,
+		ImagePolicy: policy,
+		Variants:    SliderVariants,
+		JPEGQuality: 85,
+		Now:         time.Now,
+	}
+}
@@ -0,0 +1,326 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+	"github.com/celpung/gocleanarch/application/slider/domain/usecase"
+	usecase_impl "github.com/celpung/gocleanarch/application/slider/impl/usecase"
+	slider_router "github.com/celpung/gocleanarch/delivery/std/chi/slider/router"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide (Windows / macOS / Linux)
+
+1) Run all tests in this package from the folder containing this file:
+     go test -v .
+
+2) Run a specific test using a regex:
+     go test -v -run ^TestSliderDisplay_ .
+     go test -v -run ^TestSliderRoutes_PublishAndReorder$ .
+
+Notes:
+- The use case clock is replaced through SliderUsecaseStruct.Now, so the
+  scheduling tests do not depend on the time they run at.
+- Public reads pass entity.SliderQuery{Public: true}; admin reads pass the
+  zero query and see every slider.
+===============================================================================
+*/
+
+// clockUsecase is newSliderUsecase with its clock fixed at now.
+func clockUsecase(t *testing.T, now time.Time) usecase.SliderUsecase {
+	t.Helper()
+
+	uc := newSliderUsecase(t, setupTestDB(t))
+	uc.(*usecase_impl.SliderUsecaseStruct).Now = func() time.Time { return now }
+	return uc
+}
+
+func titles(sliders []*entity.Slider) []string {
+	out := make([]string, 0, len(sliders))
+	for _, s := range sliders {
+		out = append(out, s.Title)
+	}
+	return out
+}
+
+/*
+TestSliderDisplay_Reorder verifies that new sliders are appended to the
+display order and that Reorder only accepts the complete list of sliders,
+leaving the order untouched when it is rejected.
+*/
+func TestSliderDisplay_Reorder(t *testing.T) {
+	ctx := context.Background()
+	uc := newSliderUsecase(t, setupTestDB(t))
+
+	var ids []string
+	for i, title := range []string{"A", "B", "C"} {
+		s, err := uc.Create(ctx, &entity.Slider{Title: title, Description: title, File: "/images/" + title + ".png"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, i+1, s.Position)
+		ids = append(ids, s.ID)
+	}
+
+	require.NoError(t, uc.Reorder(ctx, []string{ids[2], ids[0], ids[1]}))
+	sliders, _, err := uc.Read(ctx, entity.SliderQuery{}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"C", "A", "B"}, titles(sliders))
+
+	require.ErrorIs(t, uc.Reorder(ctx, []string{ids[0], ids[1]}), entity.ErrOrderMismatch)
+	require.ErrorIs(t, uc.Reorder(ctx, []string{ids[0], ids[0], ids[1]}), entity.ErrOrderMismatch)
+	require.ErrorIs(t, uc.Reorder(ctx, []string{ids[0], ids[1], "missing"}), entity.ErrOrderMismatch)
+
+	sliders, _, err = uc.Read(ctx, entity.SliderQuery{}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"C", "A", "B"}, titles(sliders))
+}
+
+/*
+TestSliderDisplay_PublicReadsOnlyActive verifies that public reads skip
+unpublished, not yet started and ended sliders, while admin reads list all
+of them.
+*/
+func TestSliderDisplay_PublicReadsOnlyActive(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	uc := clockUsecase(t, now)
+
+	hour := time.Hour
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	create := func(title string, published bool, startsAt, endsAt *time.Time) *entity.Slider {
+		s, err := uc.Create(ctx, &entity.Slider{
+			Title: title, Description: title, File: "/images/" + title + ".png",
+			Published: published, StartsAt: startsAt, EndsAt: endsAt,
+		}, nil)
+		require.NoError(t, err)
+		return s
+	}
+
+	create("always", true, nil, nil)
+	hidden := create("hidden", false, nil, nil)
+	upcoming := create("upcoming", true, at(hour), nil)
+	create("ended", true, nil, at(-hour))
+	create("running", true, at(-hour), at(hour))
+	create("zero", true, &time.Time{}, &time.Time{})
+
+	public, total, err := uc.Read(ctx, entity.SliderQuery{Public: true}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), total)
+	require.Equal(t, []string{"always", "running", "zero"}, titles(public))
+
+	_, total, err = uc.Read(ctx, entity.SliderQuery{}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), total)
+
+	_, err = uc.ReadByID(ctx, hidden.ID, entity.SliderQuery{Public: true})
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+
+	published, err := uc.SetPublished(ctx, hidden.ID, true)
+	require.NoError(t, err)
+	require.True(t, published.Published)
+
+	_, err = uc.ReadByID(ctx, hidden.ID, entity.SliderQuery{Public: true})
+	require.NoError(t, err)
+
+	/* Clearing the start of the schedule makes the upcoming slider visible. */
+	_, err = uc.Update(ctx, &entity.UpdateSliderPayload{ID: upcoming.ID, StartsAt: &time.Time{}})
+	require.NoError(t, err)
+
+	public, _, err = uc.Read(ctx, entity.SliderQuery{Public: true}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"always", "hidden", "upcoming", "running", "zero"}, titles(public))
+}
+
+/*
+TestSliderDisplay_Localize verifies that public reads use the exact locale,
+then its language, then the default text, and that admins get every
+translation.
+*/
+func TestSliderDisplay_Localize(t *testing.T) {
+	ctx := context.Background()
+	uc := newSliderUsecase(t, setupTestDB(t))
+
+	created, err := uc.Create(ctx, &entity.Slider{
+		Title: "Summer sale", Description: "Up to 50% off", File: "/images/summer.png", Published: true,
+		Translations: map[string]entity.Translation{
+			"ID":    {Title: " Diskon musim panas ", Description: "Hingga 50%"},
+			"en-gb": {Title: "Summer sale, innit", Description: "Up to 50% off"},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, created.Translations, 2)
+	require.Equal(t, "Diskon musim panas", created.Translations["id"].Title)
+
+	read := func(locale string) *entity.Slider {
+		s, err := uc.ReadByID(ctx, created.ID, entity.SliderQuery{Public: true, Locale: locale})
+		require.NoError(t, err)
+		require.Nil(t, s.Translations)
+		return s
+	}
+
+	require.Equal(t, "Diskon musim panas", read("id-ID").Title)
+	require.Equal(t, "id", read("id-ID").Locale)
+	require.Equal(t, "Summer sale, innit", read("en-GB").Title)
+	require.Equal(t, "Summer sale", read("en-US").Title)
+	require.Empty(t, read("fr").Locale)
+
+	/* A non-nil map replaces every translation. */
+	updated, err := uc.Update(ctx, &entity.UpdateSliderPayload{
+		ID:           created.ID,
+		Translations: map[string]entity.Translation{"fr": {Title: "Soldes d'été"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]entity.Translation{"fr": {Title: "Soldes d'été"}}, updated.Translations)
+	require.Equal(t, "Summer sale", read("id").Title)
+	require.Equal(t, "Soldes d'été", read("fr").Title)
+}
+
+/*
+TestSliderDisplay_RejectsInvalidInput verifies that bad links, schedules
+that end before they start and malformed locales are rejected on create and
+update.
+*/
+func TestSliderDisplay_RejectsInvalidInput(t *testing.T) {
+	ctx := context.Background()
+	uc := newSliderUsecase(t, setupTestDB(t))
+
+	base := entity.Slider{Title: "A", Description: "a", File: "/images/a.png"}
+	create := func(edit func(*entity.Slider)) error {
+		s := base
+		edit(&s)
+		_, err := uc.Create(ctx, &s, nil)
+		return err
+	}
+
+	for _, link := range []string{"javascript:alert(1)", "//evil.example", "ftp://example.com/a", "https://"} {
+		require.ErrorIs(t, create(func(s *entity.Slider) { s.LinkURL = link }), entity.ErrInvalidLink, link)
+	}
+	for _, link := range []string{"/promo", "https://example.com/promo?ref=slider"} {
+		require.NoError(t, create(func(s *entity.Slider) { s.LinkURL = link }), link)
+	}
+
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Minute)
+	require.ErrorIs(t, create(func(s *entity.Slider) { s.StartsAt, s.EndsAt = &start, &end }), entity.ErrInvalidSchedule)
+	require.ErrorIs(t, create(func(s *entity.Slider) {
+		s.Translations = map[string]entity.Translation{"english!": {Title: "x"}}
+	}), entity.ErrInvalidLocale)
+
+	scheduled, err := uc.Create(ctx, &entity.Slider{Title: "B", Description: "b", File: "/images/b.png", StartsAt: &start}, nil)
+	require.NoError(t, err)
+
+	/* The new end is checked against the start that is already stored. */
+	_, err = uc.Update(ctx, &entity.UpdateSliderPayload{ID: scheduled.ID, EndsAt: &end})
+	require.ErrorIs(t, err, entity.ErrInvalidSchedule)
+
+	bad := "mailto:sales@example.com"
+	_, err = uc.Update(ctx, &entity.UpdateSliderPayload{ID: scheduled.ID, LinkURL: &bad})
+	require.ErrorIs(t, err, entity.ErrInvalidLink)
+
+	_, err = uc.Update(ctx, &entity.UpdateSliderPayload{ID: "missing", Translations: map[string]entity.Translation{}})
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+}
+
+/*
+TestSliderRoutes_PublishAndReorder verifies the admin display routes: the
+admin listing, publish and unpublish, reordering and localized public
+reads.
+*/
+func TestSliderRoutes_PublishAndReorder(t *testing.T) {
+	useSliderGlobals(t)
+
+	r := chi.NewRouter()
+	slider_router.Router(r)
+
+	do := func(method, path, authorization, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	type listed struct {
+		Data struct {
+			Count   int64 `json:"count"`
+			Sliders []struct {
+				ID     string `json:"id"`
+				Title  string `json:"title"`
+				Locale string `json:"locale"`
+			} `json:"sliders"`
+		} `json:"data"`
+	}
+	list := func(path, authorization string, headers ...string) listed {
+		rec := do(http.MethodGet, path, authorization, "", headers...)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var out listed
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+		return out
+	}
+
+	create := func(body string) string {
+		rec := do(http.MethodPost, "/sliders", adminToken(t), body)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var created struct {
+			Slider struct {
+				ID string `json:"id"`
+			} `json:"slider"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		return created.Slider.ID
+	}
+
+	first := create(`{"title":"First","description":"1","file":"/images/1.png","link_url":"/promo",
+		"translations":{"id":{"title":"Pertama","description":"1"}}}`)
+	draft := create(`{"title":"Draft","description":"2","file":"/images/2.png","published":false}`)
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/sliders", adminToken(t),
+		`{"title":"Bad","description":"x","file":"/images/x.png","starts_at":"tomorrow"}`).Code)
+
+	require.Equal(t, int64(1), list("/sliders", "").Data.Count)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/sliders/all", "", "").Code)
+	require.Equal(t, int64(2), list("/sliders/all", adminToken(t)).Data.Count)
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/sliders/"+draft, "", "").Code)
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/sliders/"+draft+"/publish", "", "").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/sliders/"+draft+"/publish", adminToken(t), "").Code)
+	require.Equal(t, int64(2), list("/sliders", "").Data.Count)
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/sliders/order", adminToken(t), `{"ids":["`+draft+`"]}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/sliders/order", adminToken(t), `{"ids":[]}`).Code)
+	rec := do(http.MethodPut, "/sliders/order", adminToken(t), `{"ids":["`+draft+`","`+first+`"]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	ordered := list("/sliders", "", "Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+	require.Len(t, ordered.Data.Sliders, 2)
+	require.Equal(t, "Draft", ordered.Data.Sliders[0].Title)
+	require.Equal(t, "Pertama", ordered.Data.Sliders[1].Title)
+	require.Equal(t, "id", ordered.Data.Sliders[1].Locale)
+	require.Equal(t, "First", list("/sliders?locale=en", "", "Accept-Language", "id").Data.Sliders[1].Title)
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/sliders/"+first+"/unpublish", adminToken(t), "").Code)
+	require.Equal(t, int64(1), list("/sliders", "").Data.Count)
+	require.Equal(t, http.StatusNotFound, do(http.MethodPost, "/sliders/missing/publish", adminToken(t), "").Code)
+}
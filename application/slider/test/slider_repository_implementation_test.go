@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+	repository_impl "github.com/celpung/gocleanarch/application/slider/impl/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/db/sqlite"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+===============================================================================
+Test Execution Guide (Windows / macOS / Linux)
+
+1) Install dependencies at the project root:
+     go get github.com/glebarez/sqlite gorm.io/gorm github.com/stretchr/testify
+     go mod tidy
+
+2) Run all tests in this package from the folder containing this file:
+     go test -v .
+
+3) Run a specific test using a regex:
+     go test -v -run ^TestCreateSlider$ .
+     go test -v -run 'Test(UpdateSliderFields|SoftDeleteSlider)$' .
+
+4) Run the entire repository test suite from the project root:
+     go test -v ./...
+
+5) Coverage and race detection (optional):
+     go test -race -cover .
+
+Notes:
+- setupTestDB migrates an in-memory SQLite database with the embedded
+  migrations, so the sliders table matches production.
+- Soft deleted sliders must behave exactly like missing ones.
+===============================================================================
+*/
+
+// makeSlider constructs a minimal valid slider model for test scenarios.
+func makeSlider(title string) *model.Slider {
+	return &model.Slider{
+		Title:       title,
+		Description: title + " description",
+		File:        "/images/" + title + ".png",
+	}
+}
+
+/*
+TestCreateSlider verifies that a slider is persisted with a generated identifier.
+*/
+func TestCreateSlider(t *testing.T) {
+	ctx := context.Background()
+	repo := repository_impl.NewSliderRepository(setupTestDB(t))
+
+	saved, err := repo.Create(ctx, makeSlider("summer"))
+	require.NoError(t, err)
+	require.NotEmpty(t, saved.ID, "expected generated ID to be non-empty")
+
+	got, err := repo.ReadByID(ctx, saved.ID)
+	require.NoError(t, err)
+	require.Equal(t, "summer", got.Title)
+	require.Equal(t, "/images/summer.png", got.File)
+}
+
+/*
+TestCreateSlider_ConcurrentPositions verifies that sliders created at the
+same time on separate connections each get their own position.
+*/
+func TestCreateSlider_ConcurrentPositions(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite.SetupDB(filepath.Join(t.TempDir(), "sliders.db"))
+	require.NoError(t, err)
+	repo := repository_impl.NewSliderRepository(db)
+
+	const n = 8
+	var g errgroup.Group
+	for i := range n {
+		g.Go(func() error {
+			_, err := repo.Create(ctx, makeSlider(fmt.Sprintf("slide-%d", i)))
+			return err
+		})
+	}
+	require.NoError(t, g.Wait())
+
+	var positions []int
+	require.NoError(t, db.Model(&model.Slider{}).Order("position").Pluck("position", &positions).Error)
+	require.Len(t, positions, n)
+	for i, p := range positions {
+		require.Equal(t, i+1, p, "positions must be distinct and contiguous")
+	}
+}
+
+/*
+TestReadSlidersPaginated verifies that Read returns sliders in display
+order, one page at a time, together with the total count.
+*/
+func TestReadSlidersPaginated(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	repo := repository_impl.NewSliderRepository(db)
+
+	base := time.Now().Add(-time.Hour)
+	for i := range 5 {
+		s := makeSlider(fmt.Sprintf("slide-%d", i))
+		s.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		_, err := repo.Create(ctx, s)
+		require.NoError(t, err)
+	}
+
+	first, total, err := repo.Read(ctx, 1, 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), total)
+	require.Len(t, first, 2)
+	require.Equal(t, "slide-0", first[0].Title)
+	require.Equal(t, 1, first[0].Position)
+	require.Equal(t, "slide-1", first[1].Title)
+
+	last, _, err := repo.Read(ctx, 3, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, last, 1)
+	require.Equal(t, "slide-4", last[0].Title)
+}
+
+/*
+TestReadByIDSliderNotFound verifies that an unknown identifier is reported
+as entity.ErrSliderNotFound.
+*/
+func TestReadByIDSliderNotFound(t *testing.T) {
+	repo := repository_impl.NewSliderRepository(setupTestDB(t))
+
+	_, err := repo.ReadByID(context.Background(), "missing")
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+}
+
+/*
+TestUpdateSliderFields verifies that only the given columns change and that
+updating an unknown slider returns entity.ErrSliderNotFound.
+*/
+func TestUpdateSliderFields(t *testing.T) {
+	ctx := context.Background()
+	repo := repository_impl.NewSliderRepository(setupTestDB(t))
+
+	saved, err := repo.Create(ctx, makeSlider("winter"))
+	require.NoError(t, err)
+
+	updated, err := repo.UpdateFields(ctx, saved.ID, map[string]any{"title": "Winter sale"})
+	require.NoError(t, err)
+	require.Equal(t, "Winter sale", updated.Title)
+	require.Equal(t, "winter description", updated.Description)
+
+	_, err = repo.UpdateFields(ctx, "missing", map[string]any{"title": "x"})
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+}
+
+/*
+TestSoftDeleteSlider verifies that a soft deleted slider disappears from
+reads and listings, and cannot be deleted or updated a second time.
+*/
+func TestSoftDeleteSlider(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	repo := repository_impl.NewSliderRepository(db)
+
+	saved, err := repo.Create(ctx, makeSlider("autumn"))
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SoftDelete(ctx, saved.ID))
+
+	_, err = repo.ReadByID(ctx, saved.ID)
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+
+	sliders, total, err := repo.Read(ctx, 1, 10, nil)
+	require.NoError(t, err)
+	require.Zero(t, total)
+	require.Empty(t, sliders)
+
+	require.ErrorIs(t, repo.SoftDelete(ctx, saved.ID), entity.ErrSliderNotFound)
+	_, err = repo.UpdateFields(ctx, saved.ID, map[string]any{"title": "x"})
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+
+	/* The row is kept for auditing, only hidden by deleted_at. */
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&model.Slider{}).Where("id = ?", saved.ID).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+}
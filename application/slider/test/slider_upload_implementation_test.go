@@ -0,0 +1,379 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+	"github.com/celpung/gocleanarch/application/slider/domain/usecase"
+	repository_impl "github.com/celpung/gocleanarch/application/slider/impl/repository"
+	usecase_impl "github.com/celpung/gocleanarch/application/slider/impl/usecase"
+	slider_router "github.com/celpung/gocleanarch/delivery/std/chi/slider/router"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide (Windows / macOS / Linux)
+
+1) Run all tests in this package from the folder containing this file:
+     go test -v .
+
+2) Run a specific test using a regex:
+     go test -v -run ^TestSliderUpload_ .
+     go test -v -run ^TestLocalStorage_RejectsEscapingKeys$ .
+
+Notes:
+- Uploads are written to a LocalStorage below t.TempDir(), so tests can
+  inspect the files and leave nothing behind.
+- Images are generated in memory; no fixtures are needed.
+===============================================================================
+*/
+
+const testUploadLimit = 1 << 20
+
+// newSliderUsecase builds the use case on db with uploads in a temporary
+// directory.
+func newSliderUsecase(t *testing.T, db *gorm.DB) usecase.SliderUsecase {
+	uc, _ := newUploadUsecase(t, db)
+	return uc
+}
+
+// newUploadUsecase is newSliderUsecase that also returns the storage root.
+func newUploadUsecase(t *testing.T, db *gorm.DB) (usecase.SliderUsecase, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	store := storage_impl.NewLocalStorage(root, "/images")
+	repository := repository_impl.NewSliderRepository(db)
+	return usecase_impl.NewSliderUsecase(repository, uow_impl.NewGormUnitOfWork(db), store, checker.NewInspector(nil), testUploadLimit), root
+}
+
+// useSliderGlobals points database.DB, the shared storage and the shared
+// inspector at test instances for routers, and restores them afterwards.
+func useSliderGlobals(t *testing.T) string {
+	t.Helper()
+
+	prevDB, prevStorage, prevSize, prevInspector := database.DB, storage_impl.Shared, storage_impl.MaxUploadSize, checker.Shared
+	t.Cleanup(func() {
+		database.DB, storage_impl.Shared, storage_impl.MaxUploadSize, checker.Shared = prevDB, prevStorage, prevSize, prevInspector
+	})
+
+	root := t.TempDir()
+	database.DB = setupTestDB(t)
+	storage_impl.Shared = storage_impl.NewLocalStorage(root, "/images")
+	storage_impl.MaxUploadSize = testUploadLimit
+	checker.Shared = checker.NewInspector(nil)
+	return root
+}
+
+// infectedScanner reports every file as malware.
+type infectedScanner struct{}
+
+func (infectedScanner) Scan(ctx context.Context, content io.Reader) error {
+	return fmt.Errorf("%w: Eicar-Test-Signature", checker.ErrInfected)
+}
+
+// withComment adds a tEXt chunk holding comment right after the IHDR chunk
+// of a PNG.
+func withComment(content []byte, comment string) []byte {
+	data := "Comment\x00" + comment
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, "tEXt"+data...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	out := append([]byte(nil), content[:33]...)
+	out = append(out, chunk...)
+	return append(out, content[33:]...)
+}
+
+// storedCopies is how many files an upload leaves with SliderVariants:
+// the original, a JPEG per variant and a WebP for medium and thumbnail.
+const storedCopies = 1 + 3 + 2
+
+// imageURL returns the URL of the copy of variant in format, or "".
+func imageURL(images []entity.StoredImage, variant, format string) string {
+	for _, img := range images {
+		if img.Variant == variant && img.Format == format {
+			return img.URL
+		}
+	}
+	return ""
+}
+
+// pngImage encodes a width x height PNG.
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func uploadOf(content []byte) *entity.Image {
+	return &entity.Image{Filename: "slide.png", Size: int64(len(content)), Content: bytes.NewReader(content)}
+}
+
+// storedFiles lists the files below root as slash separated keys, leaving
+// out the hidden metadata LocalStorage keeps next to them.
+func storedFiles(t *testing.T, root string) []string {
+	t.Helper()
+
+	var keys []string
+	require.NoError(t, filepath.WalkDir(root, func(name string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		keys = append(keys, filepath.ToSlash(rel))
+		return err
+	}))
+	return keys
+}
+
+// storedWidth decodes the width of the stored file behind url.
+func storedWidth(t *testing.T, root, url string) int {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(url, "/images/"))))
+	require.NoError(t, err)
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	require.NoError(t, err)
+	return cfg.Width
+}
+
+/*
+TestSliderUpload_StoresOriginalAndVariants verifies that an uploaded image is
+stored as uploaded plus every format of every variant, scaled down but never
+up, that only the smaller variants get a WebP copy, and that the slider
+links all of them by variant and format.
+*/
+func TestSliderUpload_StoresOriginalAndVariants(t *testing.T) {
+	ctx := context.Background()
+	uc, root := newUploadUsecase(t, setupTestDB(t))
+
+	created, err := uc.Create(ctx, &entity.Slider{Title: "Launch", Description: "Launch week"}, uploadOf(pngImage(t, 1200, 600)))
+	require.NoError(t, err)
+
+	require.Len(t, storedFiles(t, root), storedCopies)
+	require.Len(t, created.Images, storedCopies)
+	require.Equal(t, imageURL(created.Images, "original", "png"), created.File)
+	require.True(t, strings.HasPrefix(created.ImageKey, "sliders/"+created.ID+"/"))
+	require.True(t, strings.HasSuffix(created.ImageKey, "/original.png"))
+
+	require.Equal(t, 1200, storedWidth(t, root, imageURL(created.Images, "original", "png")))
+	require.Equal(t, 1200, storedWidth(t, root, imageURL(created.Images, "large", "jpeg")), "small images are not upscaled")
+	require.Empty(t, imageURL(created.Images, "large", "webp"), "lossless WebP would outweigh the JPEG")
+	require.Equal(t, 1024, storedWidth(t, root, imageURL(created.Images, "medium", "jpeg")))
+	require.Equal(t, 320, storedWidth(t, root, imageURL(created.Images, "thumbnail", "jpeg")))
+	require.True(t, strings.HasSuffix(imageURL(created.Images, "thumbnail", "webp"), "/thumbnail.webp"))
+	require.Equal(t, 320, storedWidth(t, root, imageURL(created.Images, "thumbnail", "webp")))
+	require.Equal(t, 1024, storedWidth(t, root, imageURL(created.Images, "medium", "webp")))
+
+	got, err := uc.ReadByID(ctx, created.ID, entity.SliderQuery{})
+	require.NoError(t, err)
+	require.Equal(t, created.Images, got.Images)
+
+	/* The original is stored without its metadata. */
+	tagged, err := uc.Create(ctx, &entity.Slider{Title: "Tagged", Description: "Tagged"}, uploadOf(withComment(pngImage(t, 40, 20), "GPS 52.5200N 13.4050E")))
+	require.NoError(t, err)
+	original, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(tagged.ImageKey)))
+	require.NoError(t, err)
+	require.NotContains(t, string(original), "GPS")
+}
+
+/*
+TestSliderUpload_ReplaceAndDeleteRemoveFiles verifies that replacing an
+uploaded image, or switching to an external URL, removes the old files, and
+that deleting the slider removes the current ones.
+*/
+func TestSliderUpload_ReplaceAndDeleteRemoveFiles(t *testing.T) {
+	ctx := context.Background()
+	uc, root := newUploadUsecase(t, setupTestDB(t))
+
+	created, err := uc.Create(ctx, &entity.Slider{Title: "Launch", Description: "Launch week"}, uploadOf(pngImage(t, 400, 200)))
+	require.NoError(t, err)
+
+	replaced, err := uc.Update(ctx, &entity.UpdateSliderPayload{ID: created.ID, Image: uploadOf(pngImage(t, 500, 250))})
+	require.NoError(t, err)
+	require.NotEqual(t, created.ImageKey, replaced.ImageKey)
+
+	files := storedFiles(t, root)
+	require.Len(t, files, storedCopies)
+	for _, key := range files {
+		require.True(t, strings.HasPrefix(key, filepath.ToSlash(filepath.Dir(replaced.ImageKey))+"/"), "old upload should be gone, found %s", key)
+	}
+
+	require.NoError(t, uc.SoftDelete(ctx, created.ID))
+	require.Empty(t, storedFiles(t, root))
+
+	/* An external file URL also releases an uploaded image. */
+	other, err := uc.Create(ctx, &entity.Slider{Title: "Other", Description: "Other"}, uploadOf(pngImage(t, 100, 100)))
+	require.NoError(t, err)
+	external := "https://cdn.example.com/other.jpg"
+	switched, err := uc.Update(ctx, &entity.UpdateSliderPayload{ID: other.ID, File: &external})
+	require.NoError(t, err)
+	require.Equal(t, external, switched.File)
+	require.Empty(t, switched.Images)
+	require.Empty(t, storedFiles(t, root))
+}
+
+/*
+TestSliderUpload_RejectsInvalidUploads verifies the size limit, that content
+which is not an image or fails inspection is refused, that a slider needs an
+image or URL, and that nothing is stored for rejected uploads.
+*/
+func TestSliderUpload_RejectsInvalidUploads(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	uc, root := newUploadUsecase(t, db)
+	slider := &entity.Slider{Title: "Launch", Description: "Launch week"}
+
+	_, err := uc.Create(ctx, slider, nil)
+	require.ErrorIs(t, err, entity.ErrImageRequired)
+
+	_, err = uc.Create(ctx, slider, uploadOf([]byte("%PDF-1.4 not an image")))
+	require.ErrorIs(t, err, entity.ErrInvalidImage)
+
+	/* The declared size may be wrong, so the bytes read count too. */
+	big := bytes.Repeat([]byte{0}, testUploadLimit+1)
+	_, err = uc.Create(ctx, slider, &entity.Image{Filename: "big.png", Size: 10, Content: bytes.NewReader(big)})
+	require.ErrorIs(t, err, entity.ErrImageTooLarge)
+
+	/* Whatever the inspector refuses, like malware, is an invalid image too. */
+	scanned := usecase_impl.NewSliderUsecase(repository_impl.NewSliderRepository(db), uow_impl.NewGormUnitOfWork(db),
+		storage_impl.NewLocalStorage(root, "/images"), checker.NewInspector(infectedScanner{}), testUploadLimit)
+	_, err = scanned.Create(ctx, slider, uploadOf(pngImage(t, 32, 32)))
+	require.ErrorIs(t, err, entity.ErrInvalidImage)
+	require.ErrorIs(t, err, checker.ErrInfected)
+
+	require.Empty(t, storedFiles(t, root))
+}
+
+/*
+TestLocalStorage_RejectsEscapingKeys verifies that keys cannot leave the
+storage root or name hidden metadata files, and that deleting missing keys
+is not an error.
+*/
+func TestLocalStorage_RejectsEscapingKeys(t *testing.T) {
+	ctx := context.Background()
+	store := storage_impl.NewLocalStorage(t.TempDir(), "/images/")
+
+	for _, key := range []string{"", "/etc/passwd", "../outside.txt", "a/../../outside.txt", `a\..\b`, "a/.b.txt.meta"} {
+		require.ErrorIs(t, store.Put(ctx, key, strings.NewReader("x"), "text/plain"), storage.ErrInvalidKey, key)
+	}
+
+	require.NoError(t, store.Put(ctx, "a/b/c.txt", strings.NewReader("x"), "text/plain"))
+	require.Equal(t, "/images/a/b/c.txt", store.URL("a/b/c.txt"))
+	require.NoError(t, store.Delete(ctx, "a/b/c.txt", "a/b/missing.txt"))
+	require.Empty(t, storedFiles(t, store.Root), "empty directories are pruned")
+}
+
+/*
+TestSliderUpload_MultipartRoutes verifies that the chi routes accept a
+multipart upload, run it through the upload inspector and report the
+stored image URLs.
+*/
+func TestSliderUpload_MultipartRoutes(t *testing.T) {
+	root := useSliderGlobals(t)
+
+	r := chi.NewRouter()
+	slider_router.Router(r)
+
+	form := func(fields map[string]string, filename string, content []byte) (*bytes.Buffer, string) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		for k, v := range fields {
+			require.NoError(t, mw.WriteField(k, v))
+		}
+		if content != nil {
+			part, err := mw.CreateFormFile("image", filename)
+			require.NoError(t, err)
+			_, err = part.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+		return &body, mw.FormDataContentType()
+	}
+	do := func(method, path string, body *bytes.Buffer, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", adminToken(t))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	fields := map[string]string{"title": "Launch", "description": "Launch week"}
+
+	body, ct := form(fields, "notes.txt", []byte("plain text is not an image"))
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/sliders", body, ct).Code)
+
+	body, ct = form(fields, "", nil)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/sliders", body, ct).Code, "an image or file URL is required")
+
+	body, ct = form(fields, "launch.png", pngImage(t, 640, 320))
+	rec := do(http.MethodPost, "/sliders", body, ct)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		Slider struct {
+			ID     string `json:"id"`
+			File   string `json:"file"`
+			Images []struct {
+				Variant string `json:"variant"`
+				Format  string `json:"format"`
+				URL     string `json:"url"`
+			} `json:"images"`
+		} `json:"slider"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Len(t, created.Slider.Images, storedCopies)
+	thumbnail := created.Slider.Images[len(created.Slider.Images)-2]
+	require.Equal(t, []string{"thumbnail", "jpeg"}, []string{thumbnail.Variant, thumbnail.Format})
+	require.Equal(t, 320, storedWidth(t, root, thumbnail.URL))
+
+	/* A multipart update without the title keeps it. */
+	body, ct = form(map[string]string{"description": "Updated"}, "next.png", pngImage(t, 200, 100))
+	rec = do(http.MethodPatch, "/sliders/"+created.Slider.ID, body, ct)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"title":"Launch"`)
+	require.Contains(t, rec.Body.String(), `"description":"Updated"`)
+	require.NotContains(t, rec.Body.String(), created.Slider.File)
+	require.Len(t, storedFiles(t, root), storedCopies)
+
+	body, ct = form(fields, "huge.png", bytes.Repeat([]byte{0x89}, testUploadLimit+2<<20))
+	require.Equal(t, http.StatusRequestEntityTooLarge, do(http.MethodPost, "/sliders", body, ct).Code)
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+	user_entity "github.com/celpung/gocleanarch/application/user/domain/entity"
+	slider_router "github.com/celpung/gocleanarch/delivery/std/chi/slider/router"
+	"github.com/celpung/gocleanarch/infrastructure/auth"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide (Windows / macOS / Linux)
+
+1) Run all tests in this package from the folder containing this file:
+     go test -v .
+
+2) Run a specific test using a regex:
+     go test -v -run ^TestSliderUsecase_ .
+     go test -v -run ^TestSliderRoutes_AdminOnlyMutations$ .
+
+Notes:
+- The use case tests run against the real GORM repository on in-memory SQLite.
+- The route test mounts the chi slider router, which reads database.DB, and
+  signs tokens with the JWT service the login flow uses.
+===============================================================================
+*/
+
+/*
+TestSliderUsecase_CreateTrimsInput verifies that Create stores trimmed values
+and returns the generated identifier and timestamps.
+*/
+func TestSliderUsecase_CreateTrimsInput(t *testing.T) {
+	ctx := context.Background()
+	uc := newSliderUsecase(t, setupTestDB(t))
+
+	created, err := uc.Create(ctx, &entity.Slider{
+		Title:       "  Spring  ",
+		Description: " New arrivals ",
+		File:        " /images/spring.png ",
+	}, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, created.ID)
+	require.Equal(t, "Spring", created.Title)
+	require.Equal(t, "New arrivals", created.Description)
+	require.Equal(t, "/images/spring.png", created.File)
+	require.False(t, created.CreatedAt.IsZero())
+}
+
+/*
+TestSliderUsecase_PartialUpdate verifies that Update only touches the fields
+that were given, and that an empty payload returns the slider unchanged.
+*/
+func TestSliderUsecase_PartialUpdate(t *testing.T) {
+	ctx := context.Background()
+	uc := newSliderUsecase(t, setupTestDB(t))
+
+	created, err := uc.Create(ctx, &entity.Slider{Title: "Spring", Description: "New arrivals", File: "/images/spring.png"}, nil)
+	require.NoError(t, err)
+
+	title := " Spring sale "
+	updated, err := uc.Update(ctx, &entity.UpdateSliderPayload{ID: created.ID, Title: &title})
+	require.NoError(t, err)
+	require.Equal(t, "Spring sale", updated.Title)
+	require.Equal(t, "New arrivals", updated.Description)
+
+	same, err := uc.Update(ctx, &entity.UpdateSliderPayload{ID: created.ID})
+	require.NoError(t, err)
+	require.Equal(t, "Spring sale", same.Title)
+
+	_, err = uc.Update(ctx, &entity.UpdateSliderPayload{ID: "missing", Title: &title})
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+}
+
+/*
+TestSliderUsecase_ReadAndDelete verifies listing, lookups and soft delete
+through the use case.
+*/
+func TestSliderUsecase_ReadAndDelete(t *testing.T) {
+	ctx := context.Background()
+	uc := newSliderUsecase(t, setupTestDB(t))
+
+	a, err := uc.Create(ctx, &entity.Slider{Title: "A", Description: "a", File: "/images/a.png"}, nil)
+	require.NoError(t, err)
+	_, err = uc.Create(ctx, &entity.Slider{Title: "B", Description: "b", File: "/images/b.png"}, nil)
+	require.NoError(t, err)
+
+	sliders, total, err := uc.Read(ctx, entity.SliderQuery{}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total)
+	require.Len(t, sliders, 2)
+
+	require.NoError(t, uc.SoftDelete(ctx, a.ID))
+	_, err = uc.ReadByID(ctx, a.ID, entity.SliderQuery{})
+	require.ErrorIs(t, err, entity.ErrSliderNotFound)
+
+	_, total, err = uc.Read(ctx, entity.SliderQuery{}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+}
+
+// roleToken is an Authorization header for a user with role.
+func roleToken(t *testing.T, role string) string {
+	t.Helper()
+
+	tok, err := auth.NewJwtService().JWTGenerator(user_entity.User{ID: "u-" + role, Email: role + "@example.com", Role: role})
+	require.NoError(t, err)
+	return "Bearer " + tok
+}
+
+func adminToken(t *testing.T) string {
+	return roleToken(t, "ADMIN")
+}
+
+/*
+TestSliderRoutes_AdminOnlyMutations verifies that anyone can read sliders,
+that creating one needs an admin token, and that unknown IDs return 404.
+*/
+func TestSliderRoutes_AdminOnlyMutations(t *testing.T) {
+	useSliderGlobals(t)
+
+	r := chi.NewRouter()
+	slider_router.Router(r)
+
+	do := func(method, path, authorization, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"title":"Launch","description":"Launch week","file":"/images/launch.png"}`
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/sliders", "", body).Code)
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "/sliders", roleToken(t, "USER"), body).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/sliders", roleToken(t, "ADMIN"), `{"title":"Launch"}`).Code)
+
+	rec := do(http.MethodPost, "/sliders", roleToken(t, "ADMIN"), body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		Slider struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"slider"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, "Launch", created.Slider.Title)
+
+	rec = do(http.MethodGet, "/sliders", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"count":1`)
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/sliders/"+created.Slider.ID, "", "").Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/sliders/missing", "", "").Code)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodDelete, "/sliders/"+created.Slider.ID, "", "").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodDelete, "/sliders/"+created.Slider.ID, roleToken(t, "SUPER"), "").Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodPatch, "/sliders/"+created.Slider.ID, roleToken(t, "ADMIN"), `{"title":"x"}`).Code)
+}
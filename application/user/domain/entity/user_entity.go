@@ -1,19 +1,38 @@
 package entity
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 type User struct {
-	ID        string
-	Name      string
-	Email     string
-	Password  string
-	Active    bool
-	Role      string
+	ID       string
+	Name     string
+	Email    string
+	Password string
+	Active   bool
+	Role     string
+	// AvatarKey is the storage prefix of the uploaded avatar, empty when the
+	// user has none.
+	AvatarKey string
+	// Avatars maps avatar sizes such as "small" to URLs.
+	Avatars   map[string]string
+	Version   uint
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time
 }
 
+// Avatar is an uploaded profile picture that has not been stored yet.
+type Avatar struct {
+	Filename string
+	Size     int64
+	Content  io.Reader
+}
+
+// UpdateUserPayload changes the fields that are not nil. Version is the
+// version the caller last read; the update is rejected with a
+// VersionConflictError when the user has changed since. Zero skips the check.
 type UpdateUserPayload struct {
 	ID       string
 	Name     *string
@@ -21,4 +40,21 @@ type UpdateUserPayload struct {
 	Password *string
 	Active   *bool
 	Role     *string
+	Version  uint
+}
+
+// UserSearchResult is a ranked search match. Highlights maps a field name to
+// its HTML-escaped value with matched terms wrapped in <mark> tags.
+type UserSearchResult struct {
+	User       User
+	Score      float64
+	Highlights map[string]string
+}
+
+// UserFacetedSearchResult is a page of fuzzy search results with counts per
+// facet value (e.g. "role" -> "ADMIN" -> 3) over all matches.
+type UserFacetedSearchResult struct {
+	Results []*UserSearchResult
+	Total   int64
+	Facets  map[string]map[string]int
 }
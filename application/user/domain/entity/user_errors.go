@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/celpung/gocleanarch/application/apperror"
+)
+
+var (
+	// ErrUserNotFound is returned for missing and soft deleted users.
+	ErrUserNotFound = apperror.New(apperror.ErrNotFound, "user not found")
+	// ErrEmailTaken is returned when another user already has the email.
+	ErrEmailTaken = apperror.New(apperror.ErrConflict, "email is already registered")
+	// ErrInvalidCredentials is returned by Login for unknown emails and
+	// wrong passwords alike, so it does not reveal which accounts exist.
+	ErrInvalidCredentials = apperror.New(apperror.ErrInvalidCredentials, "invalid email or password")
+	// ErrUserInactive is returned by Login for deactivated users.
+	ErrUserInactive = apperror.New(apperror.ErrInactive, "user is not active")
+)
+
+var (
+	// ErrAvatarTooLarge is returned for avatars over the upload size limit.
+	ErrAvatarTooLarge = apperror.New(apperror.ErrTooLarge, "avatar exceeds the upload size limit")
+	// ErrInvalidAvatar is returned for avatars that are not a supported image.
+	ErrInvalidAvatar = apperror.New(apperror.ErrInvalid, "invalid avatar")
+)
+
+// ErrVersionConflict matches every VersionConflictError via errors.Is.
+var ErrVersionConflict = apperror.New(apperror.ErrPreconditionFailed, "user was modified concurrently")
+
+// VersionConflictError reports an update based on a stale version. Current
+// is the stored version the caller has to re-read before retrying.
+type VersionConflictError struct {
+	UserID   string
+	Expected uint
+	Current  uint
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("user %s: expected version %d, current version is %d", e.UserID, e.Expected, e.Current)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict || target == apperror.ErrPreconditionFailed
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+)
+
+type Name string
+
+// UserUpdated accompanies every update. The more specific events are
+// recorded next to it when an update changes what they describe.
+const (
+	UserRegistered  Name = "user.registered"
+	UserUpdated     Name = "user.updated"
+	UserActivated   Name = "user.activated"
+	UserDeactivated Name = "user.deactivated"
+	UserRoleChanged Name = "user.role_changed"
+	UserDeleted     Name = "user.deleted"
+)
+
+// Names lists every user event, e.g. to validate webhook subscriptions.
+var Names = []Name{UserRegistered, UserUpdated, UserActivated, UserDeactivated, UserRoleChanged, UserDeleted}
+
+// UserEvent describes a change to a user. ID identifies the event across
+// redeliveries. User is a snapshot taken after the change, without the
+// password; it is nil for deletions. Changed lists the fields an update
+// changed. ActorID and RequestID are copied from the request context and
+// are empty for changes made outside an authenticated request.
+type UserEvent struct {
+	ID         string
+	Name       Name
+	UserID     string
+	User       *entity.User
+	Changed    []string
+	ActorID    string
+	RequestID  string
+	OccurredAt time.Time
+}
+
+// Handler reacts to user events, e.g. to keep a search index up to date.
+type Handler interface {
+	HandleUserEvent(e UserEvent) error
+}
+
+// Publisher hands user events to whoever is interested. The use case only
+// publishes after the change has been stored.
+type Publisher interface {
+	Publish(e UserEvent)
+}
+
+// Outbox records events in the transaction carried by ctx, so they are
+// delivered to other services if and only if the change commits.
+type Outbox interface {
+	Record(ctx context.Context, events ...UserEvent) error
+}
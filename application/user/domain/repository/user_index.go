@@ -0,0 +1,30 @@
+package repository
+
+import "github.com/celpung/gocleanarch/infrastructure/db/model"
+
+// UserIndexQuery is a typo tolerant search over the embedded user index.
+// Role and Active are optional facet filters.
+type UserIndexQuery struct {
+	Text   string
+	Role   string
+	Active *bool
+	Page   uint
+	Limit  uint
+}
+
+// UserIndexResult holds one page of hits and facet counts over all matches.
+// Facets maps a facet name ("role", "active") to counts per value.
+type UserIndexResult struct {
+	Hits   []*UserSearchHit
+	Total  int64
+	Facets map[string]map[string]int
+}
+
+// UserIndex is a search index maintained next to the database. It is fed by
+// user events and can be rebuilt from the repository at any time.
+type UserIndex interface {
+	Index(user *model.User) error
+	Remove(userID string) error
+	Rebuild(users []*model.User) error
+	Search(q UserIndexQuery) (*UserIndexResult, error)
+}
@@ -1,17 +1,36 @@
 package repository
 
 import (
+	"context"
+	"errors"
+
 	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
 )
 
+// ErrVersionMismatch is returned by UpdateFields when the stored version is
+// not the expected one.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// ErrDuplicateEmail is returned by Create and UpdateFields when another user
+// already has the email.
+var ErrDuplicateEmail = errors.New("duplicate email")
+
+// ErrNotFound is returned for missing and soft deleted users.
+var ErrNotFound = errors.New("user not found")
+
 type UserRepository interface {
-	Create(user *model.User) (*model.User, error)
-	Read(page, limit uint) ([]*model.User, int64, error)
-	ReadByID(userID string) (*model.User, error)
-	ReadByEmailPublic(email string) (*model.User, error)
-	ReadByEmailPrivate(email string) (*model.User, error)
-	Search(page, limit uint, keyword string) ([]*model.User, int64, error)
-	Update(user *model.User) (*model.User, error)
-	UpdateFields(id string, fields map[string]any) (*model.User, error)
-	SoftDelete(userID string) error
+	Create(ctx context.Context, user *model.User) (*model.User, error)
+	Read(ctx context.Context, page, limit uint) ([]*model.User, int64, error)
+	ReadByCursor(ctx context.Context, cursor *pagination.Cursor, limit uint) ([]*model.User, bool, error)
+	ReadByID(ctx context.Context, userID string) (*model.User, error)
+	ReadByEmailPublic(ctx context.Context, email string) (*model.User, error)
+	ReadByEmailPrivate(ctx context.Context, email string) (*model.User, error)
+	Search(ctx context.Context, page, limit uint, keyword string) ([]*model.User, int64, error)
+	SearchByCursor(ctx context.Context, cursor *pagination.Cursor, limit uint, keyword string) ([]*model.User, bool, error)
+	Update(ctx context.Context, user *model.User) (*model.User, error)
+	// UpdateFields applies fields and increments the version in one
+	// statement. A non-zero version makes the update conditional on it.
+	UpdateFields(ctx context.Context, id string, version uint, fields map[string]any) (*model.User, error)
+	SoftDelete(ctx context.Context, userID string) error
 }
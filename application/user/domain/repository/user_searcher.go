@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+)
+
+// UserSearchHit is a single ranked match. Highlights maps a column name
+// ("name", "email") to its value with matched terms wrapped in <mark> tags;
+// the rest of the value is HTML-escaped.
+type UserSearchHit struct {
+	User       *model.User
+	Score      float64
+	Highlights map[string]string
+}
+
+// UserSearcher runs relevance-ranked keyword searches over users. Hits are
+// ordered by descending score.
+type UserSearcher interface {
+	Search(ctx context.Context, keyword string, page, limit uint) ([]*UserSearchHit, int64, error)
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+)
+
+// FileInspector checks an upload against policy before it is stored and
+// returns its content as it may be kept. Rejections wrap the checker
+// errors. *checker.Inspector implements it.
+type FileInspector interface {
+	Inspect(ctx context.Context, policy checker.Policy, filename string, content io.Reader, size int64) (*checker.File, error)
+}
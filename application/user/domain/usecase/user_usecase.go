@@ -1,13 +1,24 @@
 package usecase
 
-import "github.com/celpung/gocleanarch/application/user/domain/entity"
+import (
+	"context"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+)
 
 type UserUsecase interface {
-	Create(user *entity.User) (*entity.User, error)
-	Read(page, limit uint) ([]*entity.User, int64, error)
-	ReadByID(userID string) (*entity.User, error)
-	Search(page, limit uint, keyword string) ([]*entity.User, int64, error)
-	Update(payload *entity.UpdateUserPayload) (*entity.User, error)
-	SoftDelete(userID string) error
-	Login(email, password string) (string, error)
+	Create(ctx context.Context, user *entity.User) (*entity.User, error)
+	Read(ctx context.Context, page, limit uint) ([]*entity.User, int64, error)
+	ReadByCursor(ctx context.Context, cursor string, limit uint) ([]*entity.User, *pagination.CursorPage, error)
+	ReadByID(ctx context.Context, userID string) (*entity.User, error)
+	Search(ctx context.Context, page, limit uint, keyword string) ([]*entity.User, int64, error)
+	SearchRanked(ctx context.Context, keyword string, page, limit uint) ([]*entity.UserSearchResult, int64, error)
+	FuzzySearch(ctx context.Context, keyword, role string, active *bool, page, limit uint) (*entity.UserFacetedSearchResult, error)
+	SearchByCursor(ctx context.Context, cursor string, limit uint, keyword string) ([]*entity.User, *pagination.CursorPage, error)
+	Update(ctx context.Context, payload *entity.UpdateUserPayload) (*entity.User, error)
+	SoftDelete(ctx context.Context, userID string) error
+	SetAvatar(ctx context.Context, userID string, avatar *entity.Avatar) (*entity.User, error)
+	RemoveAvatar(ctx context.Context, userID string) (*entity.User, error)
+	Login(ctx context.Context, email, password string) (string, error)
 }
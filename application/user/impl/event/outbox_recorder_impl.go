@@ -0,0 +1,120 @@
+package event_impl
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+)
+
+// UserPayload is the user snapshot in an outbox message. AvatarKey is the
+// storage prefix of the current avatar and is left out when there is none.
+type UserPayload struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Active    bool      `json:"active"`
+	Role      string    `json:"role"`
+	AvatarKey string    `json:"avatar_key,omitempty"`
+	Version   uint      `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserEventPayload is the JSON body of a user event in the outbox and on
+// every sink. It is a public contract: add fields, never rename them.
+type UserEventPayload struct {
+	ID         string       `json:"id"`
+	Type       event.Name   `json:"type"`
+	UserID     string       `json:"user_id"`
+	User       *UserPayload `json:"user,omitempty"`
+	Changed    []string     `json:"changed,omitempty"`
+	ActorID    string       `json:"actor_id,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+	OccurredAt time.Time    `json:"occurred_at"`
+}
+
+// OutboxRecorder writes user events to the outbox store, one message per
+// event, keyed by user ID and using the event name as topic.
+type OutboxRecorder struct {
+	Store outbox.Store
+}
+
+func (r *OutboxRecorder) Record(ctx context.Context, events ...event.UserEvent) error {
+	msgs := make([]outbox.Message, 0, len(events))
+	for _, e := range events {
+		payload, err := EncodeUserEvent(e)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, outbox.Message{ID: e.ID, Topic: string(e.Name), Key: e.UserID, Payload: payload})
+	}
+	return r.Store.Add(ctx, msgs...)
+}
+
+// EncodeUserEvent renders e as a UserEventPayload.
+func EncodeUserEvent(e event.UserEvent) ([]byte, error) {
+	p := UserEventPayload{
+		ID:         e.ID,
+		Type:       e.Name,
+		UserID:     e.UserID,
+		Changed:    e.Changed,
+		ActorID:    e.ActorID,
+		RequestID:  e.RequestID,
+		OccurredAt: e.OccurredAt.UTC(),
+	}
+	if u := e.User; u != nil {
+		p.User = &UserPayload{
+			ID:        u.ID,
+			Name:      u.Name,
+			Email:     u.Email,
+			Active:    u.Active,
+			Role:      u.Role,
+			AvatarKey: u.AvatarKey,
+			Version:   u.Version,
+			CreatedAt: u.CreatedAt.UTC(),
+			UpdatedAt: u.UpdatedAt.UTC(),
+		}
+	}
+	return json.Marshal(p)
+}
+
+// DecodeUserEvent parses a message written by OutboxRecorder, for in-process
+// subscribers of the outbox bus.
+func DecodeUserEvent(msg outbox.Message) (event.UserEvent, error) {
+	var p UserEventPayload
+	if err := json.Unmarshal(msg.Payload, &p); err != nil {
+		return event.UserEvent{}, err
+	}
+
+	e := event.UserEvent{
+		ID:         p.ID,
+		Name:       p.Type,
+		UserID:     p.UserID,
+		Changed:    p.Changed,
+		ActorID:    p.ActorID,
+		RequestID:  p.RequestID,
+		OccurredAt: p.OccurredAt,
+	}
+	if u := p.User; u != nil {
+		e.User = &entity.User{
+			ID:        u.ID,
+			Name:      u.Name,
+			Email:     u.Email,
+			Active:    u.Active,
+			Role:      u.Role,
+			AvatarKey: u.AvatarKey,
+			Version:   u.Version,
+			CreatedAt: u.CreatedAt,
+			UpdatedAt: u.UpdatedAt,
+		}
+	}
+	return e, nil
+}
+
+func NewOutboxRecorder(store outbox.Store) event.Outbox {
+	return &OutboxRecorder{Store: store}
+}
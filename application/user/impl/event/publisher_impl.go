@@ -0,0 +1,26 @@
+package event_impl
+
+import (
+	"log"
+
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+)
+
+// InProcessPublisher calls every handler synchronously. A failing handler is
+// logged and does not affect the others or the caller, since the change the
+// event describes has already been committed.
+type InProcessPublisher struct {
+	Handlers []event.Handler
+}
+
+func (p *InProcessPublisher) Publish(e event.UserEvent) {
+	for _, h := range p.Handlers {
+		if err := h.HandleUserEvent(e); err != nil {
+			log.Printf("user event %s for %s (request %s): handler failed: %v", e.Name, e.UserID, e.RequestID, err)
+		}
+	}
+}
+
+func NewInProcessPublisher(handlers ...event.Handler) event.Publisher {
+	return &InProcessPublisher{Handlers: handlers}
+}
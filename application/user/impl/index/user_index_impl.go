@@ -0,0 +1,172 @@
+package index_impl
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/mapper"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	"gorm.io/gorm"
+)
+
+// FieldBoosts ranks name matches above email matches.
+var FieldBoosts = map[string]float64{
+	"name":  2,
+	"email": 1,
+}
+
+type UserIndexStruct struct {
+	Store *searchindex.Index
+}
+
+func (i *UserIndexStruct) Index(user *model.User) error {
+	return i.Store.Put(toDocument(user))
+}
+
+func (i *UserIndexStruct) Remove(userID string) error {
+	return i.Store.Delete(userID)
+}
+
+func (i *UserIndexStruct) Rebuild(users []*model.User) error {
+	docs := make([]*searchindex.Document, 0, len(users))
+	for _, u := range users {
+		docs = append(docs, toDocument(u))
+	}
+	return i.Store.Replace(docs)
+}
+
+func (i *UserIndexStruct) Search(q repository.UserIndexQuery) (*repository.UserIndexResult, error) {
+	limit := pagination.NormalizeLimit(q.Limit)
+	page := q.Page
+	if page == 0 {
+		page = 1
+	}
+
+	filters := map[string]string{}
+	if q.Role != "" {
+		filters["role"] = q.Role
+	}
+	if q.Active != nil {
+		filters["active"] = strconv.FormatBool(*q.Active)
+	}
+
+	res := i.Store.Search(searchindex.Query{
+		Text:    q.Text,
+		Filters: filters,
+		Offset:  int((page - 1) * limit),
+		Limit:   int(limit),
+	})
+
+	out := &repository.UserIndexResult{
+		Hits:   make([]*repository.UserSearchHit, 0, len(res.Hits)),
+		Total:  int64(res.Total),
+		Facets: res.Facets,
+	}
+	for _, h := range res.Hits {
+		out.Hits = append(out.Hits, &repository.UserSearchHit{
+			User:  fromDocument(h.Document),
+			Score: h.Score,
+		})
+	}
+	return out, nil
+}
+
+// HandleUserEvent keeps the index in sync with the database.
+func (i *UserIndexStruct) HandleUserEvent(e event.UserEvent) error {
+	switch e.Name {
+	case event.UserDeleted:
+		return i.Remove(e.UserID)
+	case event.UserRegistered, event.UserUpdated:
+		if e.User == nil {
+			return nil
+		}
+		var m model.User
+		if err := mapper.CopyTo(e.User, &m); err != nil {
+			return err
+		}
+		return i.Index(&m)
+	default:
+		// the specific update events repeat the snapshot of user.updated
+		return nil
+	}
+}
+
+func toDocument(u *model.User) *searchindex.Document {
+	return &searchindex.Document{
+		ID: u.ID,
+		Fields: map[string]string{
+			"name":  u.Name,
+			"email": u.Email,
+		},
+		Facets: map[string]string{
+			"role":   u.Role,
+			"active": strconv.FormatBool(u.Active),
+		},
+		Stored: map[string]string{
+			"avatar_key": u.AvatarKey,
+		},
+	}
+}
+
+func fromDocument(d *searchindex.Document) *model.User {
+	active, _ := strconv.ParseBool(d.Facets["active"])
+	return &model.User{
+		BaseModelUUID: model.BaseModelUUID{ID: d.ID},
+		Name:          d.Fields["name"],
+		Email:         d.Fields["email"],
+		Role:          d.Facets["role"],
+		Active:        active,
+		AvatarKey:     d.Stored["avatar_key"],
+	}
+}
+
+// RebuildFromRepository pages through every user with keyset pagination and
+// replaces the index content in one go.
+func RebuildFromRepository(ctx context.Context, repo repository.UserRepository, index repository.UserIndex) (int, error) {
+	var (
+		all    []*model.User
+		cursor *pagination.Cursor
+	)
+	for {
+		users, hasMore, err := repo.ReadByCursor(ctx, cursor, pagination.MaxLimit)
+		if err != nil {
+			return 0, err
+		}
+		all = append(all, users...)
+		if !hasMore || len(users) == 0 {
+			break
+		}
+		last := users[len(users)-1]
+		cursor = &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Direction: pagination.Next}
+	}
+
+	if err := index.Rebuild(all); err != nil {
+		return 0, err
+	}
+	return len(all), nil
+}
+
+// OpenUserIndex opens searchindex.Users and fills it from the database when
+// it is empty, e.g. on the first start or after the file was removed.
+func OpenUserIndex(ctx context.Context, db *gorm.DB) error {
+	if err := searchindex.OpenUsers(FieldBoosts); err != nil {
+		return err
+	}
+	if searchindex.Users.Len() > 0 {
+		return nil
+	}
+
+	_, err := RebuildFromRepository(ctx, repository_impl.NewUserRepository(db), NewUserIndex(searchindex.Users))
+	return err
+}
+
+// NewUserIndex returns the index adapter. It also implements event.Handler,
+// so it can be registered with the user event publisher.
+func NewUserIndex(index *searchindex.Index) *UserIndexStruct {
+	return &UserIndexStruct{Store: index}
+}
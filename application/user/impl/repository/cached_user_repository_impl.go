@@ -0,0 +1,188 @@
+package repository_impl
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/cache"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"golang.org/x/sync/singleflight"
+)
+
+// CachedUserRepository decorates a UserRepository with a read-through cache
+// for ReadByID and ReadByEmailPublic. Concurrent misses for the same key
+// share one database query. Update, UpdateFields and SoftDelete drop the
+// cached user; every other method passes straight through.
+//
+// A user is cached under its ID; the email key only remembers the ID, and
+// a hit is discarded when the cached user no longer has that email, so an
+// email change never needs the old address to invalidate. Reads inside a
+// unit of work transaction bypass the cache, as they may see uncommitted
+// rows, and writes inside one invalidate again once it commits.
+type CachedUserRepository struct {
+	Next  repository.UserRepository
+	Cache cache.Cache
+	TTL   time.Duration
+
+	group singleflight.Group
+}
+
+const (
+	userIDKeyPrefix    = "user:id:"
+	userEmailKeyPrefix = "user:email:"
+)
+
+func (r *CachedUserRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	return r.Next.Create(ctx, user)
+}
+
+func (r *CachedUserRepository) Read(ctx context.Context, page, limit uint) ([]*model.User, int64, error) {
+	return r.Next.Read(ctx, page, limit)
+}
+
+func (r *CachedUserRepository) ReadByCursor(ctx context.Context, cursor *pagination.Cursor, limit uint) ([]*model.User, bool, error) {
+	return r.Next.ReadByCursor(ctx, cursor, limit)
+}
+
+func (r *CachedUserRepository) ReadByID(ctx context.Context, userID string) (*model.User, error) {
+	if uow_impl.InTransaction(ctx) {
+		return r.Next.ReadByID(ctx, userID)
+	}
+	if user, ok := r.cachedUser(ctx, userID); ok {
+		return user, nil
+	}
+	return r.load(ctx, userIDKeyPrefix+userID, func() (*model.User, error) {
+		return r.Next.ReadByID(ctx, userID)
+	})
+}
+
+func (r *CachedUserRepository) ReadByEmailPublic(ctx context.Context, email string) (*model.User, error) {
+	if uow_impl.InTransaction(ctx) {
+		return r.Next.ReadByEmailPublic(ctx, email)
+	}
+	key := userEmailKeyPrefix + strings.ToLower(email)
+	if id, ok, _ := r.Cache.Get(ctx, key); ok {
+		if user, ok := r.cachedUser(ctx, string(id)); ok && strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return r.load(ctx, key, func() (*model.User, error) {
+		return r.Next.ReadByEmailPublic(ctx, email)
+	})
+}
+
+// ReadByEmailPrivate is never cached: it returns the password hash.
+func (r *CachedUserRepository) ReadByEmailPrivate(ctx context.Context, email string) (*model.User, error) {
+	return r.Next.ReadByEmailPrivate(ctx, email)
+}
+
+func (r *CachedUserRepository) Search(ctx context.Context, page, limit uint, keyword string) ([]*model.User, int64, error) {
+	return r.Next.Search(ctx, page, limit, keyword)
+}
+
+func (r *CachedUserRepository) SearchByCursor(ctx context.Context, cursor *pagination.Cursor, limit uint, keyword string) ([]*model.User, bool, error) {
+	return r.Next.SearchByCursor(ctx, cursor, limit, keyword)
+}
+
+func (r *CachedUserRepository) Update(ctx context.Context, user *model.User) (*model.User, error) {
+	updated, err := r.Next.Update(ctx, user)
+	r.invalidate(ctx, user.ID)
+	return updated, err
+}
+
+func (r *CachedUserRepository) UpdateFields(ctx context.Context, id string, version uint, fields map[string]any) (*model.User, error) {
+	updated, err := r.Next.UpdateFields(ctx, id, version, fields)
+	r.invalidate(ctx, id)
+	return updated, err
+}
+
+func (r *CachedUserRepository) SoftDelete(ctx context.Context, userID string) error {
+	err := r.Next.SoftDelete(ctx, userID)
+	r.invalidate(ctx, userID)
+	return err
+}
+
+// load runs fetch once per key across concurrent callers and caches the
+// result. Misses and errors are not cached.
+func (r *CachedUserRepository) load(ctx context.Context, key string, fetch func() (*model.User, error)) (*model.User, error) {
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		user, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		r.store(ctx, user)
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers sharing a result must not see each other's changes to it.
+	user := *v.(*model.User)
+	return &user, nil
+}
+
+func (r *CachedUserRepository) cachedUser(ctx context.Context, id string) (*model.User, bool) {
+	data, ok, err := r.Cache.Get(ctx, userIDKeyPrefix+id)
+	if err != nil {
+		log.Printf("user cache: get %s: %v", id, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var user model.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, false
+	}
+	return &user, true
+}
+
+func (r *CachedUserRepository) store(ctx context.Context, user *model.User) {
+	cached := *user
+	cached.Password = ""
+	data, err := json.Marshal(&cached)
+	if err != nil {
+		return
+	}
+
+	if err := r.Cache.Set(ctx, userIDKeyPrefix+user.ID, data, r.TTL); err != nil {
+		log.Printf("user cache: set %s: %v", user.ID, err)
+		return
+	}
+	if user.Email != "" {
+		if err := r.Cache.Set(ctx, userEmailKeyPrefix+strings.ToLower(user.Email), []byte(user.ID), r.TTL); err != nil {
+			log.Printf("user cache: set %s: %v", user.ID, err)
+		}
+	}
+}
+
+// invalidate drops the cached user now and again after the surrounding
+// transaction commits. A read that started before the write may still
+// cache the old row; the TTL bounds how long it is served.
+func (r *CachedUserRepository) invalidate(ctx context.Context, id string) {
+	drop := func() {
+		r.group.Forget(userIDKeyPrefix + id)
+		if err := r.Cache.Delete(context.WithoutCancel(ctx), userIDKeyPrefix+id); err != nil {
+			log.Printf("user cache: delete %s: %v", id, err)
+		}
+	}
+	drop()
+	uow_impl.AfterCommit(ctx, drop)
+}
+
+// NewCachedUserRepository wraps next with c. A nil c disables caching and
+// returns next unchanged.
+func NewCachedUserRepository(next repository.UserRepository, c cache.Cache, ttl time.Duration) repository.UserRepository {
+	if c == nil {
+		return next
+	}
+	return &CachedUserRepository{Next: next, Cache: c, TTL: ttl}
+}
@@ -1,27 +1,39 @@
 package repository_impl
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/celpung/gocleanarch/application/user/domain/repository"
 	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"github.com/celpung/gocleanarch/infrastructure/sqlutil"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
+// UserRepositoryStruct reports missing and soft deleted users as
+// repository.ErrNotFound.
 type UserRepositoryStruct struct {
 	DB *gorm.DB
 }
 
-func (r *UserRepositoryStruct) Create(m *model.User) (*model.User, error) {
-	if err := r.DB.Create(m).Error; err != nil {
-		return nil, err
+func (r *UserRepositoryStruct) Create(ctx context.Context, m *model.User) (*model.User, error) {
+	if m.Version == 0 {
+		m.Version = 1
+	}
+
+	if err := r.db(ctx).Create(m).Error; err != nil {
+		return nil, r.translate(err)
 	}
 
 	return m, nil
 }
 
-func (r *UserRepositoryStruct) Read(page, limit uint) ([]*model.User, int64, error) {
+func (r *UserRepositoryStruct) Read(ctx context.Context, page, limit uint) ([]*model.User, int64, error) {
 	var (
 		users []*model.User
 		total int64
@@ -46,7 +58,7 @@ func (r *UserRepositoryStruct) Read(page, limit uint) ([]*model.User, int64, err
 
 	offset := int((page - 1) * limit)
 
-	base := r.DB.Model(&model.User{})
+	base := r.db(ctx).Model(&model.User{})
 
 	if err := base.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -63,62 +75,52 @@ func (r *UserRepositoryStruct) Read(page, limit uint) ([]*model.User, int64, err
 	return users, total, nil
 }
 
-func (r *UserRepositoryStruct) ReadByID(userID string) (*model.User, error) {
+func (r *UserRepositoryStruct) ReadByCursor(ctx context.Context, cursor *pagination.Cursor, limit uint) ([]*model.User, bool, error) {
+	return r.keysetPage(r.db(ctx).Model(&model.User{}), cursor, limit)
+}
+
+func (r *UserRepositoryStruct) ReadByID(ctx context.Context, userID string) (*model.User, error) {
 	user := &model.User{}
 
-	if err := r.selectUserData(r.DB).
+	if err := r.selectUserData(r.primary(ctx)).
 		First(user, "id = ?", userID).Error; err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 
 	return user, nil
 }
 
-func (r *UserRepositoryStruct) ReadByEmailPublic(email string) (*model.User, error) {
+func (r *UserRepositoryStruct) ReadByEmailPublic(ctx context.Context, email string) (*model.User, error) {
 	user := &model.User{}
 
-	if err := r.selectUserData(r.DB).
+	if err := r.selectUserData(r.primary(ctx)).
 		Where("email = ?", email).
 		First(user).Error; err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 
 	return user, nil
 }
 
-func (r *UserRepositoryStruct) ReadByEmailPrivate(email string) (*model.User, error) {
+func (r *UserRepositoryStruct) ReadByEmailPrivate(ctx context.Context, email string) (*model.User, error) {
 	user := &model.User{}
 
-	if err := r.DB.
+	if err := r.primary(ctx).
 		Where("email = ?", email).
 		First(user).Error; err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 
 	return user, nil
 }
 
-func (r *UserRepositoryStruct) Search(page, limit uint, keyword string) ([]*model.User, int64, error) {
+func (r *UserRepositoryStruct) Search(ctx context.Context, page, limit uint, keyword string) ([]*model.User, int64, error) {
 	var (
 		users []*model.User
 		total int64
 	)
 
-	base := r.DB.Model(&model.User{})
-
-	if keyword != "" {
-		like := "%" + keyword + "%"
-		cols := []string{"users.name", "users.email"}
-		var (
-			conds []string
-			args  []any
-		)
-		for _, c := range cols {
-			conds = append(conds, fmt.Sprintf("%s LIKE ?", c))
-			args = append(args, like)
-		}
-		base = base.Where("("+strings.Join(conds, " OR ")+")", args...)
-	}
+	base := r.filterByKeyword(r.db(ctx).Model(&model.User{}), keyword)
 
 	if err := base.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -140,46 +142,173 @@ func (r *UserRepositoryStruct) Search(page, limit uint, keyword string) ([]*mode
 	return users, total, nil
 }
 
-func (r *UserRepositoryStruct) Update(m *model.User) (*model.User, error) {
-	if err := r.DB.Model(&model.User{}).Where("id = ?", m.ID).Updates(m).Error; err != nil {
+func (r *UserRepositoryStruct) SearchByCursor(ctx context.Context, cursor *pagination.Cursor, limit uint, keyword string) ([]*model.User, bool, error) {
+	return r.keysetPage(r.filterByKeyword(r.db(ctx).Model(&model.User{}), keyword), cursor, limit)
+}
+
+func (r *UserRepositoryStruct) Update(ctx context.Context, m *model.User) (*model.User, error) {
+	if err := r.db(ctx).Model(&model.User{}).Where("id = ?", m.ID).Updates(m).Error; err != nil {
 		return nil, err
 	}
 
 	return m, nil
 }
 
-func (r *UserRepositoryStruct) UpdateFields(id string, fields map[string]any) (*model.User, error) {
-	tx := r.DB.Model(&model.User{}).Where("id = ?", id).Updates(fields)
+func (r *UserRepositoryStruct) UpdateFields(ctx context.Context, id string, version uint, fields map[string]any) (*model.User, error) {
+	changes := make(map[string]any, len(fields)+1)
+	for k, v := range fields {
+		changes[k] = v
+	}
+	changes["version"] = gorm.Expr("version + 1")
 
+	q := r.db(ctx).Model(&model.User{}).Where("id = ?", id)
+	if version > 0 {
+		q = q.Where("version = ?", version)
+	}
+
+	tx := q.Updates(changes)
 	if tx.Error != nil {
-		return nil, tx.Error
+		return nil, r.translate(tx.Error)
 	}
 
 	if tx.RowsAffected == 0 {
-		return nil, gorm.ErrRecordNotFound
+		if version == 0 {
+			return nil, repository.ErrNotFound
+		}
+		var count int64
+		if err := r.primary(ctx).Model(&model.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, repository.ErrNotFound
+		}
+		return nil, repository.ErrVersionMismatch
 	}
 
 	var m model.User
-	if err := r.selectUserData(r.DB).
+	if err := r.selectUserData(r.primary(ctx)).
 		First(&m, "id = ?", id).Error; err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 
 	return &m, nil
 }
 
-func (r *UserRepositoryStruct) SoftDelete(userID string) error {
-	if err := r.DB.
+// SoftDelete marks the user deleted. It returns repository.ErrNotFound when
+// no live user has the ID.
+func (r *UserRepositoryStruct) SoftDelete(ctx context.Context, userID string) error {
+	res := r.db(ctx).
 		Where("id = ?", userID).
-		Delete(&model.User{}).Error; err != nil {
-		return err
+		Delete(&model.User{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repository.ErrNotFound
 	}
 
 	return nil
 }
 
+func (r *UserRepositoryStruct) filterByKeyword(db *gorm.DB, keyword string) *gorm.DB {
+	if keyword == "" {
+		return db
+	}
+
+	// LOWER on both sides keeps the match case-insensitive on PostgreSQL,
+	// where LIKE is case-sensitive.
+	like := "%" + sqlutil.EscapeLike(strings.ToLower(keyword)) + "%"
+	cols := []string{"users.name", "users.email"}
+	var (
+		conds []string
+		args  []any
+	)
+	for _, c := range cols {
+		conds = append(conds, fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '!'", c))
+		args = append(args, like)
+	}
+	return db.Where("("+strings.Join(conds, " OR ")+")", args...)
+}
+
+// keysetPage reads one page ordered by (created_at DESC, id DESC) starting
+// after the cursor. Rows before the cursor are read in ascending order and
+// reversed so callers always receive newest-first. One extra row is fetched
+// to report whether another page exists in the reading direction; no COUNT
+// query is issued.
+func (r *UserRepositoryStruct) keysetPage(base *gorm.DB, cursor *pagination.Cursor, limit uint) ([]*model.User, bool, error) {
+	var users []*model.User
+
+	limit = pagination.NormalizeLimit(limit)
+	q := r.selectUserData(base)
+
+	backwards := cursor != nil && cursor.Direction == pagination.Prev
+
+	switch {
+	case cursor == nil:
+		q = q.Order("users.created_at DESC").Order("users.id DESC")
+	case backwards:
+		q = q.Where("(users.created_at > ? OR (users.created_at = ? AND users.id > ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+			Order("users.created_at ASC").Order("users.id ASC")
+	default:
+		q = q.Where("(users.created_at < ? OR (users.created_at = ? AND users.id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+			Order("users.created_at DESC").Order("users.id DESC")
+	}
+
+	if err := q.Limit(int(limit) + 1).Find(&users).Error; err != nil {
+		return nil, false, err
+	}
+
+	hasMore := uint(len(users)) > limit
+	if hasMore {
+		users = users[:limit]
+	}
+
+	if backwards {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+
+	return users, hasMore, nil
+}
+
+// db scopes the connection to ctx so cancellation and deadlines reach the
+// driver, and joins the unit of work transaction when ctx carries one.
+func (r *UserRepositoryStruct) db(ctx context.Context) *gorm.DB {
+	return uow_impl.DB(ctx, r.DB)
+}
+
+// primary is db pinned to the primary database. Lists, counts and searches
+// may be served by a lagging read replica; lookups that feed a write or a
+// login must see the latest data.
+func (r *UserRepositoryStruct) primary(ctx context.Context) *gorm.DB {
+	return r.db(ctx).Clauses(dbresolver.Write)
+}
+
+// translate reports unique violations, which only the email can cause, as
+// repository.ErrDuplicateEmail. The dialector recognises them whether or
+// not GORM was opened with TranslateError.
+func (r *UserRepositoryStruct) translate(err error) error {
+	translated := err
+	if translator, ok := r.DB.Dialector.(gorm.ErrorTranslator); ok {
+		translated = translator.Translate(err)
+	}
+	if errors.Is(translated, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: %w", repository.ErrDuplicateEmail, err)
+	}
+	return err
+}
+
+// notFound reports GORM's missing record error as repository.ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
 func (r *UserRepositoryStruct) selectUserData(db *gorm.DB) *gorm.DB {
-	return db.Select([]string{"users.id", "users.name", "users.email", "users.active", "users.role"})
+	return db.Select([]string{"users.id", "users.name", "users.email", "users.active", "users.role", "users.avatar_key", "users.version", "users.created_at"})
 }
 
 func NewUserRepository(db *gorm.DB) repository.UserRepository {
@@ -0,0 +1,79 @@
+package search_impl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"github.com/celpung/gocleanarch/infrastructure/sqlutil"
+	"gorm.io/gorm"
+)
+
+// LikeSearcher is the portable fallback. It can not use an index, but it
+// escapes LIKE wildcards and ranks prefix matches on the name above
+// substring matches.
+type LikeSearcher struct {
+	DB *gorm.DB
+}
+
+func (s *LikeSearcher) Search(ctx context.Context, keyword string, page, limit uint) ([]*repository.UserSearchHit, int64, error) {
+	var (
+		rows  []*hitRow
+		total int64
+	)
+
+	limit = pagination.NormalizeLimit(limit)
+	ts := terms(keyword)
+
+	base := s.DB.WithContext(ctx).Model(&model.User{})
+	score := "0"
+	var scoreArgs []any
+
+	if len(ts) > 0 {
+		var (
+			conds  []string
+			args   []any
+			scores []string
+		)
+		for _, t := range ts {
+			like := "%" + sqlutil.EscapeLike(t) + "%"
+			conds = append(conds, "(LOWER(users.name) LIKE ? ESCAPE '!' OR LOWER(users.email) LIKE ? ESCAPE '!')")
+			args = append(args, like, like)
+
+			scores = append(scores,
+				"CASE WHEN LOWER(users.name) LIKE ? ESCAPE '!' THEN 3 ELSE 0 END",
+				"CASE WHEN LOWER(users.name) LIKE ? ESCAPE '!' THEN 2 ELSE 0 END",
+				"CASE WHEN LOWER(users.email) LIKE ? ESCAPE '!' THEN 1 ELSE 0 END",
+			)
+			scoreArgs = append(scoreArgs, sqlutil.EscapeLike(t)+"%", like, like)
+		}
+		base = base.Where(strings.Join(conds, " AND "), args...)
+		score = strings.Join(scores, " + ")
+	}
+
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := base.Session(&gorm.Session{}).
+		Select(fmt.Sprintf("users.id, users.name, users.email, users.active, users.role, users.avatar_key, users.created_at, (%s) AS score", score), scoreArgs...).
+		Order("score DESC").
+		Order("users.created_at DESC").
+		Offset(offset(page, limit)).
+		Limit(int(limit)).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]*repository.UserSearchHit, 0, len(rows))
+	for _, r := range rows {
+		r.NameHighlight = markTerms(r.Name, ts)
+		r.EmailHighlight = markTerms(r.Email, ts)
+		hits = append(hits, r.toHit())
+	}
+
+	return hits, total, nil
+}
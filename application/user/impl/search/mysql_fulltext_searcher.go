@@ -0,0 +1,99 @@
+package search_impl
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"gorm.io/gorm"
+)
+
+// MySQLFulltextSearcher uses the idx_users_fulltext InnoDB FULLTEXT index
+// over name and email, created by the users migration, in boolean mode.
+// Every term is required and prefix matched ("+term*").
+// MySQL has no highlight function, so highlights are computed in Go.
+//
+// InnoDB does not index words shorter than innodb_ft_min_token_size or
+// stopwords, so a required term of either kind matches no row at all.
+// Such keywords are answered by LikeSearcher instead.
+type MySQLFulltextSearcher struct {
+	DB *gorm.DB
+	// MinTokenSize mirrors the server's innodb_ft_min_token_size.
+	MinTokenSize int
+}
+
+// innodbStopwords is INFORMATION_SCHEMA.INNODB_FT_DEFAULT_STOPWORD, the
+// list InnoDB uses unless innodb_ft_server_stopword_table is set.
+var innodbStopwords = map[string]bool{
+	"a": true, "about": true, "an": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "com": true, "de": true, "en": true, "for": true,
+	"from": true, "how": true, "i": true, "in": true, "is": true, "it": true,
+	"la": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "what": true, "when": true,
+	"where": true, "who": true, "will": true, "with": true, "und": true,
+	"www": true,
+}
+
+func (s *MySQLFulltextSearcher) Search(ctx context.Context, keyword string, page, limit uint) ([]*repository.UserSearchHit, int64, error) {
+	ts := terms(keyword)
+	if len(ts) == 0 || !s.indexable(ts) {
+		return (&LikeSearcher{DB: s.DB}).Search(ctx, keyword, page, limit)
+	}
+
+	var (
+		rows  []*hitRow
+		total int64
+	)
+
+	limit = pagination.NormalizeLimit(limit)
+	query := booleanModeQuery(ts)
+	match := "MATCH(users.name, users.email) AGAINST (? IN BOOLEAN MODE)"
+
+	base := s.DB.WithContext(ctx).Model(&model.User{}).Where(match, query)
+
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := base.Session(&gorm.Session{}).
+		Select("users.id, users.name, users.email, users.active, users.role, users.avatar_key, users.created_at, "+match+" AS score", query).
+		Order("score DESC").
+		Order("users.created_at DESC").
+		Offset(offset(page, limit)).
+		Limit(int(limit)).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]*repository.UserSearchHit, 0, len(rows))
+	for _, r := range rows {
+		r.NameHighlight = markTerms(r.Name, ts)
+		r.EmailHighlight = markTerms(r.Email, ts)
+		hits = append(hits, r.toHit())
+	}
+
+	return hits, total, nil
+}
+
+// indexable reports whether every term can be found in the FULLTEXT index.
+func (s *MySQLFulltextSearcher) indexable(ts []string) bool {
+	for _, t := range ts {
+		if utf8.RuneCountInString(t) < s.MinTokenSize || innodbStopwords[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// booleanModeQuery builds "+term1* +term2*". terms() already removed every
+// character that has a meaning in boolean mode.
+func booleanModeQuery(ts []string) string {
+	parts := make([]string, 0, len(ts))
+	for _, t := range ts {
+		parts = append(parts, "+"+t+"*")
+	}
+	return strings.Join(parts, " ")
+}
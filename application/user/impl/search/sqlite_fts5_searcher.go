@@ -0,0 +1,68 @@
+package search_impl
+
+import (
+	"context"
+	"strings"
+
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"gorm.io/gorm"
+)
+
+// SQLiteFTS5Searcher queries the users_fts external-content table, which
+// triggers keep in sync with users. Results are ranked with bm25 and
+// highlighted by FTS5 itself.
+type SQLiteFTS5Searcher struct {
+	DB *gorm.DB
+}
+
+func (s *SQLiteFTS5Searcher) Search(ctx context.Context, keyword string, page, limit uint) ([]*repository.UserSearchHit, int64, error) {
+	ts := terms(keyword)
+	if len(ts) == 0 {
+		return (&LikeSearcher{DB: s.DB}).Search(ctx, "", page, limit)
+	}
+
+	var (
+		rows  []*hitRow
+		total int64
+	)
+
+	limit = pagination.NormalizeLimit(limit)
+	query := fts5Query(ts)
+
+	const from = ` FROM users_fts JOIN users ON users.seq = users_fts.rowid
+		WHERE users_fts MATCH ? AND users.deleted_at IS NULL`
+
+	if err := s.DB.WithContext(ctx).Raw("SELECT COUNT(*)"+from, query).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.DB.WithContext(ctx).Raw(`SELECT users.id, users.name, users.email, users.active, users.role, users.avatar_key, users.created_at,
+			-bm25(users_fts) AS score,
+			highlight(users_fts, 0, ?, ?) AS name_highlight,
+			highlight(users_fts, 1, ?, ?) AS email_highlight`+from+`
+		ORDER BY bm25(users_fts), users.created_at DESC
+		LIMIT ? OFFSET ?`,
+		markOpen, markClose, markOpen, markClose, query, int(limit), offset(page, limit)).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]*repository.UserSearchHit, 0, len(rows))
+	for _, r := range rows {
+		hits = append(hits, r.toHit())
+	}
+
+	return hits, total, nil
+}
+
+// fts5Query quotes every term as a string literal and makes it a prefix
+// query: "term1"* "term2"*. Quoting keeps FTS5 from reading any character
+// of the user input as syntax.
+func fts5Query(ts []string) string {
+	parts := make([]string, 0, len(ts))
+	for _, t := range ts {
+		parts = append(parts, `"`+strings.ReplaceAll(t, `"`, `""`)+`"*`)
+	}
+	return strings.Join(parts, " ")
+}
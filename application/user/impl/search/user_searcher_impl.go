@@ -0,0 +1,157 @@
+package search_impl
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"gorm.io/gorm"
+)
+
+// maxTerms caps how many keywords a single query may contain.
+const maxTerms = 8
+
+// mysqlMinTokenSize is the default innodb_ft_min_token_size.
+const mysqlMinTokenSize = 3
+
+// Markers used while a highlight is built; they are replaced by <mark> tags
+// after the surrounding text has been HTML-escaped.
+const (
+	markOpen  = "\x02"
+	markClose = "\x03"
+)
+
+// NewUserSearcher returns the full-text implementation matching the dialect
+// of db: MySQL FULLTEXT, SQLite FTS5, or an escaped LIKE scan for anything
+// else. The indexes are created by the database migrations.
+func NewUserSearcher(db *gorm.DB) repository.UserSearcher {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return &MySQLFulltextSearcher{DB: db, MinTokenSize: mysqlMinTokenSize}
+	case "sqlite":
+		return &SQLiteFTS5Searcher{DB: db}
+	default:
+		return &LikeSearcher{DB: db}
+	}
+}
+
+// hitRow is the scan target shared by the implementations.
+type hitRow struct {
+	ID             string
+	Name           string
+	Email          string
+	Active         bool
+	Role           string
+	AvatarKey      string
+	CreatedAt      time.Time
+	Score          float64
+	NameHighlight  string
+	EmailHighlight string
+}
+
+func (r *hitRow) toHit() *repository.UserSearchHit {
+	return &repository.UserSearchHit{
+		User: &model.User{
+			BaseModelUUID: model.BaseModelUUID{ID: r.ID},
+			Name:          r.Name,
+			Email:         r.Email,
+			Active:        r.Active,
+			Role:          r.Role,
+			AvatarKey:     r.AvatarKey,
+			CreatedAt:     r.CreatedAt,
+		},
+		Score: r.Score,
+		Highlights: map[string]string{
+			"name":  renderHighlight(r.NameHighlight),
+			"email": renderHighlight(r.EmailHighlight),
+		},
+	}
+}
+
+// terms splits a keyword into lower-cased words made of letters and digits.
+// Every other rune acts as a separator, which also strips any operator a
+// full-text engine would otherwise interpret.
+func terms(keyword string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(keyword), func(r rune) bool {
+		return !isWordRune(r)
+	})
+
+	seen := make(map[string]bool, len(fields))
+	out := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		out = append(out, f)
+		if len(out) == maxTerms {
+			break
+		}
+	}
+	return out
+}
+
+// markTerms wraps every word in s that starts with one of the terms in
+// highlight markers. Whole words are marked, matching what FTS5 highlight()
+// produces for prefix queries, and comparison is case-insensitive.
+func markTerms(s string, ts []string) string {
+	if len(ts) == 0 {
+		return s
+	}
+
+	var (
+		b     strings.Builder
+		runes = []rune(s)
+	)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		lower := strings.ToLower(word)
+
+		matched := false
+		for _, t := range ts {
+			if strings.HasPrefix(lower, t) {
+				matched = true
+				break
+			}
+		}
+
+		if matched {
+			b.WriteString(markOpen + word + markClose)
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// renderHighlight escapes s and turns the markers into <mark> tags.
+func renderHighlight(s string) string {
+	escaped := html.EscapeString(s)
+	escaped = strings.ReplaceAll(escaped, markOpen, "<mark>")
+	return strings.ReplaceAll(escaped, markClose, "</mark>")
+}
+
+func offset(page, limit uint) int {
+	if page == 0 {
+		page = 1
+	}
+	return int((page - 1) * limit)
+}
@@ -0,0 +1,186 @@
+package usecase_impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/mapper"
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+	"github.com/celpung/gocleanarch/infrastructure/thumbnail"
+	"github.com/google/uuid"
+)
+
+// AvatarPolicy is what avatar uploads accept. The use case sets MaxSize to
+// the upload limit it is built with. Avatars are re-encoded, which drops
+// their metadata, so it is not stripped beforehand.
+var AvatarPolicy = checker.Policy{
+	Name:      "avatars",
+	Types:     []checker.FileType{checker.JPEG, checker.PNG, checker.GIF, checker.WebP},
+	MaxWidth:  8_000,
+	MaxHeight: 8_000,
+	MaxPixels: 25_000_000,
+}
+
+// AvatarSizes are the squares avatars are stored in; Width is both the
+// width and the height.
+var AvatarSizes = []thumbnail.Variant{
+	{Name: "small", Width: 64},
+	{Name: "medium", Width: 256},
+	{Name: "large", Width: 512},
+}
+
+// SetAvatar crops avatar to a centred square and stores one JPEG per size
+// below "avatars/<user id>/<upload id>/". The previous avatar is removed
+// once the user points at the new one.
+func (u *UserUsecaseStruct) SetAvatar(ctx context.Context, userID string, avatar *entity.Avatar) (*entity.User, error) {
+	if avatar == nil {
+		return nil, fmt.Errorf("%w: no file", entity.ErrInvalidAvatar)
+	}
+
+	key, written, err := u.storeAvatar(ctx, userID, avatar)
+	if err != nil {
+		return nil, err
+	}
+
+	user, previous, err := u.changeAvatar(ctx, userID, key)
+	if err != nil {
+		storage.DeleteQuietly(ctx, u.Storage, "users", written...)
+		return nil, err
+	}
+
+	storage.DeleteQuietly(ctx, u.Storage, "users", u.avatarKeys(previous)...)
+	return user, nil
+}
+
+// RemoveAvatar deletes the avatar of userID, if it has one.
+func (u *UserUsecaseStruct) RemoveAvatar(ctx context.Context, userID string) (*entity.User, error) {
+	user, previous, err := u.changeAvatar(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	storage.DeleteQuietly(ctx, u.Storage, "users", u.avatarKeys(previous)...)
+	return user, nil
+}
+
+// changeAvatar points userID at the avatar stored below key and returns the
+// user together with the key it replaced.
+func (u *UserUsecaseStruct) changeAvatar(ctx context.Context, userID, key string) (*entity.User, string, error) {
+	var (
+		updated  *model.User
+		previous string
+		events   []event.UserEvent
+	)
+	err := u.atomically(ctx, func(ctx context.Context) error {
+		cur, err := u.Repo.ReadByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		previous = cur.AvatarKey
+		if previous == key {
+			updated = cur
+			return nil
+		}
+
+		changes := map[string]any{"avatar_key": key}
+		updated, err = u.Repo.UpdateFields(ctx, userID, 0, changes)
+		if err != nil {
+			return err
+		}
+
+		var snapshot entity.User
+		if err := mapper.CopyTo(updated, &snapshot); err != nil {
+			return err
+		}
+		u.withAvatars(&snapshot)
+		events = updateEvents(ctx, cur, &snapshot, changes)
+		return u.record(ctx, events)
+	})
+	if err != nil {
+		return nil, "", domainError(err)
+	}
+
+	var out entity.User
+	if err := mapper.CopyTo(updated, &out); err != nil {
+		return nil, "", err
+	}
+	u.withAvatars(&out)
+
+	u.publish(events)
+
+	return &out, previous, nil
+}
+
+// storeAvatar inspects avatar and writes its sizes. It returns the key
+// prefix they share and every key written.
+func (u *UserUsecaseStruct) storeAvatar(ctx context.Context, userID string, avatar *entity.Avatar) (string, []string, error) {
+	file, err := u.Inspector.Inspect(ctx, u.AvatarPolicy, avatar.Filename, avatar.Content, avatar.Size)
+	switch {
+	case errors.Is(err, checker.ErrTooLarge):
+		return "", nil, entity.ErrAvatarTooLarge
+	case errors.Is(err, checker.ErrRejected):
+		return "", nil, fmt.Errorf("%w: %w", entity.ErrInvalidAvatar, err)
+	case err != nil:
+		return "", nil, err
+	}
+
+	img, _, err := thumbnail.Decode(file.Content, u.AvatarPolicy.MaxPixels)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", entity.ErrInvalidAvatar, err)
+	}
+
+	dir := path.Join("avatars", userID, uuid.NewString())
+
+	var written []string
+	for _, size := range u.AvatarSizes {
+		var buf bytes.Buffer
+		if err := thumbnail.EncodeJPEG(&buf, thumbnail.Square(img, size.Width), u.JPEGQuality); err != nil {
+			storage.DeleteQuietly(ctx, u.Storage, "users", written...)
+			return "", nil, err
+		}
+
+		key := path.Join(dir, size.Name+".jpg")
+		if err := u.Storage.Put(ctx, key, &buf, "image/jpeg"); err != nil {
+			storage.DeleteQuietly(ctx, u.Storage, "users", written...)
+			return "", nil, err
+		}
+		written = append(written, key)
+	}
+
+	return dir, written, nil
+}
+
+// avatarKeys lists the files of the avatar stored below dir.
+func (u *UserUsecaseStruct) avatarKeys(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+
+	keys := make([]string, 0, len(u.AvatarSizes))
+	for _, size := range u.AvatarSizes {
+		keys = append(keys, path.Join(dir, size.Name+".jpg"))
+	}
+	return keys
+}
+
+// withAvatars fills in the avatar URLs of users that have one.
+func (u *UserUsecaseStruct) withAvatars(users ...*entity.User) {
+	for _, user := range users {
+		if user.AvatarKey == "" || u.Storage == nil {
+			continue
+		}
+
+		user.Avatars = make(map[string]string, len(u.AvatarSizes))
+		for _, size := range u.AvatarSizes {
+			user.Avatars[size.Name] = u.Storage.URL(path.Join(user.AvatarKey, size.Name+".jpg"))
+		}
+	}
+}
@@ -1,24 +1,49 @@
 package usecase_impl
 
 import (
+	"context"
 	"errors"
+	"sort"
+	"time"
 
+	"github.com/celpung/gocleanarch/application/apperror"
 	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/event"
 	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	"github.com/celpung/gocleanarch/application/user/domain/service"
 	"github.com/celpung/gocleanarch/application/user/domain/usecase"
 	"github.com/celpung/gocleanarch/infrastructure/auth"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
 	"github.com/celpung/gocleanarch/infrastructure/db/model"
 	"github.com/celpung/gocleanarch/infrastructure/mapper"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+	"github.com/celpung/gocleanarch/infrastructure/thumbnail"
 	"github.com/celpung/gocleanarch/infrastructure/typograph"
+	"github.com/celpung/gocleanarch/infrastructure/uow"
+	"github.com/google/uuid"
 )
 
+// UserUsecaseStruct stores avatars in Storage once they pass Inspector
+// under AvatarPolicy. Users are returned with their avatar URLs filled in.
 type UserUsecaseStruct struct {
 	Repo            repository.UserRepository
+	Searcher        repository.UserSearcher
+	Index           repository.UserIndex
+	Events          event.Publisher
+	Outbox          event.Outbox
+	UoW             uow.UnitOfWork
 	PasswordService *auth.PasswordService
 	JWTService      *auth.JwtService
+	Storage         storage.Storage
+	Inspector       service.FileInspector
+	AvatarPolicy    checker.Policy
+	AvatarSizes     []thumbnail.Variant
+	JPEGQuality     int
 }
 
-func (u *UserUsecaseStruct) Create(user *entity.User) (*entity.User, error) {
+func (u *UserUsecaseStruct) Create(ctx context.Context, user *entity.User) (*entity.User, error) {
 	hashed, err := u.PasswordService.HashPassword(user.Password)
 	if err != nil {
 		return nil, err
@@ -31,21 +56,34 @@ func (u *UserUsecaseStruct) Create(user *entity.User) (*entity.User, error) {
 		return nil, err
 	}
 
-	created, err := u.Repo.Create(&m)
+	var out entity.User
+	var events []event.UserEvent
+	err = u.atomically(ctx, func(ctx context.Context) error {
+		created, err := u.Repo.Create(ctx, &m)
+		if err != nil {
+			return err
+		}
+
+		if err := mapper.CopyTo(created, &out); err != nil {
+			return err
+		}
+		out.Password = ""
+		u.withAvatars(&out)
+
+		events = []event.UserEvent{newEvent(ctx, event.UserRegistered, out.ID, &out, nil)}
+		return u.record(ctx, events)
+	})
 	if err != nil {
-		return nil, err
+		return nil, domainError(err)
 	}
 
-	var out entity.User
-	if err := mapper.CopyTo(created, &out); err != nil {
-		return nil, err
-	}
+	u.publish(events)
 
 	return &out, nil
 }
 
-func (u *UserUsecaseStruct) Read(page, limit uint) ([]*entity.User, int64, error) {
-	ms, total, err := u.Repo.Read(page, limit)
+func (u *UserUsecaseStruct) Read(ctx context.Context, page, limit uint) ([]*entity.User, int64, error) {
+	ms, total, err := u.Repo.Read(ctx, page, limit)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -54,13 +92,28 @@ func (u *UserUsecaseStruct) Read(page, limit uint) ([]*entity.User, int64, error
 	if err != nil {
 		return nil, 0, err
 	}
+	u.withAvatars(es...)
 	return es, total, nil
 }
 
-func (u *UserUsecaseStruct) ReadByID(userID string) (*entity.User, error) {
-	m, err := u.Repo.ReadByID(userID)
+func (u *UserUsecaseStruct) ReadByCursor(ctx context.Context, cursor string, limit uint) ([]*entity.User, *pagination.CursorPage, error) {
+	cur, err := pagination.Decode(cursor)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	ms, hasMore, err := u.Repo.ReadByCursor(ctx, cur, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u.toCursorPage(ms, hasMore, cur)
+}
+
+func (u *UserUsecaseStruct) ReadByID(ctx context.Context, userID string) (*entity.User, error) {
+	m, err := u.Repo.ReadByID(ctx, userID)
+	if err != nil {
+		return nil, domainError(err)
 	}
 
 	var out entity.User
@@ -68,15 +121,12 @@ func (u *UserUsecaseStruct) ReadByID(userID string) (*entity.User, error) {
 
 	titleCased := typograph.ToTitleCase(out.Name)
 	out.Name = titleCased
+	u.withAvatars(&out)
 
 	return &out, nil
 }
 
-func (u *UserUsecaseStruct) Update(payload *entity.UpdateUserPayload) (*entity.User, error) {
-	if _, err := u.Repo.ReadByID(payload.ID); err != nil {
-		return nil, err
-	}
-
+func (u *UserUsecaseStruct) Update(ctx context.Context, payload *entity.UpdateUserPayload) (*entity.User, error) {
 	changes := make(map[string]any)
 
 	if payload.Name != nil {
@@ -103,39 +153,89 @@ func (u *UserUsecaseStruct) Update(payload *entity.UpdateUserPayload) (*entity.U
 		changes["role"] = *payload.Role
 	}
 
-	if len(changes) == 0 {
-		cur, err := u.Repo.ReadByID(payload.ID)
+	var updated *model.User
+	var events []event.UserEvent
+	err := u.atomically(ctx, func(ctx context.Context) error {
+		cur, err := u.Repo.ReadByID(ctx, payload.ID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		var out entity.User
-		if err := mapper.CopyTo(cur, &out); err != nil {
-			return nil, err
+		if payload.Version > 0 && cur.Version != payload.Version {
+			return &entity.VersionConflictError{UserID: payload.ID, Expected: payload.Version, Current: cur.Version}
 		}
 
-		return &out, nil
-	}
+		if len(changes) == 0 {
+			updated = cur
+			return nil
+		}
 
-	updated, err := u.Repo.UpdateFields(payload.ID, changes)
+		updated, err = u.Repo.UpdateFields(ctx, payload.ID, payload.Version, changes)
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			// another writer committed between our read and write
+			conflict := &entity.VersionConflictError{UserID: payload.ID, Expected: payload.Version}
+			if latest, rerr := u.Repo.ReadByID(ctx, payload.ID); rerr == nil {
+				conflict.Current = latest.Version
+			}
+			return conflict
+		}
+		if err != nil {
+			return err
+		}
+
+		var snapshot entity.User
+		if err := mapper.CopyTo(updated, &snapshot); err != nil {
+			return err
+		}
+		u.withAvatars(&snapshot)
+		events = updateEvents(ctx, cur, &snapshot, changes)
+		return u.record(ctx, events)
+	})
 	if err != nil {
-		return nil, err
+		return nil, domainError(err)
 	}
 
 	var res entity.User
 	if err := mapper.CopyTo(updated, &res); err != nil {
 		return nil, err
 	}
+	u.withAvatars(&res)
+
+	u.publish(events)
 
 	return &res, nil
 }
 
-func (u *UserUsecaseStruct) SoftDelete(userID string) error {
-	return u.Repo.SoftDelete(userID)
+func (u *UserUsecaseStruct) SoftDelete(ctx context.Context, userID string) error {
+	var (
+		avatar string
+		events []event.UserEvent
+	)
+	err := u.atomically(ctx, func(ctx context.Context) error {
+		cur, err := u.Repo.ReadByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := u.Repo.SoftDelete(ctx, userID); err != nil {
+			return err
+		}
+		avatar = cur.AvatarKey
+
+		events = []event.UserEvent{newEvent(ctx, event.UserDeleted, userID, nil, nil)}
+		return u.record(ctx, events)
+	})
+	if err != nil {
+		return domainError(err)
+	}
+
+	u.publish(events)
+	storage.DeleteQuietly(ctx, u.Storage, "users", u.avatarKeys(avatar)...)
+
+	return nil
 }
 
-func (u *UserUsecaseStruct) Search(page, limit uint, keyword string) ([]*entity.User, int64, error) {
-	ms, total, err := u.Repo.Search(page, limit, keyword)
+func (u *UserUsecaseStruct) Search(ctx context.Context, page, limit uint, keyword string) ([]*entity.User, int64, error) {
+	ms, total, err := u.Repo.Search(ctx, page, limit, keyword)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -144,21 +244,88 @@ func (u *UserUsecaseStruct) Search(page, limit uint, keyword string) ([]*entity.
 	if err != nil {
 		return nil, 0, err
 	}
+	u.withAvatars(es...)
 	return es, total, nil
 }
 
-func (u *UserUsecaseStruct) Login(email, password string) (string, error) {
-	m, err := u.Repo.ReadByEmailPrivate(email)
+func (u *UserUsecaseStruct) SearchRanked(ctx context.Context, keyword string, page, limit uint) ([]*entity.UserSearchResult, int64, error) {
+	hits, total, err := u.Searcher.Search(ctx, keyword, page, limit)
 	if err != nil {
-		return "", err
+		return nil, 0, err
 	}
 
-	if !m.Active {
-		return "", errors.New("user not active")
+	out := make([]*entity.UserSearchResult, 0, len(hits))
+	for _, h := range hits {
+		res := &entity.UserSearchResult{Score: h.Score, Highlights: h.Highlights}
+		if err := mapper.CopyTo(h.User, &res.User); err != nil {
+			return nil, 0, err
+		}
+		u.withAvatars(&res.User)
+		out = append(out, res)
+	}
+
+	return out, total, nil
+}
+
+func (u *UserUsecaseStruct) FuzzySearch(ctx context.Context, keyword, role string, active *bool, page, limit uint) (*entity.UserFacetedSearchResult, error) {
+	res, err := u.Index.Search(repository.UserIndexQuery{
+		Text:   keyword,
+		Role:   role,
+		Active: active,
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	out := &entity.UserFacetedSearchResult{
+		Results: make([]*entity.UserSearchResult, 0, len(res.Hits)),
+		Total:   res.Total,
+		Facets:  res.Facets,
+	}
+	for _, h := range res.Hits {
+		r := &entity.UserSearchResult{Score: h.Score}
+		if err := mapper.CopyTo(h.User, &r.User); err != nil {
+			return nil, err
+		}
+		u.withAvatars(&r.User)
+		out.Results = append(out.Results, r)
+	}
+
+	return out, nil
+}
+
+func (u *UserUsecaseStruct) SearchByCursor(ctx context.Context, cursor string, limit uint, keyword string) ([]*entity.User, *pagination.CursorPage, error) {
+	cur, err := pagination.Decode(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ms, hasMore, err := u.Repo.SearchByCursor(ctx, cur, limit, keyword)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u.toCursorPage(ms, hasMore, cur)
+}
+
+func (u *UserUsecaseStruct) Login(ctx context.Context, email, password string) (string, error) {
+	m, err := u.Repo.ReadByEmailPrivate(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", entity.ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+
+	/* Check the password first so inactive accounts are only revealed to their owners. */
 	if err := u.PasswordService.VerifyPassword(m.Password, password); err != nil {
-		return "", errors.New("wrong password")
+		return "", entity.ErrInvalidCredentials
+	}
+
+	if !m.Active {
+		return "", entity.ErrUserInactive
 	}
 
 	var e entity.User
@@ -174,10 +341,139 @@ func (u *UserUsecaseStruct) Login(email, password string) (string, error) {
 	return token, nil
 }
 
-func NewUserUsecase(repo repository.UserRepository, passwordService *auth.PasswordService, jwtService *auth.JwtService) usecase.UserUsecase {
+// domainError reports missing users and taken emails as the user domain
+// errors, keeping the repository error as the cause.
+func domainError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return apperror.Wrap(entity.ErrUserNotFound, err)
+	case errors.Is(err, repository.ErrDuplicateEmail):
+		return apperror.Wrap(entity.ErrEmailTaken, err)
+	default:
+		return err
+	}
+}
+
+// atomically runs fn in the configured unit of work, or directly when there
+// is none.
+func (u *UserUsecaseStruct) atomically(ctx context.Context, fn func(ctx context.Context) error) error {
+	if u.UoW == nil {
+		return fn(ctx)
+	}
+	return u.UoW.Do(ctx, fn)
+}
+
+// record writes events to the outbox inside the unit of work. It is a no-op
+// when no outbox is configured.
+func (u *UserUsecaseStruct) record(ctx context.Context, events []event.UserEvent) error {
+	if u.Outbox == nil || len(events) == 0 {
+		return nil
+	}
+	return u.Outbox.Record(ctx, events...)
+}
+
+// publish notifies in-process event handlers about a committed change. It
+// is a no-op when no publisher is configured.
+func (u *UserUsecaseStruct) publish(events []event.UserEvent) {
+	if u.Events == nil {
+		return
+	}
+	for _, e := range events {
+		u.Events.Publish(e)
+	}
+}
+
+// newEvent stamps an event with a fresh ID and records who made the change.
+func newEvent(ctx context.Context, name event.Name, userID string, user *entity.User, changed []string) event.UserEvent {
+	return event.UserEvent{
+		ID:         uuid.NewString(),
+		Name:       name,
+		UserID:     userID,
+		User:       user,
+		Changed:    changed,
+		ActorID:    actorID(ctx),
+		RequestID:  requestctx.RequestIDFrom(ctx),
+		OccurredAt: time.Now(),
+	}
+}
+
+// updateEvents returns user.updated for the changed fields, followed by the
+// specific events for an activation or role change compared to before.
+func updateEvents(ctx context.Context, before *model.User, after *entity.User, changes map[string]any) []event.UserEvent {
+	changed := make([]string, 0, len(changes))
+	for field := range changes {
+		changed = append(changed, field)
+	}
+	sort.Strings(changed)
+
+	events := []event.UserEvent{newEvent(ctx, event.UserUpdated, after.ID, after, changed)}
+	if before.Active != after.Active {
+		name := event.UserDeactivated
+		if after.Active {
+			name = event.UserActivated
+		}
+		events = append(events, newEvent(ctx, name, after.ID, after, []string{"active"}))
+	}
+	if before.Role != after.Role {
+		events = append(events, newEvent(ctx, event.UserRoleChanged, after.ID, after, []string{"role"}))
+	}
+	return events
+}
+
+func actorID(ctx context.Context) string {
+	if p, ok := requestctx.PrincipalFrom(ctx); ok {
+		return p.UserID
+	}
+	return ""
+}
+
+func (u *UserUsecaseStruct) toCursorPage(ms []*model.User, hasMore bool, cur *pagination.Cursor) ([]*entity.User, *pagination.CursorPage, error) {
+	es, err := mapper.MapStructList[model.User, entity.User](ms)
+	if err != nil {
+		return nil, nil, err
+	}
+	u.withAvatars(es...)
+
+	page := pagination.BuildPage(ms, hasMore, cur, func(m *model.User) (time.Time, string) {
+		return m.CreatedAt, m.ID
+	})
+
+	return es, &page, nil
+}
+
+// UserUsecaseDeps lists what NewUserUsecase wires together. MaxAvatarSize
+// caps uploads under AvatarPolicy.
+type UserUsecaseDeps struct {
+	Repo            repository.UserRepository
+	Searcher        repository.UserSearcher
+	Index           repository.UserIndex
+	Events          event.Publisher
+	Outbox          event.Outbox
+	UoW             uow.UnitOfWork
+	PasswordService *auth.PasswordService
+	JWTService      *auth.JwtService
+	Storage         storage.Storage
+	Inspector       service.FileInspector
+	MaxAvatarSize   int64
+}
+
+func NewUserUsecase(deps UserUsecaseDeps) usecase.UserUsecase {
+	policy := AvatarPolicy
+	policy.MaxSize = deps.MaxAvatarSize
+
 	return &UserUsecaseStruct{
-		Repo:            repo,
-		PasswordService: passwordService,
-		JWTService:      jwtService,
+		Repo:            deps.Repo,
+		Searcher:        deps.Searcher,
+		Index:           deps.Index,
+		Events:          deps.Events,
+		Outbox:          deps.Outbox,
+		UoW:             deps.UoW,
+		PasswordService: deps.PasswordService,
+		JWTService:      deps.JWTService,
+		Storage:         deps.Storage,
+		Inspector:       deps.Inspector,
+		AvatarPolicy:    policy,
+		AvatarSizes:     AvatarSizes,
+		JPEGQuality:     85,
 	}
 }
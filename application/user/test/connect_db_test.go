@@ -1,22 +1,39 @@
 package test
 
 import (
+	"context"
+	"log"
+	"os"
 	"testing"
 
-	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
 	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
+/* TestMain registers the validation rules the request DTOs use, as the servers do on start-up. */
+func TestMain(m *testing.M) {
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
 func setupTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
 
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err, "failed to open in-memory SQLite database")
 
-	/* Ensure the schema exists for all tests. The model should include DeletedAt so that soft deletes are correctly handled by GORM. */
-	require.NoError(t, db.AutoMigrate(&model.User{}), "failed to auto-migrate schema")
+	/* Every new connection to ":memory:" opens an empty database, so keep a single connection that transactions and plain queries share. */
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	/* Build the schema with the same embedded SQLite migrations the application ships, including the FTS5 index and its triggers. */
+	require.NoError(t, migration.Migrate(context.Background(), db), "failed to migrate schema")
 
 	return db
 }
@@ -0,0 +1,395 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the outbox tests from the project root:
+     go test -v -run Outbox ./application/user/test
+
+Notes:
+- The relay runs against the SQLite outbox table with a fake clock, so
+  retries and backoff are checked without sleeping.
+- The webhook sink posts to an httptest server and the NATS sink to a fake
+  JetStream publisher; no broker is needed.
+===============================================================================
+*/
+
+func outboxTopics(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+
+	var rows []model.OutboxMessage
+	require.NoError(t, db.Order("created_at, topic").Find(&rows).Error)
+	topics := make([]string, len(rows))
+	for i, r := range rows {
+		topics[i] = r.Topic
+	}
+	return topics
+}
+
+// recordingSink remembers deliveries and fails while failures is positive.
+type recordingSink struct {
+	mu        sync.Mutex
+	delivered []outbox.Message
+	failures  int
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Deliver(ctx context.Context, msg outbox.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.delivered = append(s.delivered, msg)
+	return nil
+}
+
+type failingStore struct{ outbox.Store }
+
+func (failingStore) Add(ctx context.Context, msgs ...outbox.Message) error {
+	return errors.New("outbox unavailable")
+}
+
+/*
+TestOutbox_UsecaseRecordsDomainEvents verifies that registration, an
+update that activates and promotes a user, and a deletion each leave their
+events in the outbox, with payloads that decode back to the event.
+*/
+func TestOutbox_UsecaseRecordsDomainEvents(t *testing.T) {
+	ctx := context.Background()
+	uc, db := newUsecase(t)
+	uc.UoW = uow_impl.NewGormUnitOfWork(db)
+	uc.Outbox = event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(db))
+
+	created, err := uc.Create(ctx, makeEntityUser("Olga", "olga@example.com", "pw", "USER", false))
+	require.NoError(t, err)
+	require.Equal(t, []string{"user.registered"}, outboxTopics(t, db))
+
+	_, err = uc.Update(ctx, &entity.UpdateUserPayload{ID: created.ID, Active: ptrBool(true), Role: ptrString("ADMIN"), Name: ptrString("Olga")})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"user.registered", "user.updated", "user.activated", "user.role_changed"}, outboxTopics(t, db))
+
+	require.NoError(t, uc.SoftDelete(ctx, created.ID))
+	require.Contains(t, outboxTopics(t, db), "user.deleted")
+
+	var row model.OutboxMessage
+	require.NoError(t, db.First(&row, "topic = ?", "user.updated").Error)
+	require.Equal(t, created.ID, row.MessageKey)
+	e, err := event_impl.DecodeUserEvent(outbox.Message{Payload: []byte(row.Payload)})
+	require.NoError(t, err)
+	require.Equal(t, event.UserUpdated, e.Name)
+	require.Equal(t, row.ID, e.ID, "the message ID is the event ID")
+	require.Equal(t, []string{"active", "name", "role"}, e.Changed)
+	require.Equal(t, "ADMIN", e.User.Role)
+	require.NotContains(t, row.Payload, "password")
+}
+
+/*
+TestOutbox_CarriesTheAvatarKey verifies that setting and removing an avatar
+records updates whose snapshot carries the new avatar key, and that the
+key is left out of the payload once the avatar is gone.
+*/
+func TestOutbox_CarriesTheAvatarKey(t *testing.T) {
+	ctx := context.Background()
+	uc, db := newUsecase(t)
+	uc.UoW = uow_impl.NewGormUnitOfWork(db)
+	uc.Outbox = event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(db))
+	uc.Storage = storage_impl.NewMemoryStorage("/files")
+	uc.Inspector = checker.NewInspector(nil)
+	uc.AvatarPolicy = usecase_impl.AvatarPolicy
+	uc.AvatarPolicy.MaxSize = testAvatarLimit
+	uc.AvatarSizes = usecase_impl.AvatarSizes
+	uc.JPEGQuality = 85
+
+	created, err := uc.Create(ctx, makeEntityUser("Olga", "olga@example.com", "pw", "USER", true))
+	require.NoError(t, err)
+
+	lastUpdate := func() (event.UserEvent, string) {
+		var row model.OutboxMessage
+		require.NoError(t, db.Where("topic = ?", "user.updated").Order("created_at DESC").First(&row).Error)
+		e, err := event_impl.DecodeUserEvent(outbox.Message{Payload: []byte(row.Payload)})
+		require.NoError(t, err)
+		return e, row.Payload
+	}
+
+	withAvatar, err := uc.SetAvatar(ctx, created.ID, avatarOf("olga.png", avatarImage(t, 64, 64)))
+	require.NoError(t, err)
+	e, _ := lastUpdate()
+	require.Equal(t, []string{"avatar_key"}, e.Changed)
+	require.Equal(t, withAvatar.AvatarKey, e.User.AvatarKey)
+
+	_, err = uc.RemoveAvatar(ctx, created.ID)
+	require.NoError(t, err)
+	e, payload := lastUpdate()
+	require.Empty(t, e.User.AvatarKey)
+	require.NotContains(t, payload, "avatar_key\":")
+}
+
+/*
+TestOutbox_DeletingAMissingUserRecordsNothing verifies that deleting an
+unknown or already deleted user fails with not found and leaves no
+user.deleted event for the relay to send.
+*/
+func TestOutbox_DeletingAMissingUserRecordsNothing(t *testing.T) {
+	ctx := context.Background()
+	uc, db := newUsecase(t)
+	uc.UoW = uow_impl.NewGormUnitOfWork(db)
+	uc.Outbox = event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(db))
+
+	err := uc.SoftDelete(ctx, "missing")
+	require.ErrorIs(t, err, entity.ErrUserNotFound)
+	require.Empty(t, outboxTopics(t, db))
+
+	created, err := uc.Create(ctx, makeEntityUser("Olga", "olga@example.com", "pw", "USER", true))
+	require.NoError(t, err)
+	require.NoError(t, uc.SoftDelete(ctx, created.ID))
+	require.ErrorIs(t, uc.SoftDelete(ctx, created.ID), entity.ErrUserNotFound)
+	require.ElementsMatch(t, []string{"user.registered", "user.deleted"}, outboxTopics(t, db))
+}
+
+/*
+TestOutbox_FailedRecordRollsBackTheChange verifies that the change and its
+events commit together: when the outbox write fails, the user is not
+created and no in-process event is published.
+*/
+func TestOutbox_FailedRecordRollsBackTheChange(t *testing.T) {
+	ctx := context.Background()
+	uc, db := newUsecase(t)
+	rec := &recordingHandler{}
+	uc.UoW = uow_impl.NewGormUnitOfWork(db)
+	uc.Outbox = event_impl.NewOutboxRecorder(failingStore{})
+	uc.Events = event_impl.NewInProcessPublisher(rec)
+
+	_, err := uc.Create(ctx, makeEntityUser("Rolf", "rolf@example.com", "pw", "USER", true))
+	require.Error(t, err)
+	require.Empty(t, rec.events)
+
+	var count int64
+	require.NoError(t, db.Model(&model.User{}).Count(&count).Error)
+	require.Zero(t, count)
+}
+
+/*
+TestOutbox_RelayDeliversRetriesAndGivesUp verifies that the relay marks
+delivered messages as published, retries failures after an exponential
+backoff, and marks a message dead after the maximum number of attempts.
+*/
+func TestOutbox_RelayDeliversRetriesAndGivesUp(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	store := outbox_impl.NewGormStore(db)
+	now := time.Now()
+	store.Now = func() time.Time { return now }
+
+	sink := &recordingSink{failures: 1}
+	relay := outbox_impl.NewRelay(store, sink)
+	relay.Now = store.Now
+	relay.MaxAttempts = 3
+
+	require.NoError(t, store.Add(ctx, outbox.Message{Topic: "user.registered", Key: "u1", Payload: []byte(`{}`)}))
+
+	n, err := relay.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Empty(t, sink.delivered)
+
+	var row model.OutboxMessage
+	require.NoError(t, db.First(&row).Error)
+	require.Equal(t, 1, row.Attempts)
+	require.Equal(t, "recording: sink unavailable", row.LastError)
+	require.WithinDuration(t, now.Add(time.Second), row.AvailableAt, time.Millisecond)
+
+	n, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n, "not due before the backoff has passed")
+
+	now = now.Add(time.Second)
+	_, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Len(t, sink.delivered, 1)
+	require.Equal(t, "u1", sink.delivered[0].Key)
+	require.Equal(t, 1, sink.delivered[0].Attempts)
+
+	require.NoError(t, db.First(&row).Error)
+	require.NotNil(t, row.PublishedAt)
+	n, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n, "published messages are not delivered again")
+
+	sink.failures = 10
+	require.NoError(t, store.Add(ctx, outbox.Message{Topic: "user.deleted", Key: "u2", Payload: []byte(`{}`)}))
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Hour)
+		_, err = relay.RunOnce(ctx)
+		require.NoError(t, err)
+	}
+	var dead model.OutboxMessage
+	require.NoError(t, db.First(&dead, "message_key = ?", "u2").Error)
+	require.Equal(t, 3, dead.Attempts)
+	require.NotNil(t, dead.DeadAt)
+	require.Nil(t, dead.PublishedAt)
+}
+
+/*
+TestOutbox_ConcurrentRelaysClaimEachMessageOnce verifies that two relays
+polling the same table never deliver the same message at the same time.
+*/
+func TestOutbox_ConcurrentRelaysClaimEachMessageOnce(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	store := outbox_impl.NewGormStore(db)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, store.Add(ctx, outbox.Message{Topic: "user.updated", Payload: []byte(`{}`)}))
+	}
+
+	sink := &recordingSink{}
+	relays := []*outbox_impl.Relay{outbox_impl.NewRelay(store, sink), outbox_impl.NewRelay(store, sink)}
+	var wg sync.WaitGroup
+	for _, r := range relays {
+		r.BatchSize = 5
+		wg.Add(1)
+		go func(r *outbox_impl.Relay) {
+			defer wg.Done()
+			for {
+				n, err := r.RunOnce(ctx)
+				if err != nil || n == 0 {
+					return
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, m := range sink.delivered {
+		require.False(t, seen[m.ID], "message %s delivered twice", m.ID)
+		seen[m.ID] = true
+	}
+	require.Len(t, seen, 20)
+}
+
+/*
+TestOutbox_BusDeliversToSubscribers verifies topic and wildcard
+subscriptions on the in-process bus, and that a failing subscriber makes
+the delivery fail so the relay retries it.
+*/
+func TestOutbox_BusDeliversToSubscribers(t *testing.T) {
+	ctx := context.Background()
+	bus := outbox_impl.NewBus()
+
+	var names []event.Name
+	bus.Subscribe("user.registered", func(ctx context.Context, msg outbox.Message) error {
+		e, err := event_impl.DecodeUserEvent(msg)
+		names = append(names, e.Name)
+		return err
+	})
+	var all int
+	bus.Subscribe(outbox_impl.AllTopics, func(ctx context.Context, msg outbox.Message) error {
+		all++
+		return nil
+	})
+
+	payload, err := event_impl.EncodeUserEvent(event.UserEvent{ID: "e1", Name: event.UserRegistered, UserID: "u1"})
+	require.NoError(t, err)
+	require.NoError(t, bus.Deliver(ctx, outbox.Message{ID: "e1", Topic: "user.registered", Payload: payload}))
+	require.NoError(t, bus.Deliver(ctx, outbox.Message{ID: "e2", Topic: "user.deleted", Payload: []byte(`{}`)}))
+	require.Equal(t, []event.Name{event.UserRegistered}, names)
+	require.Equal(t, 2, all)
+
+	bus.Subscribe("user.deleted", func(ctx context.Context, msg outbox.Message) error {
+		return errors.New("consumer down")
+	})
+	require.Error(t, bus.Deliver(ctx, outbox.Message{ID: "e3", Topic: "user.deleted", Payload: []byte(`{}`)}))
+}
+
+/*
+TestOutbox_WebhookSinkPostsPayload verifies the webhook request and that a
+non-2xx response is reported as a failed delivery.
+*/
+func TestOutbox_WebhookSinkPostsPayload(t *testing.T) {
+	ctx := context.Background()
+
+	var got *http.Request
+	var body []byte
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := outbox_impl.NewWebhookSink(server.URL, server.Client())
+	msg := outbox.Message{ID: "m1", Topic: "user.deleted", Key: "u1", Payload: []byte(`{"type":"user.deleted"}`), Attempts: 2}
+	require.NoError(t, sink.Deliver(ctx, msg))
+	require.Equal(t, http.MethodPost, got.Method)
+	require.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	require.Equal(t, "m1", got.Header.Get(outbox_impl.HeaderMessageID))
+	require.Equal(t, "user.deleted", got.Header.Get(outbox_impl.HeaderTopic))
+	require.Equal(t, "3", got.Header.Get(outbox_impl.HeaderAttempt))
+	require.JSONEq(t, `{"type":"user.deleted"}`, string(body))
+
+	status = http.StatusBadGateway
+	require.Error(t, sink.Deliver(ctx, msg))
+}
+
+type fakeJetStream struct {
+	msgs []*nats.Msg
+	err  error
+}
+
+func (f *fakeJetStream) PublishMsg(m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.msgs = append(f.msgs, m)
+	return &nats.PubAck{Stream: "USERS", Sequence: uint64(len(f.msgs))}, nil
+}
+
+/*
+TestOutbox_NATSSinkPublishesWithDedupID verifies the subject and the
+Nats-Msg-Id header JetStream uses to drop redelivered messages.
+*/
+func TestOutbox_NATSSinkPublishesWithDedupID(t *testing.T) {
+	ctx := context.Background()
+	js := &fakeJetStream{}
+	sink := outbox_impl.NewNATSSink(js, "app.")
+
+	require.NoError(t, sink.Deliver(ctx, outbox.Message{ID: "m1", Topic: "user.registered", Key: "u1", Payload: []byte(`{}`)}))
+	require.Len(t, js.msgs, 1)
+	require.Equal(t, "app.user.registered", js.msgs[0].Subject)
+	require.Equal(t, "m1", js.msgs[0].Header.Get(nats.MsgIdHdr))
+	require.Equal(t, []byte(`{}`), js.msgs[0].Data)
+
+	js.err = nats.ErrNoResponders
+	require.ErrorIs(t, sink.Deliver(ctx, outbox.Message{ID: "m2", Topic: "user.deleted"}), nats.ErrNoResponders)
+}
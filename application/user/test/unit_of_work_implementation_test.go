@@ -0,0 +1,209 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the unit of work tests from the project root:
+     go test -v -run UnitOfWork ./application/user/test
+
+Notes:
+- The GORM tests use the shared in-memory SQLite database, which supports
+  SAVEPOINT, so nested units of work are exercised against a real engine.
+- The memory tests use OnRollback the way an in-memory repository fake would.
+===============================================================================
+*/
+
+var errAbort = errors.New("abort")
+
+/*
+TestGormUnitOfWork_CommitsAndRollsBack verifies that repository calls made
+with the unit of work context are committed together or not at all.
+*/
+func TestGormUnitOfWork_CommitsAndRollsBack(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+	uow := uow_impl.NewGormUnitOfWork(db)
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, makeUser("Kept", "kept@example.com")); err != nil {
+			return err
+		}
+		_, err := repo.Create(ctx, makeUser("Kept Too", "kept2@example.com"))
+		return err
+	})
+	require.NoError(t, err)
+
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, makeUser("Lost", "lost@example.com")); err != nil {
+			return err
+		}
+
+		/* The uncommitted row is visible inside the unit of work. */
+		if _, err := repo.ReadByEmailPublic(ctx, "lost@example.com"); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	require.ErrorIs(t, err, errAbort)
+
+	_, err = repo.ReadByEmailPublic(ctx, "lost@example.com")
+	require.ErrorIs(t, err, repository.ErrNotFound, "rolled back row must not be stored")
+
+	_, total, err := repo.Read(ctx, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total)
+}
+
+/*
+TestGormUnitOfWork_NestedUsesSavepoints verifies that a failing inner unit
+only undoes its own changes when the outer unit handles the error, and that
+an outer failure undoes committed inner units as well.
+*/
+func TestGormUnitOfWork_NestedUsesSavepoints(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+	uow := uow_impl.NewGormUnitOfWork(db)
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, makeUser("Outer", "outer@example.com")); err != nil {
+			return err
+		}
+
+		inner := uow.Do(ctx, func(ctx context.Context) error {
+			if _, err := repo.Create(ctx, makeUser("Inner", "inner@example.com")); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		require.ErrorIs(t, inner, errAbort)
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = repo.ReadByEmailPublic(ctx, "outer@example.com")
+	require.NoError(t, err, "outer change should be committed")
+	_, err = repo.ReadByEmailPublic(ctx, "inner@example.com")
+	require.ErrorIs(t, err, repository.ErrNotFound, "inner change should be rolled back to the savepoint")
+
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		if err := uow.Do(ctx, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, makeUser("Released", "released@example.com"))
+			return err
+		}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	require.ErrorIs(t, err, errAbort)
+
+	_, err = repo.ReadByEmailPublic(ctx, "released@example.com")
+	require.ErrorIs(t, err, repository.ErrNotFound, "outer rollback should undo released savepoints")
+}
+
+/*
+TestGormUnitOfWork_RollsBackOnPanic verifies that a panic inside the unit of
+work rolls the transaction back and is propagated to the caller.
+*/
+func TestGormUnitOfWork_RollsBackOnPanic(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+	uow := uow_impl.NewGormUnitOfWork(db)
+
+	require.Panics(t, func() {
+		_ = uow.Do(ctx, func(ctx context.Context) error {
+			if _, err := repo.Create(ctx, makeUser("Boom", "boom@example.com")); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+
+	_, err := repo.ReadByEmailPublic(ctx, "boom@example.com")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+/*
+TestUsecase_Update_RunsInUnitOfWork verifies that Update reads and writes
+through the unit of work and still reports missing users.
+*/
+func TestUsecase_Update_RunsInUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _ := newUsecase(t)
+	uow := uow_impl.NewMemoryUnitOfWork()
+	uc.UoW = uow
+
+	created, err := uc.Create(ctx, makeEntityUser("Jill", "jill@ex.com", "pw", "USER", true))
+	require.NoError(t, err)
+
+	out, err := uc.Update(ctx, &entity.UpdateUserPayload{ID: created.ID, Name: ptrString("Jillian")})
+	require.NoError(t, err)
+	require.Equal(t, "Jillian", out.Name)
+	require.Equal(t, 2, uow.Commits, "Create and Update each run in a unit of work")
+
+	_, err = uc.Update(ctx, &entity.UpdateUserPayload{ID: "missing", Name: ptrString("X")})
+	require.ErrorIs(t, err, repository.ErrNotFound)
+	require.Equal(t, 1, uow.Rollbacks)
+}
+
+/*
+TestMemoryUnitOfWork_UndoesInReverseOrder verifies the in-memory unit of
+work: undo actions run newest first on failure, released nested units are
+undone with their parent, and nothing is undone on success.
+*/
+func TestMemoryUnitOfWork_UndoesInReverseOrder(t *testing.T) {
+	ctx := context.Background()
+	uow := uow_impl.NewMemoryUnitOfWork()
+
+	var log []string
+	record := func(ctx context.Context, name string) {
+		uow_impl.OnRollback(ctx, func() { log = append(log, "undo "+name) })
+	}
+
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		record(ctx, "a")
+		require.NoError(t, uow.Do(ctx, func(ctx context.Context) error {
+			record(ctx, "b")
+			return nil
+		}))
+		require.ErrorIs(t, uow.Do(ctx, func(ctx context.Context) error {
+			record(ctx, "c")
+			return errAbort
+		}), errAbort)
+		return errAbort
+	})
+	require.ErrorIs(t, err, errAbort)
+	require.Equal(t, []string{"undo c", "undo b", "undo a"}, log)
+	require.Equal(t, 1, uow.Commits)
+	require.Equal(t, 2, uow.Rollbacks)
+
+	log = nil
+	require.NoError(t, uow.Do(ctx, func(ctx context.Context) error {
+		record(ctx, "d")
+		return nil
+	}))
+	require.Empty(t, log)
+
+	/* Outside a unit of work there is nothing to roll back to. */
+	record(ctx, "e")
+	require.Empty(t, log)
+}
@@ -0,0 +1,309 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
+	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
+	user_router "github.com/celpung/gocleanarch/delivery/std/chi/user/router"
+	"github.com/celpung/gocleanarch/infrastructure/auth"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide (Windows / macOS / Linux)
+
+1) Run all tests in this package from the folder containing this file:
+     go test -v .
+
+2) Run a specific test using a regex:
+     go test -v -run ^TestUserAvatar_ .
+
+Notes:
+- Avatars are written to a MemoryStorage, so tests can read every stored
+  file back and leave nothing behind.
+- Images are generated in memory; no fixtures are needed.
+===============================================================================
+*/
+
+const testAvatarLimit = 1 << 20
+
+// newAvatarUsecase is newUsecase with avatars stored in memory.
+func newAvatarUsecase(t *testing.T) (*usecase_impl.UserUsecaseStruct, *storage_impl.MemoryStorage) {
+	t.Helper()
+
+	uc, _ := newUsecase(t)
+	store := storage_impl.NewMemoryStorage("/files")
+	uc.Storage = store
+	uc.Inspector = checker.NewInspector(nil)
+	uc.AvatarPolicy = usecase_impl.AvatarPolicy
+	uc.AvatarPolicy.MaxSize = testAvatarLimit
+	uc.AvatarSizes = usecase_impl.AvatarSizes
+	uc.JPEGQuality = 85
+	return uc, store
+}
+
+// avatarImage encodes a width x height PNG.
+func avatarImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 120, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func avatarOf(filename string, content []byte) *entity.Avatar {
+	return &entity.Avatar{Filename: filename, Size: int64(len(content)), Content: bytes.NewReader(content)}
+}
+
+// storedSize decodes the dimensions of the stored file behind url.
+func storedSize(t *testing.T, store *storage_impl.MemoryStorage, url string) (int, int) {
+	t.Helper()
+
+	r, _, err := store.Get(context.Background(), strings.TrimPrefix(url, "/files/"))
+	require.NoError(t, err)
+	defer r.Close()
+
+	cfg, format, err := image.DecodeConfig(r)
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", format)
+	return cfg.Width, cfg.Height
+}
+
+func sortedKeys(store *storage_impl.MemoryStorage) []string {
+	keys := store.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+/*
+TestUserAvatar_StoresSquareSizes verifies that an avatar is cropped to a
+square in every standard size, small images included, and that users are
+read back with the avatar URLs, from the database and from the index.
+*/
+func TestUserAvatar_StoresSquareSizes(t *testing.T) {
+	ctx := context.Background()
+	uc, store := newAvatarUsecase(t)
+	index, _ := openTestIndex(t)
+	uc.Index = index
+	uc.Events = event_impl.NewInProcessPublisher(index)
+
+	created, err := uc.Create(ctx, makeEntityUser("Alice", "alice@ex.com", "secret123", "USER", true))
+	require.NoError(t, err)
+	require.Empty(t, created.Avatars)
+
+	updated, err := uc.SetAvatar(ctx, created.ID, avatarOf("me.png", avatarImage(t, 300, 200)))
+	require.NoError(t, err)
+	require.Len(t, updated.Avatars, len(usecase_impl.AvatarSizes))
+	require.True(t, strings.HasPrefix(updated.AvatarKey, "avatars/"+created.ID+"/"))
+	require.Greater(t, updated.Version, created.Version)
+
+	for _, size := range usecase_impl.AvatarSizes {
+		w, h := storedSize(t, store, updated.Avatars[size.Name])
+		require.Equal(t, size.Width, w, size.Name)
+		require.Equal(t, size.Width, h, size.Name)
+	}
+
+	got, err := uc.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated.Avatars, got.Avatars)
+
+	users, _, err := uc.Read(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, updated.Avatars, users[0].Avatars)
+
+	results, _, err := uc.SearchRanked(ctx, "alice", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, updated.Avatars, results[0].User.Avatars)
+
+	fuzzy, err := uc.FuzzySearch(ctx, "alice", "", nil, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, fuzzy.Results, 1)
+	require.Equal(t, updated.Avatars, fuzzy.Results[0].User.Avatars)
+}
+
+/*
+TestUserAvatar_ReplaceRemoveAndDelete verifies that a new avatar replaces the
+files of the old one, and that removing the avatar or deleting the user
+removes its files.
+*/
+func TestUserAvatar_ReplaceRemoveAndDelete(t *testing.T) {
+	ctx := context.Background()
+	uc, store := newAvatarUsecase(t)
+
+	user, err := uc.Create(ctx, makeEntityUser("Bob", "bob@ex.com", "secret123", "USER", true))
+	require.NoError(t, err)
+
+	first, err := uc.SetAvatar(ctx, user.ID, avatarOf("a.png", avatarImage(t, 100, 100)))
+	require.NoError(t, err)
+	second, err := uc.SetAvatar(ctx, user.ID, avatarOf("b.png", avatarImage(t, 120, 80)))
+	require.NoError(t, err)
+	require.NotEqual(t, first.AvatarKey, second.AvatarKey)
+
+	keys := sortedKeys(store)
+	require.Len(t, keys, len(usecase_impl.AvatarSizes))
+	for _, key := range keys {
+		require.True(t, strings.HasPrefix(key, second.AvatarKey+"/"), "old avatar should be gone, found %s", key)
+	}
+
+	removed, err := uc.RemoveAvatar(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, removed.AvatarKey)
+	require.Empty(t, removed.Avatars)
+	require.Empty(t, store.Keys())
+
+	/* Removing a missing avatar is not an error. */
+	_, err = uc.RemoveAvatar(ctx, user.ID)
+	require.NoError(t, err)
+
+	_, err = uc.SetAvatar(ctx, user.ID, avatarOf("c.png", avatarImage(t, 64, 64)))
+	require.NoError(t, err)
+	require.NoError(t, uc.SoftDelete(ctx, user.ID))
+	require.Empty(t, store.Keys())
+}
+
+/*
+TestUserAvatar_RejectsInvalidUploads verifies that avatars go through the
+upload inspector and that nothing is stored for rejected uploads or
+unknown users.
+*/
+func TestUserAvatar_RejectsInvalidUploads(t *testing.T) {
+	ctx := context.Background()
+	uc, store := newAvatarUsecase(t)
+
+	user, err := uc.Create(ctx, makeEntityUser("Carol", "carol@ex.com", "secret123", "USER", true))
+	require.NoError(t, err)
+
+	_, err = uc.SetAvatar(ctx, user.ID, nil)
+	require.ErrorIs(t, err, entity.ErrInvalidAvatar)
+
+	_, err = uc.SetAvatar(ctx, user.ID, avatarOf("me.png", []byte("%PDF-1.7 not a picture")))
+	require.ErrorIs(t, err, entity.ErrInvalidAvatar)
+	require.ErrorIs(t, err, checker.ErrUnsupportedType)
+
+	_, err = uc.SetAvatar(ctx, user.ID, avatarOf("me.gif", avatarImage(t, 10, 10)))
+	require.ErrorIs(t, err, entity.ErrInvalidAvatar)
+	require.ErrorIs(t, err, checker.ErrExtensionMismatch)
+
+	big := avatarImage(t, 10, 10)
+	_, err = uc.SetAvatar(ctx, user.ID, &entity.Avatar{Filename: "me.png", Size: testAvatarLimit + 1, Content: bytes.NewReader(big)})
+	require.ErrorIs(t, err, entity.ErrAvatarTooLarge)
+
+	_, err = uc.SetAvatar(ctx, "00000000-0000-4000-8000-000000000000", avatarOf("me.png", avatarImage(t, 10, 10)))
+	require.Error(t, err)
+
+	require.Empty(t, store.Keys())
+}
+
+/*
+TestUserAvatar_Routes verifies that signed in users upload and remove their
+own avatar over multipart, and that responses carry the avatar URLs.
+*/
+func TestUserAvatar_Routes(t *testing.T) {
+	prevDB, prevCache, prevIndex := database.DB, cache_impl.Shared, searchindex.Users
+	prevStorage, prevSize, prevInspector := storage_impl.Shared, storage_impl.MaxUploadSize, checker.Shared
+	t.Cleanup(func() {
+		database.DB, cache_impl.Shared, searchindex.Users = prevDB, prevCache, prevIndex
+		storage_impl.Shared, storage_impl.MaxUploadSize, checker.Shared = prevStorage, prevSize, prevInspector
+	})
+
+	db := setupTestDB(t)
+	index, err := searchindex.Open(filepath.Join(t.TempDir(), "users.idx"), nil)
+	require.NoError(t, err)
+	store := storage_impl.NewMemoryStorage("/files")
+	database.DB, cache_impl.Shared, searchindex.Users = db, nil, index
+	storage_impl.Shared, storage_impl.MaxUploadSize, checker.Shared = store, testAvatarLimit, checker.NewInspector(nil)
+
+	user, err := (&usecase_impl.UserUsecaseStruct{
+		Repo:            repository_impl.NewUserRepository(db),
+		Searcher:        search_impl.NewUserSearcher(db),
+		PasswordService: auth.NewPasswordService(),
+	}).Create(context.Background(), makeEntityUser("Dana", "dana@ex.com", "secret123", "USER", true))
+	require.NoError(t, err)
+	token, err := auth.NewJwtService().JWTGenerator(*user)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	user_router.Router(r)
+
+	do := func(method string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/users/avatar", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	upload := func(filename string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("avatar", filename)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		return do(http.MethodPut, &body, mw.FormDataContentType())
+	}
+
+	require.Equal(t, http.StatusBadRequest, upload("notes.txt", []byte("plain text")).Code)
+	require.Equal(t, http.StatusRequestEntityTooLarge, upload("huge.png", bytes.Repeat([]byte{0x89}, testAvatarLimit+2<<20)).Code)
+
+	rec := upload("me.png", avatarImage(t, 200, 200))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotEmpty(t, rec.Header().Get("ETag"))
+
+	var res struct {
+		User struct {
+			ID      string            `json:"id"`
+			Avatars map[string]string `json:"avatars"`
+		} `json:"user"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, user.ID, res.User.ID)
+	require.Len(t, res.User.Avatars, len(usecase_impl.AvatarSizes))
+	require.True(t, strings.HasPrefix(res.User.Avatars["small"], "/files/avatars/"+user.ID+"/"))
+	require.Len(t, store.Keys(), len(usecase_impl.AvatarSizes))
+
+	rec = do(http.MethodDelete, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotContains(t, rec.Body.String(), `"avatars"`)
+	require.Empty(t, store.Keys())
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/avatar", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,298 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	"github.com/celpung/gocleanarch/infrastructure/cache"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the cache tests from the project root:
+     go test -v -run Cache ./application/user/test
+
+Notes:
+- The Redis implementation runs against miniredis, an in-memory server that
+  speaks the Redis protocol, so no Redis installation is needed.
+===============================================================================
+*/
+
+// countingRepository counts the lookups that reach the database and can
+// slow them down to widen race windows.
+type countingRepository struct {
+	repository.UserRepository
+	byID    atomic.Int32
+	byEmail atomic.Int32
+	delay   time.Duration
+}
+
+func (r *countingRepository) ReadByID(ctx context.Context, userID string) (*model.User, error) {
+	r.byID.Add(1)
+	time.Sleep(r.delay)
+	return r.UserRepository.ReadByID(ctx, userID)
+}
+
+func (r *countingRepository) ReadByEmailPublic(ctx context.Context, email string) (*model.User, error) {
+	r.byEmail.Add(1)
+	time.Sleep(r.delay)
+	return r.UserRepository.ReadByEmailPublic(ctx, email)
+}
+
+func newCachedRepository(t *testing.T, c cache.Cache) (repository.UserRepository, *countingRepository, *gorm.DB) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	counting := &countingRepository{UserRepository: repository_impl.NewUserRepository(db)}
+	return repository_impl.NewCachedUserRepository(counting, c, time.Minute), counting, db
+}
+
+/*
+TestCache_LRUEvictsAndExpires verifies that the in-process cache evicts the
+least recently used entry when full and drops entries past their TTL.
+*/
+func TestCache_LRUEvictsAndExpires(t *testing.T) {
+	ctx := context.Background()
+	c := cache_impl.NewLRUCache(2)
+	now := time.Now()
+	c.Now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+	_, ok, _ := c.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = c.Get(ctx, "b")
+	require.False(t, ok, "b was least recently used")
+	require.Equal(t, 2, c.Len())
+
+	now = now.Add(2 * time.Minute)
+	_, ok, _ = c.Get(ctx, "a")
+	require.False(t, ok, "a expired")
+	v, ok, _ := c.Get(ctx, "c")
+	require.True(t, ok, "entries without TTL do not expire")
+	require.Equal(t, []byte("3"), v)
+
+	require.NoError(t, c.Delete(ctx, "c", "missing"))
+	require.Equal(t, 0, c.Len())
+}
+
+/*
+TestCache_RedisStoresWithPrefixAndTTL verifies the Redis implementation
+against miniredis: prefixed keys, misses, expiry and deletes.
+*/
+func TestCache_RedisStoresWithPrefixAndTTL(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c := cache_impl.NewRedisCache(redis.NewClient(&redis.Options{Addr: server.Addr()}), "app:")
+
+	_, ok, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), time.Minute))
+	require.True(t, server.Exists("app:k"))
+	v, ok, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("v"), v)
+
+	server.FastForward(2 * time.Minute)
+	_, ok, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), 0))
+	require.NoError(t, c.Delete(ctx, "k"))
+	require.False(t, server.Exists("app:k"))
+
+	server.Close()
+	_, _, err = c.Get(ctx, "k")
+	require.Error(t, err, "a broken backend is reported")
+}
+
+/*
+TestCache_RepositoryServesHitsAndInvalidatesOnWrites verifies that repeated
+lookups reach the database once and that UpdateFields and SoftDelete drop
+the cached user.
+*/
+func TestCache_RepositoryServesHitsAndInvalidatesOnWrites(t *testing.T) {
+	ctx := context.Background()
+	repo, counting, _ := newCachedRepository(t, cache_impl.NewLRUCache(0))
+
+	created, err := repo.Create(ctx, makeUser("Alice", "alice@example.com"))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		got, err := repo.ReadByID(ctx, created.ID)
+		require.NoError(t, err)
+		require.Equal(t, "Alice", got.Name)
+	}
+	require.EqualValues(t, 1, counting.byID.Load())
+
+	_, err = repo.UpdateFields(ctx, created.ID, 0, map[string]any{"name": "Alicia"})
+	require.NoError(t, err)
+	got, err := repo.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Alicia", got.Name)
+	require.EqualValues(t, 2, counting.byID.Load())
+
+	require.NoError(t, repo.SoftDelete(ctx, created.ID))
+	_, err = repo.ReadByID(ctx, created.ID)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+/*
+TestCache_RepositoryEmailKeyFollowsEmailChanges verifies that lookups by
+email are cached and that an old address stops resolving after the user
+changes it.
+*/
+func TestCache_RepositoryEmailKeyFollowsEmailChanges(t *testing.T) {
+	ctx := context.Background()
+	repo, counting, _ := newCachedRepository(t, cache_impl.NewLRUCache(0))
+
+	created, err := repo.Create(ctx, makeUser("Bob", "bob@example.com"))
+	require.NoError(t, err)
+
+	_, err = repo.ReadByEmailPublic(ctx, "bob@example.com")
+	require.NoError(t, err)
+	_, err = repo.ReadByEmailPublic(ctx, "BOB@example.com")
+	require.NoError(t, err)
+	_, err = repo.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, counting.byEmail.Load())
+	require.EqualValues(t, 0, counting.byID.Load(), "the email lookup filled the ID entry")
+
+	_, err = repo.UpdateFields(ctx, created.ID, 0, map[string]any{"email": "robert@example.com"})
+	require.NoError(t, err)
+
+	_, err = repo.ReadByEmailPublic(ctx, "bob@example.com")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+	got, err := repo.ReadByEmailPublic(ctx, "robert@example.com")
+	require.NoError(t, err)
+	require.Equal(t, created.ID, got.ID)
+}
+
+/*
+TestCache_RepositoryCollapsesConcurrentMisses verifies that concurrent
+misses for the same user share a single database query.
+*/
+func TestCache_RepositoryCollapsesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	repo, counting, _ := newCachedRepository(t, cache_impl.NewLRUCache(0))
+	counting.delay = 50 * time.Millisecond
+
+	created, err := repo.Create(ctx, makeUser("Carol", "carol@example.com"))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := repo.ReadByID(ctx, created.ID)
+			if err == nil {
+				got.Name = "mutated by one caller"
+			}
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 1, counting.byID.Load())
+
+	got, err := repo.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Carol", got.Name, "callers get their own copy")
+}
+
+/*
+TestCache_RepositoryInvalidatesAfterCommit verifies that reads inside a
+unit of work bypass the cache and that a write inside one invalidates again
+after commit, so a value cached mid-transaction does not survive it.
+*/
+func TestCache_RepositoryInvalidatesAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	c := cache_impl.NewLRUCache(0)
+	repo, counting, db := newCachedRepository(t, c)
+	unitOfWork := uow_impl.NewGormUnitOfWork(db)
+
+	created, err := repo.Create(ctx, makeUser("Dave", "dave@example.com"))
+	require.NoError(t, err)
+	_, err = repo.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+
+	err = unitOfWork.Do(ctx, func(ctx context.Context) error {
+		_, err := repo.ReadByID(ctx, created.ID)
+		require.NoError(t, err)
+		_, err = repo.UpdateFields(ctx, created.ID, 0, map[string]any{"name": "David"})
+		require.NoError(t, err)
+
+		/* Simulate another request caching the pre-commit row. */
+		require.NoError(t, c.Set(context.Background(), "user:id:"+created.ID, []byte(`{"ID":"`+created.ID+`","Name":"Dave"}`), time.Minute))
+		return nil
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, counting.byID.Load(), "the read in the transaction reached the database")
+
+	got, err := repo.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "David", got.Name)
+
+	rollback := errors.New("rollback")
+	err = unitOfWork.Do(ctx, func(ctx context.Context) error {
+		_, err := repo.UpdateFields(ctx, created.ID, 0, map[string]any{"name": "Nobody"})
+		require.NoError(t, err)
+		return rollback
+	})
+	require.ErrorIs(t, err, rollback)
+	got, err = repo.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "David", got.Name)
+}
+
+/*
+TestCache_RepositoryWorksOverRedis verifies the decorator end to end with
+the Redis cache, including invalidation visible to a second repository
+instance sharing the server.
+*/
+func TestCache_RepositoryWorksOverRedis(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	newCache := func() cache.Cache {
+		return cache_impl.NewRedisCache(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:")
+	}
+
+	db := setupTestDB(t)
+	first := repository_impl.NewCachedUserRepository(repository_impl.NewUserRepository(db), newCache(), time.Minute)
+	second := repository_impl.NewCachedUserRepository(repository_impl.NewUserRepository(db), newCache(), time.Minute)
+
+	created, err := first.Create(ctx, makeUser("Erin", "erin@example.com"))
+	require.NoError(t, err)
+	_, err = second.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.True(t, server.Exists("test:user:id:"+created.ID))
+
+	_, err = first.UpdateFields(ctx, created.ID, 0, map[string]any{"name": "Erina"})
+	require.NoError(t, err)
+	got, err := second.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Erina", got.Name)
+
+	plain := repository_impl.NewUserRepository(db)
+	require.Same(t, plain, repository_impl.NewCachedUserRepository(plain, nil, time.Minute), "a nil cache disables caching")
+}
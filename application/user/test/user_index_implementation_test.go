@@ -0,0 +1,198 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the embedded index tests from the project root:
+     go test -v -run 'Index|Fuzzy' ./application/user/test
+
+Notes:
+- Each test opens an index file inside t.TempDir(), so tests are isolated and
+  also cover persistence by reopening the same file.
+- The use case is wired with the in-process publisher so index updates are
+  driven by the same user events the application emits.
+===============================================================================
+*/
+
+func openTestIndex(t *testing.T) (*index_impl.UserIndexStruct, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "users.idx")
+	store, err := searchindex.Open(path, index_impl.FieldBoosts)
+	require.NoError(t, err)
+	return index_impl.NewUserIndex(store), path
+}
+
+/*
+TestUserIndex_FollowsUserEvents verifies that create, update and delete in
+the use case keep the index in sync and that the index survives a reopen.
+*/
+func TestUserIndex_FollowsUserEvents(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _ := newUsecase(t)
+	index, path := openTestIndex(t)
+	uc.Index = index
+	uc.Events = event_impl.NewInProcessPublisher(index)
+
+	created, err := uc.Create(ctx, makeEntityUser("Maria", "maria@ex.com", "pw", "ADMIN", true))
+	require.NoError(t, err)
+
+	res, err := uc.FuzzySearch(ctx, "maria", "", nil, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.Total)
+
+	_, err = uc.Update(ctx, &entity.UpdateUserPayload{ID: created.ID, Name: ptrString("Mariana")})
+	require.NoError(t, err)
+
+	res, err = uc.FuzzySearch(ctx, "mariana", "", nil, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.Total)
+	require.Equal(t, "Mariana", res.Results[0].User.Name)
+
+	require.NoError(t, index.Store.Flush())
+	reopened, err := searchindex.Open(path, index_impl.FieldBoosts)
+	require.NoError(t, err)
+	require.Equal(t, 1, reopened.Len(), "index should be persisted to disk")
+
+	require.NoError(t, uc.SoftDelete(ctx, created.ID))
+
+	res, err = uc.FuzzySearch(ctx, "mariana", "", nil, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, res.Total)
+}
+
+/*
+TestUserIndex_ToleratesTypos verifies substitutions, transpositions and
+missing characters, and that short words are not matched fuzzily.
+*/
+func TestUserIndex_ToleratesTypos(t *testing.T) {
+	index, _ := openTestIndex(t)
+
+	jonathan := makeUser("Jonathan Smith", "jon@example.com")
+	jonathan.ID = "jonathan"
+	maria := makeUser("Maria Lopez", "maria@example.com")
+	maria.ID = "maria"
+	require.NoError(t, index.Index(jonathan))
+	require.NoError(t, index.Index(maria))
+
+	for _, q := range []string{"jonathon", "jnoathan", "smth", "lopes", "mari"} {
+		res, err := index.Search(searchQuery(q))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, res.Total, "query %q should match one user", q)
+	}
+
+	res, err := index.Search(searchQuery("bob"))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, res.Total, "three letter words must match exactly")
+}
+
+/*
+TestUserIndex_RanksAndFacets verifies that exact matches outrank fuzzy ones,
+that facet counts cover every match, and that filters narrow the result.
+*/
+func TestUserIndex_RanksAndFacets(t *testing.T) {
+	index, _ := openTestIndex(t)
+
+	admin := makeUser("Anna", "anna@example.com")
+	admin.ID = "a"
+	user := makeUser("Hanna", "hanna@example.com")
+	user.ID = "b"
+	user.Role = "USER"
+	user.Active = false
+	require.NoError(t, index.Index(admin))
+	require.NoError(t, index.Index(user))
+
+	res, err := index.Search(searchQuery("anna"))
+	require.NoError(t, err)
+	require.EqualValues(t, 2, res.Total)
+	require.Equal(t, "Anna", res.Hits[0].User.Name, "exact match should rank first")
+	require.Equal(t, map[string]int{"ADMIN": 1, "USER": 1}, res.Facets["role"])
+	require.Equal(t, map[string]int{"true": 1, "false": 1}, res.Facets["active"])
+
+	q := searchQuery("anna")
+	q.Role = "user"
+	res, err = index.Search(q)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.Total)
+	require.Equal(t, "Hanna", res.Hits[0].User.Name)
+
+	q = searchQuery("")
+	q.Active = ptrBool(true)
+	res, err = index.Search(q)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.Total)
+	require.Equal(t, "Anna", res.Hits[0].User.Name)
+}
+
+/*
+TestUserIndex_RebuildFromRepository verifies that a rebuild reads every user
+page by page and replaces stale content.
+*/
+func TestUserIndex_RebuildFromRepository(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _ := newUsecase(t)
+	index, _ := openTestIndex(t)
+
+	stale := makeUser("Ghost", "ghost@example.com")
+	stale.ID = "ghost"
+	require.NoError(t, index.Index(stale))
+
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		_, err := uc.Create(ctx, makeEntityUser(name, name+"@ex.com", "pw", "USER", true))
+		require.NoError(t, err)
+	}
+
+	count, err := index_impl.RebuildFromRepository(ctx, uc.Repo, index)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	res, err := index.Search(searchQuery(""))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, res.Total)
+}
+
+/* searchQuery builds a first-page index query with a small page size. */
+/*
+TestUserIndex_PersistsOnFlush verifies that updates stay in memory until
+Flush or Close writes them, so a burst of updates costs a single write.
+*/
+func TestUserIndex_PersistsOnFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.idx")
+	store, err := searchindex.Open(path, index_impl.FieldBoosts)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(&searchindex.Document{ID: "u1", Fields: map[string]string{"name": "Maria"}}))
+	require.NoError(t, store.Put(&searchindex.Document{ID: "u2", Fields: map[string]string{"name": "Joao"}}))
+
+	reopened, err := searchindex.Open(path, index_impl.FieldBoosts)
+	require.NoError(t, err)
+	require.Equal(t, 0, reopened.Len(), "nothing should be written before a flush")
+
+	require.NoError(t, store.Flush())
+	require.NoError(t, store.Delete("u2"))
+	require.NoError(t, store.Close())
+
+	reopened, err = searchindex.Open(path, index_impl.FieldBoosts)
+	require.NoError(t, err)
+	require.Equal(t, 1, reopened.Len())
+}
+
+func searchQuery(text string) repository.UserIndexQuery {
+	return repository.UserIndexQuery{Text: text, Page: 1, Limit: 10}
+}
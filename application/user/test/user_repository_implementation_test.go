@@ -1,12 +1,21 @@
 package test
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
 	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
 	"github.com/celpung/gocleanarch/infrastructure/db/model" // Lightweight SQLite driver suitable for tests.
-	"github.com/stretchr/testify/require"                    // Assertion helpers for clearer tests.
+	"github.com/celpung/gocleanarch/infrastructure/db/sqlite"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	gsqlite "github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require" // Assertion helpers for clearer tests.
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 /*
@@ -77,12 +86,14 @@ func makeUser(name, email string) *model.User {
 TestCreateUser verifies that a user can be persisted and an identifier is set upon creation.
 */
 func TestCreateUser(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
 	u := makeUser("Alice", "alice@example.com")
 
-	saved, err := repo.Create(u)
+	saved, err := repo.Create(ctx, u)
 	require.NoError(t, err, "unexpected error during create")
 	require.NotEmpty(t, saved.ID, "expected generated ID to be non-empty")
 	require.Equal(t, "Alice", saved.Name)
@@ -94,15 +105,17 @@ TestReadAllUsers verifies that multiple persisted users can be retrieved.
 The repository does not enforce ordering; assertions should therefore avoid relying on a specific order.
 */
 func TestReadAllUsers(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	_, err := repo.Create(makeUser("Maria", "maria@example.com"))
+	_, err := repo.Create(ctx, makeUser("Maria", "maria@example.com"))
 	require.NoError(t, err)
-	_, err = repo.Create(makeUser("Bob", "bob@example.com"))
+	_, err = repo.Create(ctx, makeUser("Bob", "bob@example.com"))
 	require.NoError(t, err)
 
-	users, total, err := repo.Read(1, 10)
+	users, total, err := repo.Read(ctx, 1, 10)
 	require.NoError(t, err, "unexpected error during read")
 	require.Equal(t, int64(2), total, "total count harus 2")
 	require.Len(t, users, 2, "expected exactly two users")
@@ -121,13 +134,15 @@ TestReadByIDUser verifies that a record can be retrieved by its identifier.
 Repository implementation should use a predicate "id = ?" for string or UUID primary keys to avoid SQL parsing errors.
 */
 func TestReadByIDUser(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	saved, err := repo.Create(makeUser("Charlie", "charlie@example.com"))
+	saved, err := repo.Create(ctx, makeUser("Charlie", "charlie@example.com"))
 	require.NoError(t, err)
 
-	got, err := repo.ReadByID(saved.ID)
+	got, err := repo.ReadByID(ctx, saved.ID)
 	require.NoError(t, err, "unexpected error reading by ID")
 	require.Equal(t, saved.ID, got.ID)
 	require.Equal(t, "Charlie", got.Name)
@@ -138,13 +153,15 @@ TestReadByEmailUser verifies projected reads by email and expects sensitive
 fields to be omitted when the repository uses a public projection.
 */
 func TestReadByEmailUser(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	saved, err := repo.Create(makeUser("Richard", "richard@example.com"))
+	saved, err := repo.Create(ctx, makeUser("Richard", "richard@example.com"))
 	require.NoError(t, err)
 
-	got, err := repo.ReadByEmailPublic("richard@example.com")
+	got, err := repo.ReadByEmailPublic(ctx, "richard@example.com")
 	require.NoError(t, err, "unexpected error reading by email")
 	require.Equal(t, saved.Email, got.Email)
 
@@ -156,28 +173,32 @@ TestReadByEmailForLogin verifies that the authentication variant returns
 the fields necessary for credential verification, such as the password hash.
 */
 func TestReadByEmailForLogin(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	saved, err := repo.Create(makeUser("Diana", "diana@example.com"))
+	saved, err := repo.Create(ctx, makeUser("Diana", "diana@example.com"))
 	require.NoError(t, err)
 
-	got, err := repo.ReadByEmailPrivate("diana@example.com")
+	got, err := repo.ReadByEmailPrivate(ctx, "diana@example.com")
 	require.NoError(t, err, "unexpected error reading for login")
 	require.Equal(t, saved.Email, got.Email)
 	require.Equal(t, "password123", got.Password, "login read should include password field")
 }
 
 func TestSearchUsers(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	_, err := repo.Create(makeUser("Maria", "maria@example.com"))
+	_, err := repo.Create(ctx, makeUser("Maria", "maria@example.com"))
 	require.NoError(t, err)
-	_, err = repo.Create(makeUser("Bob", "bob@example.com"))
+	_, err = repo.Create(ctx, makeUser("Bob", "bob@example.com"))
 	require.NoError(t, err)
 
-	users, total, err := repo.Search(1, 10, "maria")
+	users, total, err := repo.Search(ctx, 1, 10, "maria")
 	require.NoError(t, err, "unexpected error during read")
 	require.Equal(t, int64(1), total, "total count harus 1")
 	require.Len(t, users, 1, "expected exactly two users")
@@ -189,27 +210,98 @@ func TestSearchUsers(t *testing.T) {
 	require.True(t, got["Maria"])
 }
 
+/*
+TestReadByCursor_WalksForwardAndBackward verifies keyset pagination over
+(created_at, id): pages do not overlap, the last page reports no more rows,
+and reading backwards from a cursor returns the previous page newest-first.
+*/
+func TestReadByCursor_WalksForwardAndBackward(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		u := makeUser(fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i))
+		u.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		_, err := repo.Create(ctx, u)
+		require.NoError(t, err)
+	}
+
+	first, hasMore, err := repo.ReadByCursor(ctx, nil, 2)
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Len(t, first, 2)
+	require.Equal(t, "User 4", first[0].Name)
+	require.Equal(t, "User 3", first[1].Name)
+
+	last := first[len(first)-1]
+	second, hasMore, err := repo.ReadByCursor(ctx, &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Direction: pagination.Next}, 2)
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Equal(t, "User 2", second[0].Name)
+	require.Equal(t, "User 1", second[1].Name)
+
+	last = second[len(second)-1]
+	third, hasMore, err := repo.ReadByCursor(ctx, &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Direction: pagination.Next}, 2)
+	require.NoError(t, err)
+	require.False(t, hasMore, "last page should not report more rows")
+	require.Len(t, third, 1)
+	require.Equal(t, "User 0", third[0].Name)
+
+	back, hasMore, err := repo.ReadByCursor(ctx, &pagination.Cursor{CreatedAt: third[0].CreatedAt, ID: third[0].ID, Direction: pagination.Prev}, 2)
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Equal(t, "User 2", back[0].Name, "backward page should be returned newest-first")
+	require.Equal(t, "User 1", back[1].Name)
+}
+
+/*
+TestSearchByCursor_FiltersByKeyword verifies that keyset pagination applies
+the same keyword filter as Search.
+*/
+func TestSearchByCursor_FiltersByKeyword(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+
+	_, err := repo.Create(ctx, makeUser("Maria", "maria@example.com"))
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, makeUser("Bob", "bob@example.com"))
+	require.NoError(t, err)
+
+	users, hasMore, err := repo.SearchByCursor(ctx, nil, 10, "maria")
+	require.NoError(t, err)
+	require.False(t, hasMore)
+	require.Len(t, users, 1)
+	require.Equal(t, "Maria", users[0].Name)
+}
+
 /*
 TestUpdateUser_StructUpdates verifies updates using struct-based Updates.
 Note that Updates(struct) does not write zero values; use UpdateFields(map) when zero values must be persisted.
 */
 func TestUpdateUser_StructUpdates(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	saved, err := repo.Create(makeUser("Eve", "eve@example.com"))
+	saved, err := repo.Create(ctx, makeUser("Eve", "eve@example.com"))
 	require.NoError(t, err)
 
 	saved.Name = "Eve Updated"
 	saved.Email = "eve2@example.com"
 
-	updated, err := repo.Update(saved)
+	updated, err := repo.Update(ctx, saved)
 	require.NoError(t, err, "unexpected error during update")
 	require.Equal(t, "Eve Updated", updated.Name)
 	require.Equal(t, "eve2@example.com", updated.Email)
 
 	// Confirm via a fresh read using the repository projection.
-	got, err := repo.ReadByID(saved.ID)
+	got, err := repo.ReadByID(ctx, saved.ID)
 	require.NoError(t, err)
 	require.Equal(t, "Eve Updated", got.Name)
 	require.Equal(t, "eve2@example.com", got.Email)
@@ -220,13 +312,15 @@ TestUpdateFields_AllowsZeroValues verifies that map-based Updates can persist
 zero values, which is necessary for accurate partial updates.
 */
 func TestUpdateFields_AllowsZeroValues(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	saved, err := repo.Create(makeUser("Frank", "frank@example.com"))
+	saved, err := repo.Create(ctx, makeUser("Frank", "frank@example.com"))
 	require.NoError(t, err)
 
-	updated, err := repo.UpdateFields(saved.ID, map[string]interface{}{
+	updated, err := repo.UpdateFields(ctx, saved.ID, 0, map[string]interface{}{
 		"active": false,
 		"role":   "",
 		"name":   "Frank Zeroed",
@@ -234,7 +328,7 @@ func TestUpdateFields_AllowsZeroValues(t *testing.T) {
 	require.NoError(t, err, "unexpected error during map-based update")
 	require.Equal(t, "Frank Zeroed", updated.Name)
 
-	got, err := repo.ReadByID(saved.ID)
+	got, err := repo.ReadByID(ctx, saved.ID)
 	require.NoError(t, err)
 	require.False(t, got.Active, "expected active to be false after update")
 	require.EqualValues(t, "", got.Role, "expected role to be zero after update")
@@ -246,27 +340,126 @@ TestSoftDeleteUser verifies that a soft-deleted record is no longer returned
 by default queries and does not appear in subsequent listings.
 */
 func TestSoftDeleteUser(t *testing.T) {
+	ctx := context.Background()
+
 	db := setupTestDB(t)
 	repo := repository_impl.NewUserRepository(db)
 
-	saved, err := repo.Create(makeUser("Gina", "gina@example.com"))
+	saved, err := repo.Create(ctx, makeUser("Gina", "gina@example.com"))
 	require.NoError(t, err)
 
 	// Soft delete
-	err = repo.SoftDelete(saved.ID)
+	err = repo.SoftDelete(ctx, saved.ID)
 	require.NoError(t, err, "unexpected error during soft delete")
 
-	// ReadByID harus ErrRecordNotFound (gunakan ErrorIs karena GORM bisa wrap error)
-	_, err = repo.ReadByID(saved.ID)
+	// ReadByID harus repository.ErrNotFound (gunakan ErrorIs karena GORM bisa wrap error)
+	_, err = repo.ReadByID(ctx, saved.ID)
 	require.Error(t, err, "expected read by ID to fail after soft delete")
-	require.ErrorIs(t, err, gorm.ErrRecordNotFound, "expected ErrRecordNotFound after soft delete")
+	require.ErrorIs(t, err, repository.ErrNotFound, "expected ErrNotFound after soft delete")
 
 	// Listing harus tidak menyertakan row yang terhapus
-	users, total, err := repo.Read(1, 10)
+	users, total, err := repo.Read(ctx, 1, 10)
 	require.NoError(t, err, "unexpected error reading all users after soft delete")
 	for _, us := range users {
 		require.NotEqual(t, saved.ID, us.ID, "soft-deleted user must not be listed")
 	}
 	// Karena hanya 1 user awalnya, total seharusnya 0.
 	require.Equal(t, int64(0), total, "total should exclude soft-deleted rows")
+
+	// Menghapus lagi, atau ID yang tidak ada, harus repository.ErrNotFound
+	require.ErrorIs(t, repo.SoftDelete(ctx, saved.ID), repository.ErrNotFound)
+	require.ErrorIs(t, repo.SoftDelete(ctx, "missing"), repository.ErrNotFound)
+}
+
+/*
+TestRepository_HonorsCancelledContext verifies that the repository runs its
+queries with the caller's context, so a cancelled request never reaches the
+database.
+*/
+func TestRepository_HonorsCancelledContext(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.Create(ctx, makeUser("Ivan", "ivan@example.com"))
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, _, err = repo.Read(ctx, 1, 10)
+	require.ErrorIs(t, err, context.Canceled)
+
+	users, total, err := repo.Read(context.Background(), 1, 10)
+	require.NoError(t, err)
+	require.Empty(t, users, "cancelled create must not persist a row")
+	require.Zero(t, total)
+}
+
+/*
+TestUpdateFields_ChecksAndBumpsVersion verifies that every update increments
+the version and that a conditional update with a stale version is rejected
+without touching the row.
+*/
+func TestUpdateFields_ChecksAndBumpsVersion(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+
+	saved, err := repo.Create(ctx, makeUser("Kate", "kate@example.com"))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, saved.Version, "new users start at version 1")
+
+	updated, err := repo.UpdateFields(ctx, saved.ID, 1, map[string]any{"name": "Katherine"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, updated.Version)
+
+	_, err = repo.UpdateFields(ctx, saved.ID, 1, map[string]any{"name": "Stale"})
+	require.ErrorIs(t, err, repository.ErrVersionMismatch)
+
+	got, err := repo.ReadByID(ctx, saved.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Katherine", got.Name, "stale update must not be applied")
+	require.EqualValues(t, 2, got.Version)
+
+	_, err = repo.UpdateFields(ctx, "missing", 1, map[string]any{"name": "Nobody"})
+	require.ErrorIs(t, err, repository.ErrNotFound, "unknown users are not reported as conflicts")
+}
+
+/*
+TestRepository_ReadsFromReplicaButLooksUpOnPrimary verifies the read/write
+split: listings are served by the replica, while lookups by ID, which feed
+updates and logins, always go to the primary.
+*/
+func TestRepository_ReadsFromReplicaButLooksUpOnPrimary(t *testing.T) {
+	ctx := context.Background()
+
+	replicaPath := filepath.Join(t.TempDir(), "replica.db")
+	replica, err := sqlite.SetupDB(replicaPath)
+	require.NoError(t, err)
+	require.NoError(t, replica.Create(makeUser("Replica Only", "replica@example.com")).Error)
+	replicaSQL, err := replica.DB()
+	require.NoError(t, err)
+	require.NoError(t, replicaSQL.Close())
+
+	primary := setupTestDB(t)
+	require.NoError(t, primary.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{gsqlite.Open(replicaPath)},
+	})))
+
+	repo := repository_impl.NewUserRepository(primary)
+	created, err := repo.Create(ctx, makeUser("Primary Only", "primary@example.com"))
+	require.NoError(t, err)
+
+	users, total, err := repo.Read(ctx, 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Equal(t, "replica@example.com", users[0].Email)
+
+	found, err := repo.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "primary@example.com", found.Email)
+
+	_, err = repo.ReadByEmailPrivate(ctx, "replica@example.com")
+	require.ErrorIs(t, err, repository.ErrNotFound)
 }
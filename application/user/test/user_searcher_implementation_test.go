@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the search tests from the project root:
+     go test -v -run 'Search' ./application/user/test
+
+Notes:
+- setupTestDB creates the SQLite FTS5 table and its sync triggers, so these
+  tests exercise SQLiteFTS5Searcher. The MySQL FULLTEXT implementation needs a
+  live server; only its LIKE fallback for unindexed terms is covered here.
+- LikeSearcher is constructed directly to cover the portable fallback.
+===============================================================================
+*/
+
+/*
+TestFTS5Search_RanksPrefixMatchesAndHighlights verifies prefix matching,
+ranking by relevance, and that highlights mark the matched words.
+*/
+func TestFTS5Search_RanksPrefixMatchesAndHighlights(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+	searcher := search_impl.NewUserSearcher(db)
+	require.IsType(t, &search_impl.SQLiteFTS5Searcher{}, searcher)
+
+	_, err := repo.Create(ctx, makeUser("Maria Lopez", "maria@example.com"))
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, makeUser("Bob Martin", "bob@example.com"))
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, makeUser("Charlie", "charlie@example.com"))
+	require.NoError(t, err)
+
+	hits, total, err := searcher.Search(ctx, "mar", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total)
+	require.Len(t, hits, 2)
+
+	got := map[string]string{}
+	for _, h := range hits {
+		got[h.User.Name] = h.Highlights["name"]
+	}
+	require.Equal(t, "<mark>Maria</mark> Lopez", got["Maria Lopez"])
+	require.Equal(t, "Bob <mark>Martin</mark>", got["Bob Martin"])
+
+	/* Maria matches in both name and email and should outrank Bob. */
+	require.Equal(t, "Maria Lopez", hits[0].User.Name)
+	require.Greater(t, hits[0].Score, hits[1].Score)
+}
+
+/*
+TestFTS5Search_FollowsUpdatesAndSoftDeletes verifies that the triggers keep
+the index in sync and that soft-deleted users are not returned.
+*/
+func TestFTS5Search_FollowsUpdatesAndSoftDeletes(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+	searcher := search_impl.NewUserSearcher(db)
+
+	saved, err := repo.Create(ctx, makeUser("Eve", "eve@example.com"))
+	require.NoError(t, err)
+
+	_, err = repo.UpdateFields(ctx, saved.ID, 0, map[string]any{"name": "Evelyn"})
+	require.NoError(t, err)
+
+	hits, _, err := searcher.Search(ctx, "evelyn", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+
+	require.NoError(t, repo.SoftDelete(ctx, saved.ID))
+
+	hits, total, err := searcher.Search(ctx, "evelyn", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, total)
+	require.Empty(t, hits)
+}
+
+/*
+TestFTS5Search_SurvivesVacuum verifies that the index still points at the
+right users after VACUUM rewrites the table following a hard delete.
+*/
+func TestFTS5Search_SurvivesVacuum(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+	searcher := search_impl.NewUserSearcher(db)
+
+	gone, err := repo.Create(ctx, makeUser("Alice", "alice@example.com"))
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, makeUser("Bob", "bob@example.com"))
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, makeUser("Carol", "carol@example.com"))
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec("DELETE FROM users WHERE id = ?", gone.ID).Error)
+	require.NoError(t, db.Exec("VACUUM").Error)
+
+	for _, name := range []string{"Bob", "Carol"} {
+		hits, _, err := searcher.Search(ctx, name, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, hits, 1, "%s should still be found", name)
+		require.Equal(t, name, hits[0].User.Name)
+	}
+}
+
+/*
+TestFTS5Search_IgnoresQuerySyntax verifies that characters with a meaning in
+FTS5 query syntax are treated as separators instead of breaking the query.
+*/
+func TestFTS5Search_IgnoresQuerySyntax(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+	searcher := search_impl.NewUserSearcher(db)
+
+	_, err := repo.Create(ctx, makeUser("Maria", "maria@example.com"))
+	require.NoError(t, err)
+
+	for _, q := range []string{`"maria`, `maria*`, `(maria)`, `-maria`, `+maria`, `maria'`} {
+		hits, _, err := searcher.Search(ctx, q, 1, 10)
+		require.NoError(t, err, "query %q should not fail", q)
+		require.Len(t, hits, 1, "query %q should still match", q)
+	}
+}
+
+/*
+TestLikeSearch_EscapesWildcards verifies that % and _ in a keyword match
+literally in the portable fallback and in the repository Search.
+*/
+func TestLikeSearch_EscapesWildcards(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+
+	_, err := repo.Create(ctx, makeUser("Jo_hn", "john@example.com"))
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, makeUser("Joahn", "joahn@example.com"))
+	require.NoError(t, err)
+
+	users, total, err := repo.Search(ctx, 1, 10, "jo_h")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Equal(t, "Jo_hn", users[0].Name)
+
+	users, _, err = repo.Search(ctx, 1, 10, "%")
+	require.NoError(t, err)
+	require.Empty(t, users, "a bare % must not match every row")
+
+	searcher := &search_impl.LikeSearcher{DB: db}
+	hits, total, err := searcher.Search(ctx, "joa", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Equal(t, "<mark>Joahn</mark>", hits[0].Highlights["name"])
+}
+
+/*
+TestMySQLSearch_FallsBackForUnindexedTerms verifies that keywords InnoDB does
+not index, short words and stopwords, are answered by the LIKE scan instead
+of a boolean-mode query that would match nothing. The fallback is portable
+SQL, so it runs against SQLite here.
+*/
+func TestMySQLSearch_FallsBackForUnindexedTerms(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	repo := repository_impl.NewUserRepository(db)
+
+	_, err := repo.Create(ctx, makeUser("Al Who", "al@example.com"))
+	require.NoError(t, err)
+
+	searcher := &search_impl.MySQLFulltextSearcher{DB: db, MinTokenSize: 3}
+	for _, q := range []string{"al", "who", "al example"} {
+		hits, total, err := searcher.Search(ctx, q, 1, 10)
+		require.NoError(t, err, "query %q should not reach MATCH", q)
+		require.EqualValues(t, 1, total, "query %q", q)
+		require.Equal(t, "Al Who", hits[0].User.Name)
+	}
+}
+
+/*
+TestSearchHighlights_EscapeHTML verifies that stored values are HTML-escaped
+in highlights so they can be rendered safely.
+*/
+func TestSearchHighlights_EscapeHTML(t *testing.T) {
+	ctx := context.Background()
+
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&model.User{Name: "<b>Mallory</b>", Email: "mallory@example.com", Password: "x", Role: "USER"}).Error)
+
+	hits, _, err := search_impl.NewUserSearcher(db).Search(ctx, "mallory", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, "&lt;b&gt;<mark>Mallory</mark>&lt;/b&gt;", hits[0].Highlights["name"])
+}
@@ -1,13 +1,22 @@
 package test
 
 import (
-	"strings"
+	"context"
+	"fmt"
 	"testing"
 
+	"github.com/celpung/gocleanarch/application/apperror"
 	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+	"github.com/celpung/gocleanarch/application/user/domain/repository"
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
 	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
 	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
+	"github.com/celpung/gocleanarch/delivery/etag"
 	"github.com/celpung/gocleanarch/infrastructure/auth"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
@@ -79,6 +88,7 @@ func newUsecase(t *testing.T) (*usecase_impl.UserUsecaseStruct, *gorm.DB) {
 
 	uc := &usecase_impl.UserUsecaseStruct{
 		Repo:            repo,
+		Searcher:        search_impl.NewUserSearcher(db),
 		PasswordService: ps,
 		JWTService:      js,
 	}
@@ -109,15 +119,17 @@ TestUsecase_Create_ShouldHashPasswordAndPersist verifies that Create
 hashes the incoming password and persists the user record.
 */
 func TestUsecase_Create_ShouldHashPasswordAndPersist(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
 	in := makeEntityUser("Alice", "alice@ex.com", "secret123", "SUPER", true)
-	out, err := uc.Create(in)
+	out, err := uc.Create(ctx, in)
 	require.NoError(t, err, "create should not error")
 	require.NotEmpty(t, out.ID, "expected ID to be set after create")
 
 	/* Verify the stored password is a hash and validates against the original plaintext, using the repository path that exposes the password column. */
-	stored, err := uc.Repo.ReadByEmailPrivate("alice@ex.com")
+	stored, err := uc.Repo.ReadByEmailPrivate(ctx, "alice@ex.com")
 	require.NoError(t, err)
 	require.NotEqual(t, "secret123", stored.Password, "stored password should not equal plaintext")
 
@@ -130,14 +142,16 @@ TestUsecase_Read_ReturnsEntitySlice verifies that Read returns entities
 mapped from the repository projection and does not include sensitive fields.
 */
 func TestUsecase_Read_ReturnsEntitySlice(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	_, err := uc.Create(makeEntityUser("Maria", "maria@ex.com", "pw", "SUPER", true))
+	_, err := uc.Create(ctx, makeEntityUser("Maria", "maria@ex.com", "pw", "SUPER", true))
 	require.NoError(t, err)
-	_, err = uc.Create(makeEntityUser("Bob", "bob@ex.com", "pw", "SUPER", true))
+	_, err = uc.Create(ctx, makeEntityUser("Bob", "bob@ex.com", "pw", "SUPER", true))
 	require.NoError(t, err)
 
-	list, total, err := uc.Read(1, 0)
+	list, total, err := uc.Read(ctx, 1, 0)
 	require.NoError(t, err)
 	require.EqualValues(t, 2, total)
 	require.Len(t, list, 2)
@@ -148,31 +162,71 @@ func TestUsecase_Read_ReturnsEntitySlice(t *testing.T) {
 	}
 }
 
+/*
+TestUsecase_ReadByCursor_IssuesSignedCursors verifies that the use case hands
+out opaque cursors that lead to the next page and back, and rejects cursors
+that were tampered with.
+*/
+func TestUsecase_ReadByCursor_IssuesSignedCursors(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _ := newUsecase(t)
+
+	for i := 0; i < 3; i++ {
+		_, err := uc.Create(ctx, makeEntityUser(fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@ex.com", i), "pw", "USER", true))
+		require.NoError(t, err)
+	}
+
+	first, page, err := uc.ReadByCursor(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	require.NotEmpty(t, page.Next)
+	require.Empty(t, page.Prev, "first page has nothing before it")
+
+	second, page, err := uc.ReadByCursor(ctx, page.Next, 2)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	require.Empty(t, page.Next)
+	require.NotEmpty(t, page.Prev)
+
+	back, _, err := uc.ReadByCursor(ctx, page.Prev, 2)
+	require.NoError(t, err)
+	require.Equal(t, first[0].ID, back[0].ID)
+	require.Equal(t, first[1].ID, back[1].ID)
+
+	_, _, err = uc.ReadByCursor(ctx, page.Prev+"x", 2)
+	require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
 /*
 TestUsecase_ReadByID_ReturnsSingleEntity verifies that ReadByID maps the
 repository model to the entity type correctly.
 */
 func TestUsecase_ReadByID_ReturnsSingleEntity(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	created, err := uc.Create(makeEntityUser("Charlie", "charlie@ex.com", "pw", "ADMIN", true))
+	created, err := uc.Create(ctx, makeEntityUser("Charlie", "charlie@ex.com", "pw", "ADMIN", true))
 	require.NoError(t, err)
 
-	got, err := uc.ReadByID(created.ID)
+	got, err := uc.ReadByID(ctx, created.ID)
 	require.NoError(t, err)
 	require.Equal(t, created.ID, got.ID)
 	require.Equal(t, "Charlie", got.Name)
 }
 
 func TestUsecase_Search_ReturnsEntitySlice(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	_, err := uc.Create(makeEntityUser("Maria", "maria@ex.com", "pw", "SUPER", true))
+	_, err := uc.Create(ctx, makeEntityUser("Maria", "maria@ex.com", "pw", "SUPER", true))
 	require.NoError(t, err)
-	_, err = uc.Create(makeEntityUser("Bob", "bob@ex.com", "pw", "SUPER", true))
+	_, err = uc.Create(ctx, makeEntityUser("Bob", "bob@ex.com", "pw", "SUPER", true))
 	require.NoError(t, err)
 
-	list, total, err := uc.Search(1, 10, "maria")
+	list, total, err := uc.Search(ctx, 1, 10, "maria")
 	require.NoError(t, err)
 	require.EqualValues(t, 1, total)
 	require.Len(t, list, 1)
@@ -184,18 +238,42 @@ func TestUsecase_Search_ReturnsEntitySlice(t *testing.T) {
 	require.True(t, got["Maria"])
 }
 
+/*
+TestUsecase_SearchRanked_ReturnsHighlights verifies that ranked search maps
+hits to entities and carries scores and highlights through.
+*/
+func TestUsecase_SearchRanked_ReturnsHighlights(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _ := newUsecase(t)
+
+	_, err := uc.Create(ctx, makeEntityUser("Maria", "maria@ex.com", "pw", "SUPER", true))
+	require.NoError(t, err)
+	_, err = uc.Create(ctx, makeEntityUser("Bob", "bob@ex.com", "pw", "SUPER", true))
+	require.NoError(t, err)
+
+	list, total, err := uc.SearchRanked(ctx, "mar", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Len(t, list, 1)
+	require.Equal(t, "Maria", list[0].User.Name)
+	require.Equal(t, "<mark>Maria</mark>", list[0].Highlights["name"])
+}
+
 /*
 TestUsecase_Update_NoChanges_ReturnsCurrentWithPasswordBlank exercises the
 code path where no fields are provided for update. The use case should return
 the current user state with the password sanitized.
 */
 func TestUsecase_Update_NoChanges_ReturnsCurrentWithPasswordBlank(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	created, err := uc.Create(makeEntityUser("Diana", "diana@ex.com", "pw", "USER", true))
+	created, err := uc.Create(ctx, makeEntityUser("Diana", "diana@ex.com", "pw", "USER", true))
 	require.NoError(t, err)
 
-	out, err := uc.Update(&entity.UpdateUserPayload{ID: created.ID})
+	out, err := uc.Update(ctx, &entity.UpdateUserPayload{ID: created.ID})
 	require.NoError(t, err)
 	require.Equal(t, created.Name, out.Name)
 	require.Equal(t, "", out.Password, "password should be blanked in the response")
@@ -206,9 +284,11 @@ TestUsecase_Update_WriteZeroValues verifies that the map-based update path
 writes zero values when requested through pointer fields in the payload.
 */
 func TestUsecase_Update_WriteZeroValues(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	created, err := uc.Create(makeEntityUser("Eve", "eve@ex.com", "pw", "USER", true))
+	created, err := uc.Create(ctx, makeEntityUser("Eve", "eve@ex.com", "pw", "USER", true))
 	require.NoError(t, err)
 
 	payload := &entity.UpdateUserPayload{
@@ -217,12 +297,12 @@ func TestUsecase_Update_WriteZeroValues(t *testing.T) {
 		Active: ptrBool(false), // request setting to false
 		Role:   ptrString("USER"),
 	}
-	out, err := uc.Update(payload)
+	out, err := uc.Update(ctx, payload)
 	require.NoError(t, err)
 	require.Equal(t, "Eve Zero", out.Name)
 
 	/* Confirm persisted values through repository read that uses projection. */
-	got, err := uc.Repo.ReadByID(created.ID)
+	got, err := uc.Repo.ReadByID(ctx, created.ID)
 	require.NoError(t, err)
 	require.Equal(t, "Eve Zero", got.Name)
 	require.False(t, got.Active)
@@ -233,16 +313,18 @@ func TestUsecase_Update_WriteZeroValues(t *testing.T) {
 TestUsecase_SoftDelete verifies that SoftDelete masks the record from subsequent default queries.
 */
 func TestUsecase_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	created, err := uc.Create(makeEntityUser("Frank", "frank@ex.com", "pw", "SUPER", true))
+	created, err := uc.Create(ctx, makeEntityUser("Frank", "frank@ex.com", "pw", "SUPER", true))
 	require.NoError(t, err)
 
-	err = uc.SoftDelete(created.ID)
+	err = uc.SoftDelete(ctx, created.ID)
 	require.NoError(t, err)
 
-	_, err = uc.Repo.ReadByID(created.ID)
-	require.Equal(t, gorm.ErrRecordNotFound, err, "expected soft-deleted record to be hidden")
+	_, err = uc.Repo.ReadByID(ctx, created.ID)
+	require.Equal(t, repository.ErrNotFound, err, "expected soft-deleted record to be hidden")
 }
 
 /*
@@ -250,33 +332,163 @@ TestUsecase_Login_WrongPassword validates that providing an incorrect password
 fails prior to any token generation and returns a descriptive error.
 */
 func TestUsecase_Login_WrongPassword(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	_, err := uc.Create(makeEntityUser("Greg", "greg@ex.com", "right-pass", "SUPER", true))
+	_, err := uc.Create(ctx, makeEntityUser("Greg", "greg@ex.com", "right-pass", "SUPER", true))
 	require.NoError(t, err)
 
-	token, err := uc.Login("greg@ex.com", "wrong-pass")
+	token, err := uc.Login(ctx, "greg@ex.com", "wrong-pass")
 	require.Error(t, err)
 	require.Empty(t, token)
-	require.True(t, strings.Contains(err.Error(), "wrong password"))
+	require.ErrorIs(t, err, entity.ErrInvalidCredentials)
 }
 
 /*
-TestUsecase_Login_InactiveUser validates that login attempts are rejected for
-inactive accounts prior to password verification or token generation.
+TestUsecase_Login_InactiveUser validates that login attempts with the right
+password are rejected for inactive accounts before a token is generated.
 */
 func TestUsecase_Login_InactiveUser(t *testing.T) {
+	ctx := context.Background()
+
 	uc, _ := newUsecase(t)
 
-	created, err := uc.Create(makeEntityUser("Hanna", "hanna@ex.com", "pw", "SUPER", true))
+	created, err := uc.Create(ctx, makeEntityUser("Hanna", "hanna@ex.com", "pw", "SUPER", true))
 	require.NoError(t, err)
 
 	/* Mark the user inactive using the repository partial update. */
-	_, err = uc.Repo.UpdateFields(created.ID, map[string]interface{}{"active": false})
+	_, err = uc.Repo.UpdateFields(ctx, created.ID, 0, map[string]interface{}{"active": false})
 	require.NoError(t, err)
 
-	token, err := uc.Login("hanna@ex.com", "pw")
+	token, err := uc.Login(ctx, "hanna@ex.com", "pw")
 	require.Error(t, err)
 	require.Empty(t, token)
-	require.True(t, strings.Contains(err.Error(), "user not active"))
+	require.ErrorIs(t, err, entity.ErrUserInactive)
+}
+
+/*
+TestUsecase_Errors_CarryDomainKinds verifies that repository failures reach
+callers as domain errors with a kind the delivery can map to a status, while
+the underlying gorm error stays reachable.
+*/
+func TestUsecase_Errors_CarryDomainKinds(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _ := newUsecase(t)
+
+	_, err := uc.Create(ctx, makeEntityUser("Ivy", "ivy@ex.com", "pw", "USER", true))
+	require.NoError(t, err)
+
+	_, err = uc.Create(ctx, makeEntityUser("Ivy Again", "ivy@ex.com", "pw", "USER", true))
+	require.ErrorIs(t, err, entity.ErrEmailTaken)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	_, err = uc.ReadByID(ctx, "missing")
+	require.ErrorIs(t, err, entity.ErrUserNotFound)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+	require.Equal(t, "user not found", err.Error())
+}
+
+/* recordingHandler captures published user events for assertions. */
+type recordingHandler struct {
+	events []event.UserEvent
+}
+
+func (h *recordingHandler) HandleUserEvent(e event.UserEvent) error {
+	h.events = append(h.events, e)
+	return nil
+}
+
+/*
+TestUsecase_Events_CarryPrincipalAndRequestID verifies that the principal and
+request ID placed in the context by the delivery layer reach the events the
+use case publishes.
+*/
+func TestUsecase_Events_CarryPrincipalAndRequestID(t *testing.T) {
+	uc, _ := newUsecase(t)
+	rec := &recordingHandler{}
+	uc.Events = event_impl.NewInProcessPublisher(rec)
+
+	ctx := requestctx.WithPrincipal(context.Background(), requestctx.Principal{UserID: "admin-1", Role: "ADMIN"})
+	ctx = requestctx.WithRequestID(ctx, "req-42")
+
+	created, err := uc.Create(ctx, makeEntityUser("Ivy", "ivy@ex.com", "pw", "USER", true))
+	require.NoError(t, err)
+	require.NoError(t, uc.SoftDelete(context.Background(), created.ID))
+
+	require.Len(t, rec.events, 2)
+	require.Equal(t, event.UserRegistered, rec.events[0].Name)
+	require.Equal(t, "admin-1", rec.events[0].ActorID)
+	require.Equal(t, "req-42", rec.events[0].RequestID)
+
+	require.Equal(t, event.UserDeleted, rec.events[1].Name)
+	require.Empty(t, rec.events[1].ActorID, "anonymous context has no actor")
+	require.Empty(t, rec.events[1].RequestID)
+}
+
+/*
+TestUsecase_Update_RejectsStaleVersion simulates two admins editing the same
+user: the second update, based on the version both of them read, fails with
+a VersionConflictError that reports the current version.
+*/
+func TestUsecase_Update_RejectsStaleVersion(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _ := newUsecase(t)
+
+	created, err := uc.Create(ctx, makeEntityUser("Liam", "liam@ex.com", "pw", "USER", true))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, created.Version)
+
+	first, err := uc.Update(ctx, &entity.UpdateUserPayload{ID: created.ID, Name: ptrString("Liam A"), Version: created.Version})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, first.Version)
+
+	_, err = uc.Update(ctx, &entity.UpdateUserPayload{ID: created.ID, Name: ptrString("Liam B"), Version: created.Version})
+	require.ErrorIs(t, err, entity.ErrVersionConflict)
+
+	var conflict *entity.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	require.EqualValues(t, 1, conflict.Expected)
+	require.EqualValues(t, 2, conflict.Current)
+
+	got, err := uc.ReadByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Liam A", got.Name)
+}
+
+/*
+TestETag_RoundTripsVersions verifies the entity tags used for If-Match and
+how a body version is used when the header is absent.
+*/
+func TestETag_RoundTripsVersions(t *testing.T) {
+	require.Equal(t, `"7"`, etag.Format(7))
+
+	for _, h := range []string{`"7"`, `W/"7"`, ` "7" `} {
+		v, err := etag.Parse(h)
+		require.NoError(t, err, h)
+		require.EqualValues(t, 7, v, h)
+	}
+
+	for _, h := range []string{`7`, `"x"`, `"0"`, `"1", "2"`} {
+		_, err := etag.Parse(h)
+		require.ErrorIs(t, err, etag.ErrInvalid, h)
+	}
+
+	v, err := etag.Resolve("*", nil)
+	require.NoError(t, err)
+	require.Zero(t, v, "a wildcard matches any version")
+
+	v, err = etag.Resolve("", ptrUint(3))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, v)
+
+	v, err = etag.Resolve(`"4"`, ptrUint(3))
+	require.NoError(t, err)
+	require.EqualValues(t, 4, v, "the header wins over the body")
+
+	_, err = etag.Resolve("", nil)
+	require.ErrorIs(t, err, etag.ErrMissing)
 }
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Endpoint is a URL that receives signed notifications for the events it
+// subscribes to. Events holds event names ("user.registered"), prefixes
+// ending in ".*" ("user.*") or "*" for everything. Secret is only filled in
+// when the endpoint is created or its secret is rotated.
+type Endpoint struct {
+	ID             string
+	OwnerID        string
+	URL            string
+	Description    string
+	Secret         string
+	Events         []string
+	Active         bool
+	FailureCount   int
+	DisabledAt     *time.Time
+	DisabledReason string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Subscribes reports whether the endpoint wants events of type eventType.
+func (e *Endpoint) Subscribes(eventType string) bool {
+	for _, filter := range e.Events {
+		if MatchEvent(filter, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchEvent reports whether an event filter selects eventType.
+func MatchEvent(filter, eventType string) bool {
+	switch {
+	case filter == "*":
+		return true
+	case strings.HasSuffix(filter, ".*"):
+		return strings.HasPrefix(eventType, strings.TrimSuffix(filter, "*"))
+	default:
+		return filter == eventType
+	}
+}
+
+// UpdateEndpointPayload changes the fields that are not nil. Setting Active
+// to true re-enables an endpoint that was disabled after failures.
+type UpdateEndpointPayload struct {
+	ID          string
+	URL         *string
+	Description *string
+	Events      []string
+	Active      *bool
+}
+
+type DeliveryStatus string
+
+// A delivery is pending until it succeeds or runs out of attempts.
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent to one endpoint, with the outcome of its
+// latest attempt. NextAttemptAt is nil once no more attempts are planned.
+type Delivery struct {
+	ID             string
+	EndpointID     string
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastStatusCode int
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Attempt is the log entry of a single request. StatusCode is zero when no
+// response was received. Manual marks redeliveries requested by a user.
+type Attempt struct {
+	ID           string
+	DeliveryID   string
+	Attempt      int
+	Manual       bool
+	StatusCode   int
+	ResponseBody string
+	Error        string
+	Duration     time.Duration
+	CreatedAt    time.Time
+}
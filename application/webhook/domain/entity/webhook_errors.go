@@ -0,0 +1,13 @@
+package entity
+
+import "github.com/celpung/gocleanarch/application/apperror"
+
+var (
+	// ErrEndpointNotFound is also returned for endpoints owned by someone
+	// else, so their existence is not revealed.
+	ErrEndpointNotFound = apperror.New(apperror.ErrNotFound, "webhook endpoint not found")
+	ErrDeliveryNotFound = apperror.New(apperror.ErrNotFound, "webhook delivery not found")
+	ErrUnknownEvent     = apperror.New(apperror.ErrInvalid, "unknown webhook event")
+	ErrNoEvents         = apperror.New(apperror.ErrInvalid, "webhook endpoint must subscribe to at least one event")
+	ErrUnauthenticated  = apperror.New(apperror.ErrUnauthenticated, "webhooks require an authenticated user")
+)
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) (*model.WebhookEndpoint, error)
+	// ReadEndpoints lists the endpoints of ownerID, or all endpoints when
+	// ownerID is empty.
+	ReadEndpoints(ctx context.Context, ownerID string) ([]*model.WebhookEndpoint, error)
+	ReadActiveEndpoints(ctx context.Context) ([]*model.WebhookEndpoint, error)
+	ReadEndpointByID(ctx context.Context, id string) (*model.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, id string, fields map[string]any) (*model.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+	// IncrementFailures adds one to the endpoint's consecutive failure count
+	// and returns the new count.
+	IncrementFailures(ctx context.Context, id string) (int, error)
+
+	// CreateDeliveries skips deliveries whose endpoint already has one for
+	// the same event, which makes dispatching an event idempotent.
+	CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	// ClaimDueDeliveries leases pending deliveries of active endpoints that
+	// are due at now, so concurrent workers do not send them twice.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*model.WebhookDelivery, error)
+	ReadDeliveries(ctx context.Context, endpointID, status string, page, limit uint) ([]*model.WebhookDelivery, int64, error)
+	ReadDeliveryByID(ctx context.Context, id string) (*model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, id string, fields map[string]any) error
+
+	CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error
+	ReadAttempts(ctx context.Context, deliveryID string) ([]*model.WebhookAttempt, error)
+}
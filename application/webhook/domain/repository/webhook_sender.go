@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// WebhookRequest is one signed POST of an event payload to an endpoint.
+type WebhookRequest struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	EventType  string
+	Attempt    int
+	Payload    []byte
+}
+
+// WebhookResponse is the outcome of a request. StatusCode is zero when no
+// response arrived; Err is set for transport errors and non-2xx responses.
+// Body holds the start of the response body.
+type WebhookResponse struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+	Err        error
+}
+
+// WebhookSender signs and sends webhook requests.
+type WebhookSender interface {
+	Send(ctx context.Context, req WebhookRequest) WebhookResponse
+}
@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/celpung/gocleanarch/application/webhook/domain/entity"
+)
+
+// WebhookUsecase manages webhook endpoints and delivers events to them.
+// Endpoint and delivery methods act for the principal in ctx: admins see
+// their own endpoints, super users see all of them.
+type WebhookUsecase interface {
+	CreateEndpoint(ctx context.Context, endpoint *entity.Endpoint) (*entity.Endpoint, error)
+	ListEndpoints(ctx context.Context) ([]*entity.Endpoint, error)
+	GetEndpoint(ctx context.Context, id string) (*entity.Endpoint, error)
+	UpdateEndpoint(ctx context.Context, payload *entity.UpdateEndpointPayload) (*entity.Endpoint, error)
+	RotateSecret(ctx context.Context, id string) (*entity.Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	ListDeliveries(ctx context.Context, endpointID, status string, page, limit uint) ([]*entity.Delivery, int64, error)
+	GetDelivery(ctx context.Context, endpointID, deliveryID string) (*entity.Delivery, []*entity.Attempt, error)
+	// Redeliver sends a delivery again right away, whatever its status, and
+	// returns the logged attempt.
+	Redeliver(ctx context.Context, endpointID, deliveryID string) (*entity.Attempt, error)
+
+	// Dispatch queues an event for every active endpoint subscribed to it
+	// and returns how many deliveries were queued. Dispatching the same
+	// event ID twice queues nothing new.
+	Dispatch(ctx context.Context, eventID, eventType string, payload []byte) (int, error)
+	// DeliverDue sends one batch of due deliveries and returns its size.
+	DeliverDue(ctx context.Context) (int, error)
+}
@@ -0,0 +1,89 @@
+package dispatcher_impl
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+	repository_impl "github.com/celpung/gocleanarch/application/webhook/impl/repository"
+	sender_impl "github.com/celpung/gocleanarch/application/webhook/impl/sender"
+	usecase_impl "github.com/celpung/gocleanarch/application/webhook/impl/usecase"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/infrastructure/webhook"
+	"gorm.io/gorm"
+)
+
+// Shared is the webhook use case set up by ConnectWebhooks; the routers
+// serve the webhook API with it.
+var Shared *usecase_impl.WebhookUsecaseStruct
+
+// ConnectWebhooks builds Shared from the WEBHOOK_* settings. The returned
+// dispatcher is a sink that has to be given to the outbox relay, whatever
+// OUTBOX_SINKS says, and still has to be Run.
+func ConnectWebhooks(db *gorm.DB) (*Dispatcher, error) {
+	maxAttempts, err := positiveInt("WEBHOOK_MAX_ATTEMPTS", environment.Env.WEBHOOK_MAX_ATTEMPTS)
+	if err != nil {
+		return nil, err
+	}
+	disableAfter, err := strconv.Atoi(environment.Env.WEBHOOK_DISABLE_AFTER)
+	if err != nil || disableAfter < 0 {
+		return nil, fmt.Errorf("WEBHOOK_DISABLE_AFTER must be a non-negative integer, got %q", environment.Env.WEBHOOK_DISABLE_AFTER)
+	}
+	backoff, err := positiveDuration("WEBHOOK_BACKOFF", environment.Env.WEBHOOK_BACKOFF)
+	if err != nil {
+		return nil, err
+	}
+	maxBackoff, err := positiveDuration("WEBHOOK_MAX_BACKOFF", environment.Env.WEBHOOK_MAX_BACKOFF)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := positiveDuration("WEBHOOK_TIMEOUT", environment.Env.WEBHOOK_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+	poll, err := positiveDuration("WEBHOOK_POLL_INTERVAL", environment.Env.WEBHOOK_POLL_INTERVAL)
+	if err != nil {
+		return nil, err
+	}
+	allowPrivate, err := strconv.ParseBool(environment.Env.WEBHOOK_ALLOW_PRIVATE)
+	if err != nil {
+		return nil, fmt.Errorf("WEBHOOK_ALLOW_PRIVATE must be true or false, got %q", environment.Env.WEBHOOK_ALLOW_PRIVATE)
+	}
+
+	catalog := make([]string, len(event.Names))
+	for i, name := range event.Names {
+		catalog[i] = string(name)
+	}
+
+	uc := usecase_impl.NewWebhookUsecase(
+		repository_impl.NewWebhookRepository(db),
+		sender_impl.NewHTTPSender(webhook.NewClient(timeout, allowPrivate)),
+		catalog,
+	)
+	uc.MaxAttempts = maxAttempts
+	uc.DisableAfter = disableAfter
+	uc.Backoff = backoff
+	uc.MaxBackoff = maxBackoff
+	// a batch never takes longer than sending it one request at a time
+	uc.Lease = max(uc.Lease, time.Duration(uc.BatchSize)*timeout)
+	Shared = uc
+
+	return NewDispatcher(uc, poll), nil
+}
+
+func positiveInt(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+func positiveDuration(key, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", key, value)
+	}
+	return d, nil
+}
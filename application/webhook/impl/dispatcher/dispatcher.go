@@ -0,0 +1,54 @@
+package dispatcher_impl
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/webhook/domain/usecase"
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+)
+
+// Dispatcher connects webhooks to the outbox: as a relay sink it queues
+// deliveries for every outbox message, and Run sends them in the
+// background.
+type Dispatcher struct {
+	Usecase      usecase.WebhookUsecase
+	PollInterval time.Duration
+}
+
+func (d *Dispatcher) Name() string { return "webhooks" }
+
+// Deliver queues the message for every matching webhook. Queuing is
+// idempotent per message ID, so the relay may hand it the same message
+// more than once.
+func (d *Dispatcher) Deliver(ctx context.Context, msg outbox.Message) error {
+	_, err := d.Usecase.Dispatch(ctx, msg.ID, msg.Topic, msg.Payload)
+	return err
+}
+
+// Run sends due deliveries until ctx is cancelled, sleeping PollInterval
+// whenever nothing is due.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		n, err := d.Usecase.DeliverDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("webhooks: %v", err)
+		}
+		if n > 0 && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.PollInterval):
+		}
+	}
+}
+
+func NewDispatcher(uc usecase.WebhookUsecase, pollInterval time.Duration) *Dispatcher {
+	return &Dispatcher{Usecase: uc, PollInterval: pollInterval}
+}
+
+var _ outbox.Sink = (*Dispatcher)(nil)
@@ -0,0 +1,219 @@
+package repository_impl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/webhook/domain/entity"
+	"github.com/celpung/gocleanarch/application/webhook/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+)
+
+// WebhookRepositoryStruct reports missing rows as entity.ErrEndpointNotFound
+// and entity.ErrDeliveryNotFound.
+type WebhookRepositoryStruct struct {
+	DB *gorm.DB
+}
+
+func (r *WebhookRepositoryStruct) CreateEndpoint(ctx context.Context, m *model.WebhookEndpoint) (*model.WebhookEndpoint, error) {
+	if err := r.db(ctx).Create(m).Error; err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *WebhookRepositoryStruct) ReadEndpoints(ctx context.Context, ownerID string) ([]*model.WebhookEndpoint, error) {
+	var endpoints []*model.WebhookEndpoint
+	q := r.db(ctx).Order("created_at, id")
+	if ownerID != "" {
+		q = q.Where("owner_id = ?", ownerID)
+	}
+	if err := q.Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookRepositoryStruct) ReadActiveEndpoints(ctx context.Context) ([]*model.WebhookEndpoint, error) {
+	var endpoints []*model.WebhookEndpoint
+	if err := r.primary(ctx).Where("active = ?", true).Order("created_at, id").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookRepositoryStruct) ReadEndpointByID(ctx context.Context, id string) (*model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	if err := r.primary(ctx).Where("id = ?", id).First(&endpoint).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrEndpointNotFound
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhookRepositoryStruct) UpdateEndpoint(ctx context.Context, id string, fields map[string]any) (*model.WebhookEndpoint, error) {
+	tx := r.db(ctx).Model(&model.WebhookEndpoint{}).Where("id = ?", id).Updates(fields)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return nil, entity.ErrEndpointNotFound
+	}
+	return r.ReadEndpointByID(ctx, id)
+}
+
+func (r *WebhookRepositoryStruct) DeleteEndpoint(ctx context.Context, id string) error {
+	tx := r.db(ctx).Where("id = ?", id).Delete(&model.WebhookEndpoint{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return entity.ErrEndpointNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryStruct) IncrementFailures(ctx context.Context, id string) (int, error) {
+	if err := r.db(ctx).Model(&model.WebhookEndpoint{}).
+		Where("id = ?", id).
+		UpdateColumn("failure_count", gorm.Expr("failure_count + 1")).Error; err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := r.primary(ctx).Model(&model.WebhookEndpoint{}).
+		Where("id = ?", id).
+		Select("failure_count").
+		Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *WebhookRepositoryStruct) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "endpoint_id"}, {Name: "event_id"}}, DoNothing: true}).
+		Create(deliveries).Error
+}
+
+// ClaimDueDeliveries leases each due delivery with a conditional update,
+// the same portable scheme the outbox store uses.
+func (r *WebhookRepositoryStruct) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	now = now.UTC()
+
+	var due []*model.WebhookDelivery
+	if err := r.primary(ctx).
+		Joins("JOIN webhook_endpoints ON webhook_endpoints.id = webhook_deliveries.endpoint_id").
+		Where("webhook_endpoints.active = ?", true).
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", "pending", now).
+		Where("webhook_deliveries.locked_until IS NULL OR webhook_deliveries.locked_until <= ?", now).
+		Order("webhook_deliveries.next_attempt_at, webhook_deliveries.id").
+		Limit(limit).
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	claimed := make([]*model.WebhookDelivery, 0, len(due))
+	for _, d := range due {
+		res := r.primary(ctx).Model(&model.WebhookDelivery{}).
+			Where("id = ? AND status = ?", d.ID, "pending").
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			UpdateColumn("locked_until", now.Add(lease))
+		if res.Error != nil {
+			return claimed, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		claimed = append(claimed, d)
+	}
+	return claimed, nil
+}
+
+func (r *WebhookRepositoryStruct) ReadDeliveries(ctx context.Context, endpointID, status string, page, limit uint) ([]*model.WebhookDelivery, int64, error) {
+	const (
+		defaultLimit uint = 10
+		maxLimit     uint = 100
+	)
+
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	base := r.db(ctx).Model(&model.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		base = base.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*model.WebhookDelivery
+	if err := base.
+		Order("created_at DESC, id DESC").
+		Offset(int((page - 1) * limit)).
+		Limit(int(limit)).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (r *WebhookRepositoryStruct) ReadDeliveryByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := r.primary(ctx).Where("id = ?", id).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepositoryStruct) UpdateDelivery(ctx context.Context, id string, fields map[string]any) error {
+	return r.db(ctx).Model(&model.WebhookDelivery{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *WebhookRepositoryStruct) CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error {
+	return r.db(ctx).Create(attempt).Error
+}
+
+func (r *WebhookRepositoryStruct) ReadAttempts(ctx context.Context, deliveryID string) ([]*model.WebhookAttempt, error) {
+	var attempts []*model.WebhookAttempt
+	if err := r.db(ctx).Where("delivery_id = ?", deliveryID).Order("attempt, created_at").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *WebhookRepositoryStruct) db(ctx context.Context) *gorm.DB {
+	return uow_impl.DB(ctx, r.DB)
+}
+
+// primary is db pinned to the primary database, for reads that decide what
+// to send next and must not see a lagging replica.
+func (r *WebhookRepositoryStruct) primary(ctx context.Context) *gorm.DB {
+	return r.db(ctx).Clauses(dbresolver.Write)
+}
+
+func NewWebhookRepository(db *gorm.DB) repository.WebhookRepository {
+	return &WebhookRepositoryStruct{DB: db}
+}
@@ -0,0 +1,70 @@
+package sender_impl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/webhook/domain/repository"
+	"github.com/celpung/gocleanarch/infrastructure/webhook"
+)
+
+// maxResponseBody is how much of a response is kept in the delivery log.
+const maxResponseBody = 2 << 10
+
+// HTTPSender POSTs the payload as JSON, signed with the endpoint's secret.
+// Any 2xx response counts as delivered.
+type HTTPSender struct {
+	Client *http.Client
+	// Now is the clock used for the signature timestamp.
+	Now func() time.Time
+}
+
+func (s *HTTPSender) Send(ctx context.Context, r repository.WebhookRequest) repository.WebhookResponse {
+	started := time.Now()
+	res := s.send(ctx, r)
+	res.Duration = time.Since(started)
+	return res
+}
+
+func (s *HTTPSender) send(ctx context.Context, r repository.WebhookRequest) repository.WebhookResponse {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Payload))
+	if err != nil {
+		return repository.WebhookResponse{Err: err}
+	}
+
+	now := s.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gocleanarch-webhooks/1")
+	req.Header.Set(webhook.HeaderID, r.DeliveryID)
+	req.Header.Set(webhook.HeaderEvent, r.EventType)
+	req.Header.Set(webhook.HeaderAttempt, strconv.Itoa(r.Attempt))
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(r.Secret, now, r.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return repository.WebhookResponse{Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	out := repository.WebhookResponse{StatusCode: resp.StatusCode, Body: string(body)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		out.Err = fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return out
+}
+
+func NewHTTPSender(client *http.Client) repository.WebhookSender {
+	if client == nil {
+		client = webhook.NewClient(10*time.Second, false)
+	}
+	return &HTTPSender{Client: client, Now: time.Now}
+}
@@ -0,0 +1,542 @@
+package usecase_impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/webhook/domain/entity"
+	"github.com/celpung/gocleanarch/application/webhook/domain/repository"
+	"github.com/celpung/gocleanarch/application/webhook/domain/usecase"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	"github.com/celpung/gocleanarch/infrastructure/webhook"
+	"golang.org/x/sync/errgroup"
+)
+
+// superRole sees and manages every endpoint; other roles only their own.
+const superRole = "SUPER"
+
+// maxErrorLength keeps last_error and the attempt log readable.
+const maxErrorLength = 1000
+
+// WebhookUsecaseStruct delivers each event to each subscribed endpoint at
+// least once. A failed delivery is retried with exponential backoff until
+// MaxAttempts; an endpoint that fails DisableAfter attempts in a row is
+// disabled until its owner turns it back on.
+type WebhookUsecaseStruct struct {
+	Repo   repository.WebhookRepository
+	Sender repository.WebhookSender
+	// Catalog lists the event names endpoints may subscribe to. Filters are
+	// not checked when it is empty.
+	Catalog     []string
+	MaxAttempts int
+	// Backoff is the wait before the first retry; it doubles with every
+	// attempt up to MaxBackoff.
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+	DisableAfter int
+	// BatchSize deliveries are claimed per DeliverDue and sent with up to
+	// Concurrency requests in flight. Lease must exceed the time a batch
+	// takes.
+	BatchSize   int
+	Concurrency int
+	Lease       time.Duration
+	// Now is the clock; tests replace it.
+	Now func() time.Time
+}
+
+func (u *WebhookUsecaseStruct) CreateEndpoint(ctx context.Context, e *entity.Endpoint) (*entity.Endpoint, error) {
+	ownerID, _, err := u.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := webhook.ValidateURL(e.URL); err != nil {
+		return nil, err
+	}
+	events, err := u.normalizeEvents(e.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := u.Repo.CreateEndpoint(ctx, &model.WebhookEndpoint{
+		OwnerID:     ownerID,
+		URL:         e.URL,
+		Description: strings.TrimSpace(e.Description),
+		Secret:      secret,
+		Events:      strings.Join(events, ","),
+		Active:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := toEndpoint(m)
+	out.Secret = secret
+	return out, nil
+}
+
+func (u *WebhookUsecaseStruct) ListEndpoints(ctx context.Context) ([]*entity.Endpoint, error) {
+	ownerID, all, err := u.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		ownerID = ""
+	}
+
+	ms, err := u.Repo.ReadEndpoints(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*entity.Endpoint, len(ms))
+	for i, m := range ms {
+		out[i] = toEndpoint(m)
+	}
+	return out, nil
+}
+
+func (u *WebhookUsecaseStruct) GetEndpoint(ctx context.Context, id string) (*entity.Endpoint, error) {
+	m, err := u.endpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toEndpoint(m), nil
+}
+
+func (u *WebhookUsecaseStruct) UpdateEndpoint(ctx context.Context, payload *entity.UpdateEndpointPayload) (*entity.Endpoint, error) {
+	if _, err := u.endpoint(ctx, payload.ID); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]any)
+	if payload.URL != nil {
+		if err := webhook.ValidateURL(*payload.URL); err != nil {
+			return nil, err
+		}
+		fields["url"] = *payload.URL
+	}
+	if payload.Description != nil {
+		fields["description"] = strings.TrimSpace(*payload.Description)
+	}
+	if payload.Events != nil {
+		events, err := u.normalizeEvents(payload.Events)
+		if err != nil {
+			return nil, err
+		}
+		fields["events"] = strings.Join(events, ",")
+	}
+	if payload.Active != nil {
+		fields["active"] = *payload.Active
+		if *payload.Active {
+			fields["failure_count"] = 0
+			fields["disabled_at"] = nil
+			fields["disabled_reason"] = ""
+		} else {
+			fields["disabled_at"] = u.Now().UTC()
+			fields["disabled_reason"] = "disabled by user"
+		}
+	}
+	if len(fields) == 0 {
+		return u.GetEndpoint(ctx, payload.ID)
+	}
+
+	m, err := u.Repo.UpdateEndpoint(ctx, payload.ID, fields)
+	if err != nil {
+		return nil, err
+	}
+	return toEndpoint(m), nil
+}
+
+func (u *WebhookUsecaseStruct) RotateSecret(ctx context.Context, id string) (*entity.Endpoint, error) {
+	if _, err := u.endpoint(ctx, id); err != nil {
+		return nil, err
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	m, err := u.Repo.UpdateEndpoint(ctx, id, map[string]any{"secret": secret})
+	if err != nil {
+		return nil, err
+	}
+
+	out := toEndpoint(m)
+	out.Secret = secret
+	return out, nil
+}
+
+func (u *WebhookUsecaseStruct) DeleteEndpoint(ctx context.Context, id string) error {
+	if _, err := u.endpoint(ctx, id); err != nil {
+		return err
+	}
+	return u.Repo.DeleteEndpoint(ctx, id)
+}
+
+func (u *WebhookUsecaseStruct) ListDeliveries(ctx context.Context, endpointID, status string, page, limit uint) ([]*entity.Delivery, int64, error) {
+	if _, err := u.endpoint(ctx, endpointID); err != nil {
+		return nil, 0, err
+	}
+
+	ms, total, err := u.Repo.ReadDeliveries(ctx, endpointID, status, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]*entity.Delivery, len(ms))
+	for i, m := range ms {
+		out[i] = toDelivery(m)
+	}
+	return out, total, nil
+}
+
+func (u *WebhookUsecaseStruct) GetDelivery(ctx context.Context, endpointID, deliveryID string) (*entity.Delivery, []*entity.Attempt, error) {
+	_, d, err := u.delivery(ctx, endpointID, deliveryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ms, err := u.Repo.ReadAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attempts := make([]*entity.Attempt, len(ms))
+	for i, m := range ms {
+		attempts[i] = toAttempt(m)
+	}
+	return toDelivery(d), attempts, nil
+}
+
+func (u *WebhookUsecaseStruct) Redeliver(ctx context.Context, endpointID, deliveryID string) (*entity.Attempt, error) {
+	endpoint, d, err := u.delivery(ctx, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt, err := u.attempt(ctx, endpoint, d, true)
+	if err != nil {
+		return nil, err
+	}
+	return toAttempt(attempt), nil
+}
+
+func (u *WebhookUsecaseStruct) Dispatch(ctx context.Context, eventID, eventType string, payload []byte) (int, error) {
+	endpoints, err := u.Repo.ReadActiveEndpoints(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := u.Now().UTC()
+	var deliveries []*model.WebhookDelivery
+	for _, m := range endpoints {
+		if !toEndpoint(m).Subscribes(eventType) {
+			continue
+		}
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			EndpointID:    m.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        string(entity.DeliveryPending),
+			NextAttemptAt: &now,
+		})
+	}
+
+	if err := u.Repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return 0, err
+	}
+	return len(deliveries), nil
+}
+
+func (u *WebhookUsecaseStruct) DeliverDue(ctx context.Context) (int, error) {
+	due, err := u.Repo.ClaimDueDeliveries(ctx, u.Now(), u.BatchSize, u.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	var g errgroup.Group
+	g.SetLimit(max(u.Concurrency, 1))
+	for _, d := range due {
+		g.Go(func() error {
+			endpoint, err := u.Repo.ReadEndpointByID(ctx, d.EndpointID)
+			if errors.Is(err, entity.ErrEndpointNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			_, err = u.attempt(ctx, endpoint, d, false)
+			return err
+		})
+	}
+	return len(due), g.Wait()
+}
+
+// attempt sends d once, logs the attempt and moves the delivery on: to
+// succeeded, to its next retry, or to failed once MaxAttempts is reached.
+// Manual attempts never schedule retries or count against the endpoint.
+func (u *WebhookUsecaseStruct) attempt(ctx context.Context, endpoint *model.WebhookEndpoint, d *model.WebhookDelivery, manual bool) (*model.WebhookAttempt, error) {
+	n := d.Attempts + 1
+	res := u.Sender.Send(ctx, repository.WebhookRequest{
+		URL:        endpoint.URL,
+		Secret:     endpoint.Secret,
+		DeliveryID: d.ID,
+		EventType:  d.EventType,
+		Attempt:    n,
+		Payload:    []byte(d.Payload),
+	})
+	now := u.Now().UTC()
+
+	attempt := &model.WebhookAttempt{
+		DeliveryID:   d.ID,
+		Attempt:      n,
+		Manual:       manual,
+		StatusCode:   res.StatusCode,
+		ResponseBody: res.Body,
+		DurationMs:   res.Duration.Milliseconds(),
+		CreatedAt:    now,
+	}
+	if res.Err != nil {
+		attempt.Error = truncate(res.Err.Error())
+	}
+	if err := u.Repo.CreateAttempt(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]any{
+		"attempts":         n,
+		"last_status_code": res.StatusCode,
+		"last_error":       attempt.Error,
+		"locked_until":     nil,
+	}
+	switch {
+	case res.Err == nil:
+		fields["status"] = string(entity.DeliverySucceeded)
+		fields["delivered_at"] = now
+		fields["next_attempt_at"] = nil
+	case manual:
+	case n >= u.MaxAttempts:
+		fields["status"] = string(entity.DeliveryFailed)
+		fields["next_attempt_at"] = nil
+	default:
+		fields["next_attempt_at"] = now.Add(u.backoff(n))
+	}
+	if err := u.Repo.UpdateDelivery(ctx, d.ID, fields); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.Err == nil:
+		if endpoint.FailureCount > 0 {
+			if _, err := u.Repo.UpdateEndpoint(ctx, endpoint.ID, map[string]any{"failure_count": 0}); err != nil {
+				return nil, err
+			}
+		}
+	case !manual:
+		if err := u.recordFailure(ctx, endpoint, res.Err); err != nil {
+			return nil, err
+		}
+	}
+	return attempt, nil
+}
+
+// recordFailure counts a failed attempt against the endpoint and disables
+// it once DisableAfter attempts in a row have failed.
+func (u *WebhookUsecaseStruct) recordFailure(ctx context.Context, endpoint *model.WebhookEndpoint, cause error) error {
+	failures, err := u.Repo.IncrementFailures(ctx, endpoint.ID)
+	if err != nil {
+		return err
+	}
+	if u.DisableAfter <= 0 || failures < u.DisableAfter || !endpoint.Active {
+		return nil
+	}
+
+	reason := truncate(fmt.Sprintf("disabled after %d consecutive failed attempts: %v", failures, cause))
+	if _, err := u.Repo.UpdateEndpoint(ctx, endpoint.ID, map[string]any{
+		"active":          false,
+		"disabled_at":     u.Now().UTC(),
+		"disabled_reason": reason,
+	}); err != nil {
+		return err
+	}
+	endpoint.Active = false
+	log.Printf("webhooks: endpoint %s %s", endpoint.ID, reason)
+	return nil
+}
+
+// backoff is the wait after the n-th failed attempt.
+func (u *WebhookUsecaseStruct) backoff(n int) time.Duration {
+	wait := u.Backoff
+	for i := 1; i < n && wait < u.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, u.MaxBackoff)
+}
+
+// principal returns the caller's user ID and whether they may see every
+// endpoint.
+func (u *WebhookUsecaseStruct) principal(ctx context.Context) (string, bool, error) {
+	p, ok := requestctx.PrincipalFrom(ctx)
+	if !ok || p.UserID == "" {
+		return "", false, entity.ErrUnauthenticated
+	}
+	return p.UserID, strings.EqualFold(p.Role, superRole), nil
+}
+
+// endpoint loads an endpoint the caller may manage.
+func (u *WebhookUsecaseStruct) endpoint(ctx context.Context, id string) (*model.WebhookEndpoint, error) {
+	ownerID, all, err := u.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := u.Repo.ReadEndpointByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !all && m.OwnerID != ownerID {
+		return nil, entity.ErrEndpointNotFound
+	}
+	return m, nil
+}
+
+// delivery loads a delivery of an endpoint the caller may manage.
+func (u *WebhookUsecaseStruct) delivery(ctx context.Context, endpointID, deliveryID string) (*model.WebhookEndpoint, *model.WebhookDelivery, error) {
+	endpoint, err := u.endpoint(ctx, endpointID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d, err := u.Repo.ReadDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if d.EndpointID != endpoint.ID {
+		return nil, nil, entity.ErrDeliveryNotFound
+	}
+	return endpoint, d, nil
+}
+
+// normalizeEvents trims, lowercases, deduplicates and sorts event filters
+// and checks them against the catalog.
+func (u *WebhookUsecaseStruct) normalizeEvents(filters []string) ([]string, error) {
+	seen := make(map[string]bool, len(filters))
+	var out []string
+	for _, f := range filters {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		if !u.known(f) {
+			return nil, fmt.Errorf("%w: %q", entity.ErrUnknownEvent, f)
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	if len(out) == 0 {
+		return nil, entity.ErrNoEvents
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// known reports whether filter selects at least one catalog event.
+func (u *WebhookUsecaseStruct) known(filter string) bool {
+	if len(u.Catalog) == 0 || filter == "*" {
+		return true
+	}
+	for _, name := range u.Catalog {
+		if entity.MatchEvent(filter, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func toEndpoint(m *model.WebhookEndpoint) *entity.Endpoint {
+	var events []string
+	if m.Events != "" {
+		events = strings.Split(m.Events, ",")
+	}
+	return &entity.Endpoint{
+		ID:             m.ID,
+		OwnerID:        m.OwnerID,
+		URL:            m.URL,
+		Description:    m.Description,
+		Events:         events,
+		Active:         m.Active,
+		FailureCount:   m.FailureCount,
+		DisabledAt:     m.DisabledAt,
+		DisabledReason: m.DisabledReason,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
+
+func toDelivery(m *model.WebhookDelivery) *entity.Delivery {
+	return &entity.Delivery{
+		ID:             m.ID,
+		EndpointID:     m.EndpointID,
+		EventID:        m.EventID,
+		EventType:      m.EventType,
+		Payload:        []byte(m.Payload),
+		Status:         entity.DeliveryStatus(m.Status),
+		Attempts:       m.Attempts,
+		NextAttemptAt:  m.NextAttemptAt,
+		LastStatusCode: m.LastStatusCode,
+		LastError:      m.LastError,
+		DeliveredAt:    m.DeliveredAt,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
+
+func toAttempt(m *model.WebhookAttempt) *entity.Attempt {
+	return &entity.Attempt{
+		ID:           m.ID,
+		DeliveryID:   m.DeliveryID,
+		Attempt:      m.Attempt,
+		Manual:       m.Manual,
+		StatusCode:   m.StatusCode,
+		ResponseBody: m.ResponseBody,
+		Error:        m.Error,
+		Duration:     time.Duration(m.DurationMs) * time.Millisecond,
+		CreatedAt:    m.CreatedAt,
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
+
+func NewWebhookUsecase(repo repository.WebhookRepository, sender repository.WebhookSender, catalog []string) *WebhookUsecaseStruct {
+	return &WebhookUsecaseStruct{
+		Repo:         repo,
+		Sender:       sender,
+		Catalog:      catalog,
+		MaxAttempts:  8,
+		Backoff:      30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		DisableAfter: 20,
+		BatchSize:    50,
+		Concurrency:  8,
+		Lease:        5 * time.Minute,
+		Now:          time.Now,
+	}
+}
+
+var _ usecase.WebhookUsecase = (*WebhookUsecaseStruct)(nil)
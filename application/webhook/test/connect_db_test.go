@@ -0,0 +1,28 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err, "failed to open in-memory SQLite database")
+
+	/* Every new connection to ":memory:" opens an empty database, so keep a single connection that transactions and plain queries share. */
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	/* Build the schema with the same embedded SQLite migrations the application ships, including the FTS5 index and its triggers. */
+	require.NoError(t, migration.Migrate(context.Background(), db), "failed to migrate schema")
+
+	return db
+}
@@ -0,0 +1,469 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	user_entity "github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/event"
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	user_repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	user_usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
+	webhook_entity "github.com/celpung/gocleanarch/application/webhook/domain/entity"
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	webhook_repository_impl "github.com/celpung/gocleanarch/application/webhook/impl/repository"
+	sender_impl "github.com/celpung/gocleanarch/application/webhook/impl/sender"
+	webhook_usecase_impl "github.com/celpung/gocleanarch/application/webhook/impl/usecase"
+	"github.com/celpung/gocleanarch/infrastructure/auth"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"github.com/celpung/gocleanarch/infrastructure/webhook"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the webhook tests from the project root:
+     go test -v ./application/webhook/test
+
+Notes:
+- Endpoints point at httptest servers on 127.0.0.1, so the sender's client
+  is built with private addresses allowed.
+- The use case runs on a fake clock; retries are made due by moving the
+  clock instead of sleeping.
+===============================================================================
+*/
+
+// webhookReceiver is an endpoint that answers with the queued status codes
+// (200 once they run out) and verifies every signature it receives.
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	secret   string
+	statuses []int
+	events   []string
+	bodies   []string
+	verified []error
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	t.Helper()
+
+	rec := &webhookReceiver{statuses: statuses}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.events = append(rec.events, r.Header.Get(webhook.HeaderEvent))
+		rec.bodies = append(rec.bodies, string(body))
+		rec.verified = append(rec.verified, webhook.Verify(rec.secret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), body, webhook.DefaultTolerance, time.Now()))
+
+		status := http.StatusOK
+		if len(rec.statuses) > 0 {
+			status, rec.statuses = rec.statuses[0], rec.statuses[1:]
+		}
+		w.WriteHeader(status)
+		w.Write([]byte("status " + http.StatusText(status)))
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func (r *webhookReceiver) received() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+func newWebhookUsecase(t *testing.T) (*webhook_usecase_impl.WebhookUsecaseStruct, *gorm.DB, *time.Time) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	catalog := make([]string, len(event.Names))
+	for i, name := range event.Names {
+		catalog[i] = string(name)
+	}
+
+	uc := webhook_usecase_impl.NewWebhookUsecase(
+		webhook_repository_impl.NewWebhookRepository(db),
+		sender_impl.NewHTTPSender(webhook.NewClient(5*time.Second, true)),
+		catalog,
+	)
+	now := time.Now().UTC()
+	uc.Now = func() time.Time { return now }
+	return uc, db, &now
+}
+
+func adminCtx(id, role string) context.Context {
+	return requestctx.WithPrincipal(context.Background(), requestctx.Principal{UserID: id, Role: role})
+}
+
+func ptrBool(b bool) *bool { return &b }
+
+/*
+TestWebhook_SignatureVerification verifies that a signature made by Sign
+passes Verify, also next to other signatures, and that a changed body, a
+wrong secret or a stale timestamp are rejected.
+*/
+func TestWebhook_SignatureVerification(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"user.registered"}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := webhook.Sign("whsec_a", now, body)
+
+	require.NoError(t, webhook.Verify("whsec_a", ts, sig, body, time.Minute, now))
+	require.NoError(t, webhook.Verify("whsec_a", ts, "v1=00ff, "+sig, body, time.Minute, now))
+	require.ErrorIs(t, webhook.Verify("whsec_a", ts, sig, []byte(`{"type":"user.deleted"}`), time.Minute, now), webhook.ErrInvalidSignature)
+	require.ErrorIs(t, webhook.Verify("whsec_b", ts, sig, body, time.Minute, now), webhook.ErrInvalidSignature)
+	require.ErrorIs(t, webhook.Verify("whsec_a", ts, sig, body, time.Minute, now.Add(2*time.Minute)), webhook.ErrTimestampExpired)
+	require.ErrorIs(t, webhook.Verify("whsec_a", "yesterday", sig, body, time.Minute, now), webhook.ErrInvalidTimestamp)
+	require.ErrorIs(t, webhook.Verify("whsec_a", ts, "", body, time.Minute, now), webhook.ErrMissingSignature)
+
+	secret, err := webhook.NewSecret()
+	require.NoError(t, err)
+	require.Regexp(t, `^whsec_[A-Za-z0-9_-]{43}$`, secret)
+}
+
+/*
+TestWebhook_EndpointValidationAndOwnership verifies event filter and URL
+validation, that the secret is only returned on create and rotate, and
+that admins only see their own endpoints while super users see all.
+*/
+func TestWebhook_EndpointValidationAndOwnership(t *testing.T) {
+	uc, _, _ := newWebhookUsecase(t)
+	alice := adminCtx("alice", "ADMIN")
+	bob := adminCtx("bob", "ADMIN")
+
+	_, err := uc.CreateEndpoint(alice, &webhook_entity.Endpoint{URL: "https://example.com/hook", Events: []string{"user.exploded"}})
+	require.ErrorIs(t, err, webhook_entity.ErrUnknownEvent)
+	_, err = uc.CreateEndpoint(alice, &webhook_entity.Endpoint{URL: "https://example.com/hook", Events: []string{" "}})
+	require.ErrorIs(t, err, webhook_entity.ErrNoEvents)
+	_, err = uc.CreateEndpoint(alice, &webhook_entity.Endpoint{URL: "ftp://example.com/hook", Events: []string{"*"}})
+	require.ErrorIs(t, err, webhook.ErrInvalidURL)
+	_, err = uc.CreateEndpoint(context.Background(), &webhook_entity.Endpoint{URL: "https://example.com/hook", Events: []string{"*"}})
+	require.ErrorIs(t, err, webhook_entity.ErrUnauthenticated)
+
+	created, err := uc.CreateEndpoint(alice, &webhook_entity.Endpoint{URL: "https://example.com/hook", Events: []string{"User.Deleted", "user.*", "user.deleted"}})
+	require.NoError(t, err)
+	require.Equal(t, "alice", created.OwnerID)
+	require.Equal(t, []string{"user.*", "user.deleted"}, created.Events)
+	require.True(t, created.Active)
+	require.NotEmpty(t, created.Secret)
+
+	got, err := uc.GetEndpoint(alice, created.ID)
+	require.NoError(t, err)
+	require.Empty(t, got.Secret, "the secret is only shown once")
+
+	_, err = uc.GetEndpoint(bob, created.ID)
+	require.ErrorIs(t, err, webhook_entity.ErrEndpointNotFound)
+	require.ErrorIs(t, uc.DeleteEndpoint(bob, created.ID), webhook_entity.ErrEndpointNotFound)
+	list, err := uc.ListEndpoints(bob)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	list, err = uc.ListEndpoints(adminCtx("root", "SUPER"))
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	rotated, err := uc.RotateSecret(alice, created.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, created.Secret, rotated.Secret)
+
+	require.NoError(t, uc.DeleteEndpoint(alice, created.ID))
+	_, err = uc.GetEndpoint(alice, created.ID)
+	require.ErrorIs(t, err, webhook_entity.ErrEndpointNotFound)
+}
+
+/*
+TestWebhook_DispatchMatchesFiltersOnce verifies that an event is queued
+for every active endpoint whose filters match it, and that dispatching the
+same event again queues nothing new.
+*/
+func TestWebhook_DispatchMatchesFiltersOnce(t *testing.T) {
+	uc, db, _ := newWebhookUsecase(t)
+	ctx := adminCtx("alice", "ADMIN")
+
+	create := func(events ...string) *webhook_entity.Endpoint {
+		e, err := uc.CreateEndpoint(ctx, &webhook_entity.Endpoint{URL: "https://example.com/" + events[0], Events: events})
+		require.NoError(t, err)
+		return e
+	}
+	all := create("*")
+	users := create("user.*")
+	create("user.deleted")
+	inactive := create("user.registered")
+	_, err := uc.UpdateEndpoint(ctx, &webhook_entity.UpdateEndpointPayload{ID: inactive.ID, Active: ptrBool(false)})
+	require.NoError(t, err)
+
+	n, err := uc.Dispatch(context.Background(), "0b7c3f5e-0000-4000-8000-000000000001", "user.registered", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	_, err = uc.Dispatch(context.Background(), "0b7c3f5e-0000-4000-8000-000000000001", "user.registered", []byte(`{}`))
+	require.NoError(t, err)
+
+	var rows []model.WebhookDelivery
+	require.NoError(t, db.Order("endpoint_id").Find(&rows).Error)
+	require.Len(t, rows, 2, "a repeated event is not queued twice")
+	require.ElementsMatch(t, []string{all.ID, users.ID}, []string{rows[0].EndpointID, rows[1].EndpointID})
+	require.Equal(t, "pending", rows[0].Status)
+}
+
+/*
+TestWebhook_DeliversSignedRequestsWithBackoff verifies the signed request,
+that a failed attempt is retried only after the backoff has passed, and
+that the delivery log keeps the response code of every attempt.
+*/
+func TestWebhook_DeliversSignedRequestsWithBackoff(t *testing.T) {
+	uc, _, now := newWebhookUsecase(t)
+	ctx := adminCtx("alice", "ADMIN")
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+
+	endpoint, err := uc.CreateEndpoint(ctx, &webhook_entity.Endpoint{URL: receiver.URL, Events: []string{"user.registered"}})
+	require.NoError(t, err)
+	receiver.secret = endpoint.Secret
+
+	_, err = uc.Dispatch(context.Background(), "0b7c3f5e-0000-4000-8000-000000000002", "user.registered", []byte(`{"type":"user.registered"}`))
+	require.NoError(t, err)
+
+	n, err := uc.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	deliveries, total, err := uc.ListDeliveries(ctx, endpoint.ID, "", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	d := deliveries[0]
+	require.Equal(t, webhook_entity.DeliveryPending, d.Status)
+	require.Equal(t, http.StatusInternalServerError, d.LastStatusCode)
+	require.WithinDuration(t, now.Add(uc.Backoff), *d.NextAttemptAt, time.Millisecond)
+
+	n, err = uc.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n, "not due before the backoff has passed")
+
+	*now = now.Add(uc.Backoff)
+	_, err = uc.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	d, attempts, err := uc.GetDelivery(ctx, endpoint.ID, d.ID)
+	require.NoError(t, err)
+	require.Equal(t, webhook_entity.DeliverySucceeded, d.Status)
+	require.NotNil(t, d.DeliveredAt)
+	require.Len(t, attempts, 2)
+	require.Equal(t, http.StatusInternalServerError, attempts[0].StatusCode)
+	require.Contains(t, attempts[0].Error, "500")
+	require.Equal(t, http.StatusOK, attempts[1].StatusCode)
+	require.Equal(t, "status OK", attempts[1].ResponseBody)
+
+	require.Equal(t, []string{"user.registered", "user.registered"}, receiver.events)
+	require.Equal(t, `{"type":"user.registered"}`, receiver.bodies[1])
+	require.Equal(t, []error{nil, nil}, receiver.verified)
+
+	got, err := uc.GetEndpoint(ctx, endpoint.ID)
+	require.NoError(t, err)
+	require.Zero(t, got.FailureCount, "a success resets the failure count")
+}
+
+/*
+TestWebhook_GivesUpAndDisablesFailingEndpoints verifies that a delivery
+fails for good after the maximum number of attempts, that an endpoint is
+disabled after too many failures in a row and gets nothing more, and that
+turning it back on resumes its pending deliveries.
+*/
+func TestWebhook_GivesUpAndDisablesFailingEndpoints(t *testing.T) {
+	uc, _, now := newWebhookUsecase(t)
+	uc.MaxAttempts = 2
+	uc.DisableAfter = 3
+	ctx := adminCtx("alice", "ADMIN")
+	receiver := newWebhookReceiver(t, 500, 500, 503)
+
+	endpoint, err := uc.CreateEndpoint(ctx, &webhook_entity.Endpoint{URL: receiver.URL, Events: []string{"user.*"}})
+	require.NoError(t, err)
+
+	_, err = uc.Dispatch(context.Background(), "0b7c3f5e-0000-4000-8000-000000000003", "user.registered", []byte(`{}`))
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = uc.DeliverDue(context.Background())
+		require.NoError(t, err)
+		*now = now.Add(time.Hour)
+	}
+
+	failed, _, err := uc.ListDeliveries(ctx, endpoint.ID, string(webhook_entity.DeliveryFailed), 1, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	require.Nil(t, failed[0].NextAttemptAt)
+	require.Equal(t, 2, failed[0].Attempts)
+
+	_, err = uc.Dispatch(context.Background(), "0b7c3f5e-0000-4000-8000-000000000004", "user.deleted", []byte(`{}`))
+	require.NoError(t, err)
+	_, err = uc.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	got, err := uc.GetEndpoint(ctx, endpoint.ID)
+	require.NoError(t, err)
+	require.False(t, got.Active)
+	require.NotNil(t, got.DisabledAt)
+	require.Contains(t, got.DisabledReason, "3 consecutive failed attempts")
+
+	*now = now.Add(time.Hour)
+	n, err := uc.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n, "disabled endpoints get no deliveries")
+	n, err = uc.Dispatch(context.Background(), "0b7c3f5e-0000-4000-8000-000000000005", "user.updated", []byte(`{}`))
+	require.NoError(t, err)
+	require.Zero(t, n, "disabled endpoints are not subscribed")
+
+	got, err = uc.UpdateEndpoint(ctx, &webhook_entity.UpdateEndpointPayload{ID: endpoint.ID, Active: ptrBool(true)})
+	require.NoError(t, err)
+	require.True(t, got.Active)
+	require.Zero(t, got.FailureCount)
+	require.Nil(t, got.DisabledAt)
+
+	_, err = uc.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, receiver.received(), "the pending delivery resumes")
+}
+
+/*
+TestWebhook_RedeliverSendsNow verifies that a manual redelivery of a failed
+delivery is sent right away, logged as manual and marks the delivery
+succeeded, and that it is scoped to the delivery's endpoint.
+*/
+func TestWebhook_RedeliverSendsNow(t *testing.T) {
+	uc, _, _ := newWebhookUsecase(t)
+	uc.MaxAttempts = 1
+	ctx := adminCtx("alice", "ADMIN")
+	receiver := newWebhookReceiver(t, http.StatusBadGateway)
+
+	endpoint, err := uc.CreateEndpoint(ctx, &webhook_entity.Endpoint{URL: receiver.URL, Events: []string{"*"}})
+	require.NoError(t, err)
+	other, err := uc.CreateEndpoint(ctx, &webhook_entity.Endpoint{URL: "https://example.com/other", Events: []string{"user.deleted"}})
+	require.NoError(t, err)
+
+	_, err = uc.Dispatch(context.Background(), "0b7c3f5e-0000-4000-8000-000000000006", "user.registered", []byte(`{}`))
+	require.NoError(t, err)
+	_, err = uc.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	deliveries, _, err := uc.ListDeliveries(ctx, endpoint.ID, "", 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, webhook_entity.DeliveryFailed, deliveries[0].Status)
+
+	_, err = uc.Redeliver(ctx, other.ID, deliveries[0].ID)
+	require.ErrorIs(t, err, webhook_entity.ErrDeliveryNotFound)
+	_, err = uc.Redeliver(adminCtx("bob", "ADMIN"), endpoint.ID, deliveries[0].ID)
+	require.ErrorIs(t, err, webhook_entity.ErrEndpointNotFound)
+
+	attempt, err := uc.Redeliver(ctx, endpoint.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	require.True(t, attempt.Manual)
+	require.Equal(t, 2, attempt.Attempt)
+	require.Equal(t, http.StatusOK, attempt.StatusCode)
+
+	d, _, err := uc.GetDelivery(ctx, endpoint.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, webhook_entity.DeliverySucceeded, d.Status)
+}
+
+/*
+TestWebhook_ClientRefusesPrivateAddresses verifies that the default client
+does not connect to loopback addresses and which URLs and addresses are
+accepted as webhook destinations.
+*/
+func TestWebhook_ClientRefusesPrivateAddresses(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+
+	_, err := webhook.NewClient(time.Second, false).Post(receiver.URL, "application/json", nil)
+	require.ErrorIs(t, err, webhook.ErrPrivateAddress)
+	require.Zero(t, receiver.received())
+
+	require.NoError(t, webhook.ValidateURL("https://hooks.example.com/in?x=1"))
+	for _, bad := range []string{"ftp://example.com", "https://user:pw@example.com", "/relative", "https://"} {
+		require.ErrorIs(t, webhook.ValidateURL(bad), webhook.ErrInvalidURL, bad)
+	}
+
+	for addr, public := range map[string]bool{
+		"8.8.8.8": true, "2606:4700::1111": true, "127.0.0.1": false, "10.1.2.3": false,
+		"169.254.169.254": false, "100.64.0.1": false, "::1": false, "::ffff:192.168.0.1": false,
+	} {
+		require.Equal(t, public, webhook.IsPublic(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+/*
+TestWebhook_OutboxEventsReachEndpoints verifies the whole path: a
+registered user leaves an event in the outbox, the relay hands it to the
+webhook dispatcher even when OUTBOX_SINKS lists no sink, and the endpoint
+receives the event payload.
+*/
+func TestWebhook_OutboxEventsReachEndpoints(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	users := &user_usecase_impl.UserUsecaseStruct{
+		Repo:            user_repository_impl.NewUserRepository(db),
+		PasswordService: &auth.PasswordService{},
+		JWTService:      &auth.JwtService{},
+		UoW:             uow_impl.NewGormUnitOfWork(db),
+		Outbox:          event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(db)),
+	}
+
+	hooks := webhook_usecase_impl.NewWebhookUsecase(
+		webhook_repository_impl.NewWebhookRepository(db),
+		sender_impl.NewHTTPSender(webhook.NewClient(5*time.Second, true)),
+		nil,
+	)
+	receiver := newWebhookReceiver(t)
+	endpoint, err := hooks.CreateEndpoint(adminCtx("alice", "ADMIN"), &webhook_entity.Endpoint{URL: receiver.URL, Events: []string{"user.registered"}})
+	require.NoError(t, err)
+	receiver.secret = endpoint.Secret
+
+	sinks := environment.Env.OUTBOX_SINKS
+	environment.Env.OUTBOX_SINKS = ""
+	t.Cleanup(func() { environment.Env.OUTBOX_SINKS = sinks })
+	relay, closeRelay, err := outbox_impl.NewRelayFromEnv(db, dispatcher_impl.NewDispatcher(hooks, time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(closeRelay)
+
+	created, err := users.Create(ctx, &user_entity.User{Name: "Wanda", Email: "wanda@example.com", Password: "pw", Role: "USER", Active: true})
+	require.NoError(t, err)
+	_, err = relay.RunOnce(ctx)
+	require.NoError(t, err)
+	_, err = hooks.DeliverDue(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, receiver.received())
+	require.NoError(t, receiver.verified[0])
+	e, err := event_impl.DecodeUserEvent(outbox.Message{Payload: []byte(receiver.bodies[0])})
+	require.NoError(t, err)
+	require.Equal(t, event.UserRegistered, e.Name)
+	require.Equal(t, created.ID, e.UserID)
+
+	failing := dispatcher_impl.NewDispatcher(failingWebhooks{hooks}, time.Millisecond)
+	require.Error(t, failing.Deliver(ctx, outbox.Message{ID: "e1", Topic: "user.deleted", Payload: []byte(`{}`)}), "a failed dispatch makes the relay retry")
+}
+
+// failingWebhooks cannot queue deliveries.
+type failingWebhooks struct {
+	*webhook_usecase_impl.WebhookUsecaseStruct
+}
+
+func (failingWebhooks) Dispatch(ctx context.Context, eventID, eventType string, payload []byte) (int, error) {
+	return 0, errors.New("database unavailable")
+}
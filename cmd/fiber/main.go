@@ -1,41 +1,113 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	"github.com/celpung/gocleanarch/delivery/fiber/adapter"
+	slider_router "github.com/celpung/gocleanarch/delivery/fiber/slider/router"
+	user_middleware "github.com/celpung/gocleanarch/delivery/fiber/user/middleware"
 	user_router "github.com/celpung/gocleanarch/delivery/fiber/user/router"
-	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	webhook_router "github.com/celpung/gocleanarch/delivery/fiber/webhook/router"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
 	"github.com/celpung/gocleanarch/infrastructure/environment"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once the server is asked to stop.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
-	// Connect to the database and auto migrate
-	if err := mysql.CreateDatabaseIfNotExists(); err != nil {
+	// Stop on SIGINT or SIGTERM: drain requests first, then the workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to the database and apply pending migrations
+	if err := database.CreateDatabaseIfNotExists(); err != nil {
 		log.Fatalf("failed to prepare database: %v", err)
 	}
-	if err := mysql.ConnectDatabase(); err != nil {
+	if err := database.ConnectDatabase(); err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
-	if err := mysql.AutoMigrate(); err != nil {
-		log.Fatalf("failed to auto migrate database: %v", err)
+	if err := migration.Migrate(context.Background(), database.DB); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+	if err := index_impl.OpenUserIndex(context.Background(), database.DB); err != nil {
+		log.Fatalf("failed to open user index: %v", err)
+	}
+	if err := cache_impl.ConnectCache(); err != nil {
+		log.Fatalf("failed to connect cache: %v", err)
+	}
+
+	if err := storage_impl.ConnectStorage(); err != nil {
+		log.Fatalf("failed to connect storage: %v", err)
+	}
+	if err := checker.ConnectInspector(); err != nil {
+		log.Fatalf("failed to set up upload inspection: %v", err)
+	}
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+
+	// Send webhooks for outbox events, whichever sinks are configured
+	webhooks, err := dispatcher_impl.ConnectWebhooks(database.DB)
+	if err != nil {
+		log.Fatalf("failed to set up webhooks: %v", err)
+	}
+
+	// Deliver outbox events to the webhooks and the configured sinks in the
+	// background
+	relay, closeRelay, err := outbox_impl.NewRelayFromEnv(database.DB, webhooks)
+	if err != nil {
+		log.Fatalf("failed to set up outbox relay: %v", err)
 	}
 
+	// Both workers run until the server has shut down
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var running sync.WaitGroup
+	running.Add(2)
+	go func() { defer running.Done(); webhooks.Run(workers) }()
+	go func() { defer running.Done(); relay.Run(workers) }()
+
 	// setup mode
 	mode := environment.Env.MODE
 
 	r := fiber.New(fiber.Config{
 		AppName:               "Skoolar Auth",
 		DisableStartupMessage: mode == "release",
+		// Leave room for form fields next to the largest accepted upload.
+		BodyLimit: int(storage_impl.MaxUploadSize) + 1<<20,
 	})
 
 	allowedOrigins := environment.Env.ALLOWED_ORIGINS
 
+	r.Use(user_middleware.RequestIDMiddleware())
+	r.Use(user_middleware.LocaleMiddleware())
+
 	r.Use(cors.New(cors.Config{
-		AllowOrigins: allowedOrigins,
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowOrigins:  allowedOrigins,
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, If-Match, X-Request-ID",
+		ExposeHeaders: "ETag, X-Request-ID",
 	}))
 
 	if mode == "debug" {
@@ -47,15 +119,60 @@ func main() {
 
 	api := r.Group("/api")
 	user_router.RegisterUserRouter(api)
+	webhook_router.RegisterWebhookRouter(api)
+	slider_router.RegisterSliderRouter(api)
+
+	// OpenAPI document at /openapi.json and Swagger UI at /docs
+	adapter.Register(r, httpcore.DocsRoutes("/api"))
+
+	// Health probe for load balancers and orchestrators
+	r.Get("/healthz", func(c *fiber.Ctx) error {
+		if err := database.Health(c.UserContext()); err != nil {
+			log.Printf("health check failed: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "unavailable"})
+		}
+		return c.JSON(fiber.Map{"status": "ok"})
+	})
 
 	r.Get("/", func(c *fiber.Ctx) error {
-		return c.SendFile("../../public/index.html")
+		return c.SendFile(filepath.Join(storage_impl.PublicDir, "index.html"))
 	})
 
-	r.Static("/images", "../../public/images")
+	// Serve uploaded files unless the storage serves them itself
+	if storage_impl.Handler != nil {
+		r.Get(storage_impl.MountPath+"/*", adaptor.HTTPHandler(http.StripPrefix(storage_impl.MountPath, storage_impl.Handler)))
+	}
 
 	log.Printf("Running in %s mode", mode)
-	if err := r.Listen(":" + environment.Env.PORT); err != nil {
-		log.Fatalf("failed to start fiber server: %v", err)
+	served := make(chan error, 1)
+	go func() { served <- r.Listen(":" + environment.Env.PORT) }()
+
+	// Serve until a signal arrives, then let in-flight requests finish
+	var serveErr error
+	select {
+	case serveErr = <-served:
+	case <-ctx.Done():
+		log.Printf("shutting down fiber server")
+		done, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := r.ShutdownWithContext(done); err != nil {
+			log.Printf("failed to shut down fiber server: %v", err)
+		}
+		cancel()
+	}
+
+	shutdown(stopWorkers, &running, closeRelay)
+	if serveErr != nil {
+		log.Fatalf("failed to start fiber server: %v", serveErr)
+	}
+}
+
+// shutdown stops the outbox relay and webhook dispatcher once no request
+// can emit events any more, then flushes the search index to disk.
+func shutdown(stopWorkers context.CancelFunc, running *sync.WaitGroup, closeRelay func()) {
+	stopWorkers()
+	running.Wait()
+	closeRelay()
+	if err := searchindex.CloseUsers(); err != nil {
+		log.Printf("failed to close user index: %v", err)
 	}
 }
@@ -1,33 +1,96 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"reflect"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
-	crud_router "github.com/celpung/go-generic-crud/crud_router"
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	"github.com/celpung/gocleanarch/delivery/gin/adapter"
+	slider_router "github.com/celpung/gocleanarch/delivery/gin/slider/router"
+	user_middleware "github.com/celpung/gocleanarch/delivery/gin/user/middleware"
 	user_router "github.com/celpung/gocleanarch/delivery/gin/user/router"
-	slider_entity "github.com/celpung/gocleanarch/domain/slider/entity"
-	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	webhook_router "github.com/celpung/gocleanarch/delivery/gin/webhook/router"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
 	"github.com/celpung/gocleanarch/infrastructure/environment"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once the server is asked to stop.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
-	// Connect to the database and auto migrate
-	if err := mysql.CreateDatabaseIfNotExists(); err != nil {
+	// Stop on SIGINT or SIGTERM: drain requests first, then the workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to the database and apply pending migrations
+	if err := database.CreateDatabaseIfNotExists(); err != nil {
 		log.Fatalf("failed to prepare database: %v", err)
 	}
-	if err := mysql.ConnectDatabase(); err != nil {
+	if err := database.ConnectDatabase(); err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
-	if err := mysql.AutoMigrate(); err != nil {
-		log.Fatalf("failed to auto migrate database: %v", err)
+	if err := migration.Migrate(context.Background(), database.DB); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+	if err := index_impl.OpenUserIndex(context.Background(), database.DB); err != nil {
+		log.Fatalf("failed to open user index: %v", err)
+	}
+	if err := cache_impl.ConnectCache(); err != nil {
+		log.Fatalf("failed to connect cache: %v", err)
+	}
+
+	if err := storage_impl.ConnectStorage(); err != nil {
+		log.Fatalf("failed to connect storage: %v", err)
+	}
+	if err := checker.ConnectInspector(); err != nil {
+		log.Fatalf("failed to set up upload inspection: %v", err)
+	}
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+
+	// Send webhooks for outbox events, whichever sinks are configured
+	webhooks, err := dispatcher_impl.ConnectWebhooks(database.DB)
+	if err != nil {
+		log.Fatalf("failed to set up webhooks: %v", err)
+	}
+
+	// Deliver outbox events to the webhooks and the configured sinks in the
+	// background
+	relay, closeRelay, err := outbox_impl.NewRelayFromEnv(database.DB, webhooks)
+	if err != nil {
+		log.Fatalf("failed to set up outbox relay: %v", err)
 	}
 
+	// Both workers run until the server has shut down
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var running sync.WaitGroup
+	running.Add(2)
+	go func() { defer running.Done(); webhooks.Run(workers) }()
+	go func() { defer running.Done(); relay.Run(workers) }()
+
 	// setup mode
 	mode := environment.Env.MODE
 
@@ -37,16 +100,23 @@ func main() {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	// Handlers validate bound requests with validation.ValidateStructCtx,
+	// which reports the fields at fault in the caller's language
+	binding.Validator = nil
+
 	//setup gin
 	r := gin.Default()
 
 	allowedOrigins := strings.Split(environment.Env.ALLOWED_ORIGINS, ",")
 
+	r.Use(user_middleware.RequestIDMiddleware())
+	r.Use(user_middleware.LocaleMiddleware())
+
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -54,29 +124,65 @@ func main() {
 	// setup router
 	api := r.Group("/api")
 	user_router.Router(api)
+	webhook_router.Router(api)
+	slider_router.Router(api)
 
-	// implement generic CRUD router
-	crud_router.SetupRouter[slider_entity.Slider](
-		api,
-		mysql.DB,
-		reflect.TypeOf(slider_entity.Slider{}),
-		"/sliders",
-		map[string][]gin.HandlerFunc{
-			"POST":   {},
-			"GET":    {},
-			"PUT":    {},
-			"DELETE": {},
-		})
+	// OpenAPI document at /openapi.json and Swagger UI at /docs
+	adapter.Register(r, httpcore.DocsRoutes("/api"))
+
+	// Health probe for load balancers and orchestrators
+	r.GET("/healthz", func(c *gin.Context) {
+		if err := database.Health(c.Request.Context()); err != nil {
+			log.Printf("health check failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
 
 	// Serve static files
 	r.GET("/", func(c *gin.Context) {
-		c.File("../../public/index.html")
+		c.File(filepath.Join(storage_impl.PublicDir, "index.html"))
 	})
 
-	r.Static("/images", "../../public/images")
+	// Serve uploaded files unless the storage serves them itself
+	if storage_impl.Handler != nil {
+		files := gin.WrapH(http.StripPrefix(storage_impl.MountPath, storage_impl.Handler))
+		r.GET(storage_impl.MountPath+"/*key", files)
+		r.HEAD(storage_impl.MountPath+"/*key", files)
+	}
 
 	// Start the server
-	if err := r.Run(fmt.Sprintf(":%s", environment.Env.PORT)); err != nil {
-		log.Fatalf("failed to start gin server: %v", err)
+	srv := &http.Server{Addr: fmt.Sprintf(":%s", environment.Env.PORT), Handler: r}
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+
+	// Serve until a signal arrives, then let in-flight requests finish
+	var serveErr error
+	select {
+	case serveErr = <-served:
+	case <-ctx.Done():
+		log.Printf("shutting down gin server")
+		done, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := srv.Shutdown(done); err != nil {
+			log.Printf("failed to shut down gin server: %v", err)
+		}
+		cancel()
+	}
+
+	shutdown(stopWorkers, &running, closeRelay)
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		log.Fatalf("failed to start gin server: %v", serveErr)
+	}
+}
+
+// shutdown stops the outbox relay and webhook dispatcher once no request
+// can emit events any more, then flushes the search index to disk.
+func shutdown(stopWorkers context.CancelFunc, running *sync.WaitGroup, closeRelay func()) {
+	stopWorkers()
+	running.Wait()
+	closeRelay()
+	if err := searchindex.CloseUsers(); err != nil {
+		log.Printf("failed to close user index: %v", err)
 	}
 }
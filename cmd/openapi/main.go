@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/openapi"
+)
+
+// openapi writes the OpenAPI document of the API. Run it from the module
+// root after changing routes or DTOs; the tests fail until the committed
+// document matches.
+func main() {
+	out := flag.String("o", openapi.File, "file to write")
+	base := flag.String("base", "", `path the API is mounted at, e.g. "/api" for gin and fiber`)
+	flag.Parse()
+
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+
+	data, err := openapi.Marshal(httpcore.Spec(*base))
+	if err != nil {
+		log.Fatalf("failed to encode document: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("failed to write document: %v", err)
+	}
+	log.Printf("wrote %s", *out)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+)
+
+// reindex rebuilds the embedded user search index from the database. Servers
+// load the index at startup, so restart them after running this command.
+func main() {
+	if err := database.ConnectDatabase(); err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+
+	if err := searchindex.OpenUsers(index_impl.FieldBoosts); err != nil {
+		log.Fatalf("failed to open user index: %v", err)
+	}
+
+	repo := repository_impl.NewUserRepository(database.DB)
+	count, err := index_impl.RebuildFromRepository(context.Background(), repo, index_impl.NewUserIndex(searchindex.Users))
+	if err != nil {
+		log.Fatalf("failed to rebuild user index: %v", err)
+	}
+	if err := searchindex.CloseUsers(); err != nil {
+		log.Fatalf("failed to write user index: %v", err)
+	}
+
+	log.Printf("indexed %d users into %s", count, environment.Env.SEARCH_INDEX_PATH)
+}
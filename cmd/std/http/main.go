@@ -1,27 +1,92 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/std/http/adapter"
+	slider_router "github.com/celpung/gocleanarch/delivery/std/http/slider/router"
+	user_middleware "github.com/celpung/gocleanarch/delivery/std/http/user/middleware"
 	user_router "github.com/celpung/gocleanarch/delivery/std/http/user/router"
-	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	webhook_router "github.com/celpung/gocleanarch/delivery/std/http/webhook/router"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
 	"github.com/celpung/gocleanarch/infrastructure/environment"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once the server is asked to stop.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
-	// Connect to the database and auto migrate
-	if err := mysql.CreateDatabaseIfNotExists(); err != nil {
+	// Stop on SIGINT or SIGTERM: drain requests first, then the workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to the database and apply pending migrations
+	if err := database.CreateDatabaseIfNotExists(); err != nil {
 		log.Fatalf("failed to prepare database: %v", err)
 	}
-	if err := mysql.ConnectDatabase(); err != nil {
+	if err := database.ConnectDatabase(); err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
-	if err := mysql.AutoMigrate(); err != nil {
-		log.Fatalf("failed to auto migrate database: %v", err)
+	if err := migration.Migrate(context.Background(), database.DB); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+	if err := index_impl.OpenUserIndex(context.Background(), database.DB); err != nil {
+		log.Fatalf("failed to open user index: %v", err)
+	}
+	if err := cache_impl.ConnectCache(); err != nil {
+		log.Fatalf("failed to connect cache: %v", err)
+	}
+
+	if err := storage_impl.ConnectStorage(); err != nil {
+		log.Fatalf("failed to connect storage: %v", err)
+	}
+	if err := checker.ConnectInspector(); err != nil {
+		log.Fatalf("failed to set up upload inspection: %v", err)
 	}
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+
+	// Send webhooks for outbox events, whichever sinks are configured
+	webhooks, err := dispatcher_impl.ConnectWebhooks(database.DB)
+	if err != nil {
+		log.Fatalf("failed to set up webhooks: %v", err)
+	}
+
+	// Deliver outbox events to the webhooks and the configured sinks in the
+	// background
+	relay, closeRelay, err := outbox_impl.NewRelayFromEnv(database.DB, webhooks)
+	if err != nil {
+		log.Fatalf("failed to set up outbox relay: %v", err)
+	}
+
+	// Both workers run until the server has shut down
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var running sync.WaitGroup
+	running.Add(2)
+	go func() { defer running.Done(); webhooks.Run(workers) }()
+	go func() { defer running.Done(); relay.Run(workers) }()
 
 	// Setup mode
 	mode := environment.Env.MODE
@@ -44,20 +109,30 @@ func main() {
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", strings.Join(origins, ","))
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length")
+		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, If-Match, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Length, ETag, X-Request-ID")
 
 		if r.Method == http.MethodOptions {
 			return
 		}
 
-		http.ServeFile(w, r, "../../public/index.html")
+		http.ServeFile(w, r, filepath.Join(storage_impl.PublicDir, "index.html"))
 	})
 
 	user_router.Router()
+	webhook_router.Router()
+	slider_router.Router()
 
-	// Serve static files
-	http.Handle("/images/", http.StripPrefix("/images", http.FileServer(http.Dir("../../public/images"))))
+	// OpenAPI document at /openapi.json and Swagger UI at /docs
+	adapter.Register(http.DefaultServeMux, httpcore.DocsRoutes(""))
+
+	// Health probe for load balancers and orchestrators
+	http.HandleFunc("/healthz", healthz)
+
+	// Serve uploaded files unless the storage serves them itself
+	if storage_impl.Handler != nil {
+		http.Handle(storage_impl.MountPath+"/", http.StripPrefix(storage_impl.MountPath, storage_impl.Handler))
+	}
 
 	// Log the startup message in debug mode
 	port := environment.Env.PORT
@@ -66,7 +141,49 @@ func main() {
 	}
 
 	// Start the server
-	if err := http.ListenAndServe(fmt.Sprintf(":%s", port), nil); err != nil {
-		log.Fatalf("failed to start std http server: %v", err)
+	srv := &http.Server{Addr: fmt.Sprintf(":%s", port), Handler: user_middleware.RequestIDMiddleware(user_middleware.LocaleMiddleware(http.DefaultServeMux))}
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+
+	// Serve until a signal arrives, then let in-flight requests finish
+	var serveErr error
+	select {
+	case serveErr = <-served:
+	case <-ctx.Done():
+		log.Printf("shutting down std http server")
+		done, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := srv.Shutdown(done); err != nil {
+			log.Printf("failed to shut down std http server: %v", err)
+		}
+		cancel()
+	}
+
+	shutdown(stopWorkers, &running, closeRelay)
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		log.Fatalf("failed to start std http server: %v", serveErr)
+	}
+}
+
+// shutdown stops the outbox relay and webhook dispatcher once no request
+// can emit events any more, then flushes the search index to disk.
+func shutdown(stopWorkers context.CancelFunc, running *sync.WaitGroup, closeRelay func()) {
+	stopWorkers()
+	running.Wait()
+	closeRelay()
+	if err := searchindex.CloseUsers(); err != nil {
+		log.Printf("failed to close user index: %v", err)
+	}
+}
+
+// healthz answers 200 while the primary and replicas respond and 503
+// otherwise.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := database.Health(r.Context()); err != nil {
+		log.Printf("health check failed: %v", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"unavailable"}`))
+		return
 	}
+	w.Write([]byte(`{"status":"ok"}`))
 }
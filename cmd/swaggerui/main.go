@@ -0,0 +1,112 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/delivery/openapi"
+)
+
+// swaggerui vendors the Swagger UI assets of the docs page. Run it from the
+// module root after changing openapi.SwaggerUIVersion and commit the files
+// it writes.
+func main() {
+	out := flag.String("o", openapi.SwaggerUIDir, "directory to write")
+	registry := flag.String("registry", "https://registry.npmjs.org", "npm registry")
+	flag.Parse()
+
+	client := &http.Client{Timeout: time.Minute}
+
+	var release struct {
+		Dist struct {
+			Tarball   string `json:"tarball"`
+			Integrity string `json:"integrity"`
+		} `json:"dist"`
+	}
+	meta, err := fetch(client, *registry+"/swagger-ui-dist/"+openapi.SwaggerUIVersion)
+	if err != nil {
+		log.Fatalf("failed to look up swagger-ui-dist %s: %v", openapi.SwaggerUIVersion, err)
+	}
+	if err := json.Unmarshal(meta, &release); err != nil {
+		log.Fatalf("failed to read the release metadata: %v", err)
+	}
+
+	tarball, err := fetch(client, release.Dist.Tarball)
+	if err != nil {
+		log.Fatalf("failed to download %s: %v", release.Dist.Tarball, err)
+	}
+	sum := sha512.Sum512(tarball)
+	if want := "sha512-" + base64.StdEncoding.EncodeToString(sum[:]); want != release.Dist.Integrity {
+		log.Fatalf("%s does not match its integrity %q", release.Dist.Tarball, release.Dist.Integrity)
+	}
+
+	files, err := extract(tarball, openapi.SwaggerUIFiles)
+	if err != nil {
+		log.Fatalf("failed to unpack %s: %v", release.Dist.Tarball, err)
+	}
+	for _, name := range openapi.SwaggerUIFiles {
+		body, ok := files[name]
+		if !ok {
+			log.Fatalf("%s is missing from swagger-ui-dist %s", name, openapi.SwaggerUIVersion)
+		}
+		if err := os.WriteFile(filepath.Join(*out, name), body, 0o644); err != nil {
+			log.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	log.Printf("vendored swagger-ui-dist %s into %s", openapi.SwaggerUIVersion, *out)
+}
+
+func fetch(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// extract returns the named files of the package root in an npm tarball.
+func extract(tarball []byte, names []string) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte, len(names))
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		/* npm tarballs keep the package below "package/". */
+		name, ok := strings.CutPrefix(hdr.Name, "package/")
+		if !ok || !slices.Contains(names, name) {
+			continue
+		}
+		if files[name], err = io.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+}
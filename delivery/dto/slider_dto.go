@@ -0,0 +1,70 @@
+package dto
+
+import "time"
+
+// SliderCreateRequest is read from JSON or from a multipart form. A form
+// may carry the picture as an "image" file part instead of a file URL, but
+// translations are only read from JSON. Times are RFC 3339, and a slider
+// is published unless published is false.
+type SliderCreateRequest struct {
+	Title        string                              `json:"title" form:"title" binding:"required,max=255" validate:"required,max=255"`
+	Description  string                              `json:"description" form:"description" binding:"required" validate:"required"`
+	File         string                              `json:"file" form:"file" binding:"omitempty,max=2048" validate:"omitempty,max=2048"`
+	LinkURL      string                              `json:"link_url" form:"link_url" binding:"omitempty,max=2048" validate:"omitempty,max=2048"`
+	Published    *bool                               `json:"published" form:"published"`
+	StartsAt     *string                             `json:"starts_at" form:"starts_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	EndsAt       *string                             `json:"ends_at" form:"ends_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00,after=starts_at"`
+	Translations map[string]SliderTranslationRequest `json:"translations" form:"-" binding:"omitempty,dive" validate:"omitempty,dive"`
+}
+
+// SliderUpdateRequest changes the fields that are present. An empty
+// link_url removes the link and an empty starts_at or ends_at removes that
+// end of the schedule. translations replaces every translation.
+type SliderUpdateRequest struct {
+	Title        *string                             `json:"title" form:"title" binding:"omitempty,min=1,max=255" validate:"omitempty,min=1,max=255"`
+	Description  *string                             `json:"description" form:"description" binding:"omitempty,min=1" validate:"omitempty,min=1"`
+	File         *string                             `json:"file" form:"file" binding:"omitempty,min=1,max=2048" validate:"omitempty,min=1,max=2048"`
+	LinkURL      *string                             `json:"link_url" form:"link_url" binding:"omitempty,max=2048" validate:"omitempty,max=2048"`
+	StartsAt     *string                             `json:"starts_at" form:"starts_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	EndsAt       *string                             `json:"ends_at" form:"ends_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00,after=starts_at"`
+	Translations map[string]SliderTranslationRequest `json:"translations" form:"-" binding:"omitempty,dive" validate:"omitempty,dive"`
+}
+
+type SliderTranslationRequest struct {
+	Title       string `json:"title" binding:"required,max=255" validate:"required,max=255"`
+	Description string `json:"description"`
+}
+
+// SliderReorderRequest lists every slider ID in the new display order.
+type SliderReorderRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,dive,required" validate:"required,min=1,dive,required"`
+}
+
+type SliderResponse struct {
+	ID           string                               `json:"id"`
+	Title        string                               `json:"title"`
+	Description  string                               `json:"description"`
+	Locale       string                               `json:"locale,omitempty"`
+	File         string                               `json:"file"`
+	Images       []SliderImageResponse                `json:"images,omitempty"`
+	LinkURL      string                               `json:"link_url,omitempty"`
+	Position     int                                  `json:"position"`
+	Published    bool                                 `json:"published"`
+	StartsAt     *time.Time                           `json:"starts_at,omitempty"`
+	EndsAt       *time.Time                           `json:"ends_at,omitempty"`
+	Translations map[string]SliderTranslationResponse `json:"translations,omitempty"`
+	CreatedAt    time.Time                            `json:"created_at"`
+	UpdatedAt    time.Time                            `json:"updated_at"`
+}
+
+// SliderImageResponse is one stored copy of an uploaded slider image.
+type SliderImageResponse struct {
+	Variant string `json:"variant"`
+	Format  string `json:"format"`
+	URL     string `json:"url"`
+}
+
+type SliderTranslationResponse struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
@@ -4,7 +4,7 @@ type UserCreateRequest struct {
 	Name     string `json:"name" binding:"required" validate:"required"`
 	Email    string `json:"email" binding:"required,email" validate:"required,email"`
 	Password string `json:"password" binding:"required,min=8" validate:"required,min=8"`
-	Role     string `json:"role" binding:"required" validate:"required"`
+	Role     string `json:"role" binding:"required" validate:"required,role"`
 }
 
 type UserUpdateRequest struct {
@@ -13,7 +13,8 @@ type UserUpdateRequest struct {
 	Email    *string `json:"email" binding:"omitempty,email" validate:"omitempty,email"`
 	Password *string `json:"password" binding:"omitempty,min=8" validate:"omitempty,min=8"`
 	Active   *bool   `json:"active" binding:"omitempty" validate:"omitempty"`
-	Role     *string `json:"role" binding:"omitempty" validate:"omitempty"`
+	Role     *string `json:"role" binding:"omitempty" validate:"omitempty,role"`
+	Version  *uint   `json:"version" binding:"omitempty,min=1" validate:"omitempty,min=1"`
 }
 
 type UserLoginRequest struct {
@@ -27,4 +28,14 @@ type UserResponse struct {
 	Email  string `json:"email"`
 	Active bool   `json:"active"`
 	Role   string `json:"role"`
+	// Avatars maps avatar sizes such as "small" to URLs. It is left out
+	// for users without an avatar.
+	Avatars map[string]string `json:"avatars,omitempty"`
+	Version uint              `json:"version"`
+}
+
+type UserSearchResponse struct {
+	User       UserResponse      `json:"user"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
 }
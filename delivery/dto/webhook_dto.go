@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+type WebhookEndpointCreateRequest struct {
+	URL         string   `json:"url" binding:"required,url" validate:"required,url"`
+	Description string   `json:"description" binding:"omitempty,max=255" validate:"omitempty,max=255"`
+	Events      []string `json:"events" binding:"required,min=1" validate:"required,min=1"`
+}
+
+type WebhookEndpointUpdateRequest struct {
+	URL         *string  `json:"url" binding:"omitempty,url" validate:"omitempty,url"`
+	Description *string  `json:"description" binding:"omitempty,max=255" validate:"omitempty,max=255"`
+	Events      []string `json:"events" binding:"omitempty,min=1" validate:"omitempty,min=1"`
+	Active      *bool    `json:"active" binding:"omitempty" validate:"omitempty"`
+}
+
+// WebhookEndpointResponse only carries the secret right after the endpoint
+// is created or its secret is rotated.
+type WebhookEndpointResponse struct {
+	ID             string     `json:"id"`
+	OwnerID        string     `json:"owner_id"`
+	URL            string     `json:"url"`
+	Description    string     `json:"description"`
+	Secret         string     `json:"secret,omitempty"`
+	Events         []string   `json:"events"`
+	Active         bool       `json:"active"`
+	FailureCount   int        `json:"failure_count"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string     `json:"id"`
+	EndpointID     string     `json:"endpoint_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type WebhookAttemptResponse struct {
+	ID           string    `json:"id"`
+	Attempt      int       `json:"attempt"`
+	Manual       bool      `json:"manual"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryDetailResponse is a delivery with its payload and the log
+// of every attempt.
+type WebhookDeliveryDetailResponse struct {
+	WebhookDeliveryResponse
+	Payload  string                   `json:"payload"`
+	Attempts []WebhookAttemptResponse `json:"attempt_log"`
+}
@@ -0,0 +1,55 @@
+// Package etag converts entity versions to and from HTTP entity tags so the
+// deliveries can offer conditional updates with If-Match.
+package etag
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/celpung/gocleanarch/application/apperror"
+)
+
+var (
+	// ErrMissing means neither an If-Match header nor a version was sent.
+	ErrMissing = apperror.New(apperror.ErrPreconditionRequired, "If-Match header or version is required")
+	// ErrInvalid means the If-Match header is not a tag issued by Format.
+	ErrInvalid = apperror.New(apperror.ErrInvalid, "invalid If-Match header")
+)
+
+// Format returns the strong entity tag for version, e.g. "3" in quotes.
+func Format(version uint) string {
+	return strconv.Quote(strconv.FormatUint(uint64(version), 10))
+}
+
+// Parse reads an If-Match value produced by Format. Weak tags are accepted
+// since the version identifies the representation either way. "*" matches
+// any version and yields 0.
+func Parse(header string) (uint, error) {
+	h := strings.TrimSpace(header)
+	if h == "*" {
+		return 0, nil
+	}
+	h = strings.TrimPrefix(h, "W/")
+
+	unquoted, err := strconv.Unquote(h)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	v, err := strconv.ParseUint(unquoted, 10, 0)
+	if err != nil || v == 0 {
+		return 0, ErrInvalid
+	}
+	return uint(v), nil
+}
+
+// Resolve returns the version an update is conditional on. The If-Match
+// header wins over the version field of the request body.
+func Resolve(ifMatch string, bodyVersion *uint) (uint, error) {
+	if strings.TrimSpace(ifMatch) != "" {
+		return Parse(ifMatch)
+	}
+	if bodyVersion != nil && *bodyVersion > 0 {
+		return *bodyVersion, nil
+	}
+	return 0, ErrMissing
+}
@@ -0,0 +1,73 @@
+// Package adapter serves httpcore handlers with fiber.
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	"github.com/gofiber/fiber/v2"
+)
+
+type request struct {
+	c *fiber.Ctx
+}
+
+func (r request) Context() context.Context  { return r.c.UserContext() }
+func (r request) Param(name string) string  { return r.c.Params(name) }
+func (r request) Header(name string) string { return r.c.Get(name) }
+func (r request) Body() io.Reader           { return bytes.NewReader(r.c.Body()) }
+
+func (r request) Query(name string) (string, bool) {
+	if !r.c.Context().QueryArgs().Has(name) {
+		return "", false
+	}
+	return r.c.Query(name), true
+}
+
+// MultipartForm relies on the app's BodyLimit to bound the body; fiber has
+// read it in full by now.
+func (r request) MultipartForm(maxBytes int64) (*multipart.Form, error) {
+	return r.c.MultipartForm()
+}
+
+// Handle serves h.
+func Handle(h httpcore.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return Write(c, h(request{c: c}))
+	}
+}
+
+// Write sends res, localized for the request.
+func Write(c *fiber.Ctx, res httpcore.Response) error {
+	res = res.Localize(c.UserContext())
+	for name, value := range res.Headers {
+		c.Set(name, value)
+	}
+
+	body, err := res.Encode()
+	if err != nil {
+		return err
+	}
+	c.Status(res.Status)
+	if body == nil {
+		return nil
+	}
+	c.Set(fiber.HeaderContentType, res.MediaType())
+	return c.Send(body)
+}
+
+// Problem answers the problem p.
+func Problem(c *fiber.Ctx, p problem.Problem) error {
+	return Write(c, httpcore.Fail(p))
+}
+
+// Register adds routes to r.
+func Register(r fiber.Router, routes []httpcore.Route) {
+	for _, route := range routes {
+		r.Add(route.Method, httpcore.ColonPath(route.Path), Handle(route.Serve))
+	}
+}
@@ -1,127 +1,45 @@
 package middleware
 
 import (
-	"errors"
-	"strings"
-	"time"
-
-	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/delivery/fiber/adapter"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-type Role string
+type Role = httpcore.Role
 
 const (
-	Super Role = "SUPER"
-	Admin Role = "ADMIN"
-	User  Role = "USER"
+	Super = httpcore.Super
+	Admin = httpcore.Admin
+	User  = httpcore.User
 )
 
+// AuthMiddleware lets requests through whose bearer token carries one of
+// allowedRoles, or any role when none are given. The caller is available
+// through the helpers below and, for use cases, in the user context.
 func AuthMiddleware(allowedRoles ...Role) fiber.Handler {
-	secret := []byte(environment.Env.JWT_SECRET)
-
 	return func(c *fiber.Ctx) error {
-		tokenString, err := getBearerTokenFiber(c)
+		principal, err := httpcore.Authenticate(c.Get("Authorization"), allowedRoles...)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Unauthorized",
-			})
-		}
-
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Unauthorized",
-			})
+			return adapter.Write(c, httpcore.Denied(err))
 		}
 
-		if v, ok := claims["exp"].(float64); ok {
-			if time.Now().After(time.Unix(int64(v), 0)) {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"success": false,
-					"message": "Token expired",
-				})
-			}
+		if principal.UserID != "" {
+			c.Locals("userID", principal.UserID)
 		}
-		if v, ok := claims["nbf"].(float64); ok {
-			if time.Now().Before(time.Unix(int64(v), 0)) {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"success": false,
-					"message": "Token not valid yet",
-				})
-			}
+		if principal.Email != "" {
+			c.Locals("email", principal.Email)
 		}
+		c.Locals("role", principal.Role)
 
-		userRole := extractRoleString(claims["role"])
-		if len(allowedRoles) > 0 {
-			authorized := false
-			for _, r := range allowedRoles {
-				if strings.EqualFold(userRole, string(r)) {
-					authorized = true
-					break
-				}
-			}
-			if !authorized {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"success": false,
-					"message": "Forbidden access!",
-				})
-			}
-		}
-
-		if idStr, ok := claims["id"].(string); ok {
-			c.Locals("userID", idStr)
-		}
-		if emailStr, ok := claims["email"].(string); ok {
-			c.Locals("email", emailStr)
-		}
-		c.Locals("role", strings.ToUpper(userRole))
+		// expose the principal to use cases through the user context
+		c.SetUserContext(requestctx.WithPrincipal(c.UserContext(), principal))
 
 		return c.Next()
 	}
 }
 
-func getBearerTokenFiber(c *fiber.Ctx) (string, error) {
-	h := strings.TrimSpace(c.Get("Authorization"))
-	if h == "" {
-		return "", errors.New("missing Authorization header")
-	}
-	parts := strings.Fields(h) // "Bearer <token>"
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return "", errors.New("invalid Authorization format")
-	}
-	return parts[1], nil
-}
-
-func extractRoleString(v any) string {
-	switch r := v.(type) {
-	case string:
-		return strings.ToUpper(strings.TrimSpace(r))
-	case float64:
-		switch int(r) {
-		case 1:
-			return string(User)
-		case 2:
-			return string(Admin)
-		case 3:
-			return string(Super)
-		default:
-			return ""
-		}
-	default:
-		return ""
-	}
-}
-
 func UserFromFiberCtx(c *fiber.Ctx) (id, email string, role Role, ok bool) {
 	idVal := c.Locals("userID")
 	emVal := c.Locals("email")
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocaleMiddleware picks the response language from Accept-Language, names
+// it in Content-Language and stores it in the user context.
+func LocaleMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale := i18n.Negotiate(c.Get(i18n.Header))
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(i18n.Header)
+		c.SetUserContext(i18n.WithLocale(c.UserContext(), locale))
+		return c.Next()
+	}
+}
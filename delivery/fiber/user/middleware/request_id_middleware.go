@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestIDMiddleware reuses the caller's X-Request-ID or generates one,
+// echoes it in the response and stores it in the user context.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := requestctx.RequestID(c.Get(requestctx.Header))
+		c.Set(requestctx.Header, id)
+		c.SetUserContext(requestctx.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
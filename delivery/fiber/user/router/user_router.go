@@ -1,27 +1,47 @@
 package user_router
 
 import (
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
 	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
 	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
-	delivery_impl "github.com/celpung/gocleanarch/delivery/fiber/user/impl"
-	middleware "github.com/celpung/gocleanarch/delivery/fiber/user/middleware"
+	"github.com/celpung/gocleanarch/delivery/fiber/adapter"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
 	"github.com/celpung/gocleanarch/infrastructure/auth"
-	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
 	"github.com/gofiber/fiber/v2"
 )
 
 func RegisterUserRouter(router fiber.Router) {
 	passwordService := auth.NewPasswordService()
 	jwtService := auth.NewJwtService()
-	repo := repository_impl.NewUserRepository(mysql.DB)
-	usecase := usecase_impl.NewUserUsecase(repo, passwordService, jwtService)
-	delivery := delivery_impl.NewUserDelivery(usecase)
+	repo := repository_impl.NewCachedUserRepository(repository_impl.NewUserRepository(database.DB), cache_impl.Shared, cache_impl.TTL)
+	searcher := search_impl.NewUserSearcher(database.DB)
+	index := index_impl.NewUserIndex(searchindex.Users)
+	events := event_impl.NewInProcessPublisher(index)
+	userOutbox := event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(database.DB))
+	unitOfWork := uow_impl.NewGormUnitOfWork(database.DB)
+	usecase := usecase_impl.NewUserUsecase(usecase_impl.UserUsecaseDeps{
+		Repo:            repo,
+		Searcher:        searcher,
+		Index:           index,
+		Events:          events,
+		Outbox:          userOutbox,
+		UoW:             unitOfWork,
+		PasswordService: passwordService,
+		JWTService:      jwtService,
+		Storage:         storage_impl.Shared,
+		Inspector:       checker.Shared,
+		MaxAvatarSize:   storage_impl.MaxUploadSize,
+	})
+	handlers := httpcore.NewUserHandlers(usecase, storage_impl.MaxUploadSize)
 
-	user := router.Group("/users")
-	user.Post("/register", delivery.Register)
-	user.Post("/login", delivery.Login)
-	user.Get("/", middleware.AuthMiddleware(middleware.Admin, middleware.Super), delivery.GetAllUserData)
-	user.Get("/search", middleware.AuthMiddleware(middleware.Admin), delivery.SearchUser)
-	user.Patch("/", middleware.AuthMiddleware(middleware.Admin), delivery.UpdateUser)
-	user.Delete("/:id", middleware.AuthMiddleware(middleware.Admin), delivery.DeleteUser)
+	adapter.Register(router, handlers.Routes())
 }
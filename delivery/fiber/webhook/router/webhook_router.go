@@ -0,0 +1,16 @@
+package webhook_router
+
+import (
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	"github.com/celpung/gocleanarch/delivery/fiber/adapter"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterWebhookRouter serves the webhook API to staff with the use case
+// set up by dispatcher_impl.ConnectWebhooks.
+func RegisterWebhookRouter(router fiber.Router) {
+	handlers := httpcore.NewWebhookHandlers(dispatcher_impl.Shared)
+
+	adapter.Register(router, handlers.Routes())
+}
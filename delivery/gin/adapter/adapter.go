@@ -0,0 +1,66 @@
+// Package adapter serves httpcore handlers with gin.
+package adapter
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	"github.com/gin-gonic/gin"
+)
+
+type request struct {
+	c *gin.Context
+}
+
+func (r request) Context() context.Context         { return r.c.Request.Context() }
+func (r request) Param(name string) string         { return r.c.Param(name) }
+func (r request) Query(name string) (string, bool) { return r.c.GetQuery(name) }
+func (r request) Header(name string) string        { return r.c.GetHeader(name) }
+func (r request) Body() io.Reader                  { return r.c.Request.Body }
+
+func (r request) MultipartForm(maxBytes int64) (*multipart.Form, error) {
+	r.c.Request.Body = http.MaxBytesReader(r.c.Writer, r.c.Request.Body, maxBytes)
+	return r.c.MultipartForm()
+}
+
+// Handle serves h.
+func Handle(h httpcore.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Write(c, h(request{c: c}))
+	}
+}
+
+// Write sends res, localized for the request.
+func Write(c *gin.Context, res httpcore.Response) {
+	res = res.Localize(c.Request.Context())
+	for name, value := range res.Headers {
+		c.Header(name, value)
+	}
+
+	body, err := res.Encode()
+	if err != nil {
+		Write(c, httpcore.Fail(problem.New(http.StatusInternalServerError, "Failed to encode response", err)))
+		return
+	}
+	if body == nil {
+		c.Status(res.Status)
+		return
+	}
+	c.Data(res.Status, res.MediaType(), body)
+}
+
+// Problem answers the problem p.
+func Problem(c *gin.Context, p problem.Problem) {
+	Write(c, httpcore.Fail(p))
+}
+
+// Register adds routes to r.
+func Register(r gin.IRoutes, routes []httpcore.Route) {
+	for _, route := range routes {
+		r.Handle(route.Method, httpcore.ColonPath(route.Path), Handle(route.Serve))
+	}
+}
@@ -1,119 +1,47 @@
 package middleware
 
 import (
-	"errors"
-	"net/http"
-	"strings"
-	"time"
-
-	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/delivery/gin/adapter"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-type Role string
+type Role = httpcore.Role
 
 const (
-	Super Role = "SUPER"
-	Admin Role = "ADMIN"
-	User  Role = "USER"
+	Super = httpcore.Super
+	Admin = httpcore.Admin
+	User  = httpcore.User
 )
 
+// AuthMiddleware lets requests through whose bearer token carries one of
+// allowedRoles, or any role when none are given. The caller is available
+// through the helpers below and, for use cases, in the request context.
 func AuthMiddleware(allowedRoles ...Role) gin.HandlerFunc {
-	secret := []byte(environment.Env.JWT_SECRET)
-
 	return func(c *gin.Context) {
-		tokenString, err := getBearerTokenGin(c)
+		principal, err := httpcore.Authenticate(c.GetHeader("Authorization"), allowedRoles...)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Unauthorized"})
-			return
-		}
-
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Unauthorized"})
+			adapter.Write(c, httpcore.Denied(err))
+			c.Abort()
 			return
 		}
 
-		if v, ok := claims["exp"].(float64); ok {
-			if time.Now().After(time.Unix(int64(v), 0)) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Token expired"})
-				return
-			}
+		if principal.UserID != "" {
+			c.Set("userID", principal.UserID)
 		}
-		if v, ok := claims["nbf"].(float64); ok {
-			if time.Now().Before(time.Unix(int64(v), 0)) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Token not valid yet"})
-				return
-			}
+		if principal.Email != "" {
+			c.Set("email", principal.Email)
 		}
+		c.Set("role", principal.Role)
 
-		userRole := extractRoleString(claims["role"])
-		if len(allowedRoles) > 0 {
-			ok := false
-			for _, r := range allowedRoles {
-				if strings.EqualFold(userRole, string(r)) {
-					ok = true
-					break
-				}
-			}
-			if !ok {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "message": "Forbidden access!"})
-				return
-			}
-		}
-
-		if idStr, ok := claims["id"].(string); ok {
-			c.Set("userID", idStr)
-		}
-		if emailStr, ok := claims["email"].(string); ok {
-			c.Set("email", emailStr)
-		}
-		c.Set("role", strings.ToUpper(userRole))
+		// expose the principal to use cases through the request context
+		c.Request = c.Request.WithContext(requestctx.WithPrincipal(c.Request.Context(), principal))
 
-		// 6) Lanjut
 		c.Next()
 	}
 }
 
-func getBearerTokenGin(c *gin.Context) (string, error) {
-	h := strings.TrimSpace(c.GetHeader("Authorization"))
-	if h == "" {
-		return "", errors.New("missing Authorization header")
-	}
-	parts := strings.Fields(h) // "Bearer <token>"
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return "", errors.New("invalid Authorization format")
-	}
-	return parts[1], nil
-}
-
-func extractRoleString(v any) string {
-	switch r := v.(type) {
-	case string:
-		return strings.ToUpper(strings.TrimSpace(r))
-	case float64:
-		switch int(r) {
-		case 1:
-			return string(User)
-		case 2:
-			return string(Admin)
-		case 3:
-			return string(Super)
-		default:
-			return ""
-		}
-	default:
-		return ""
-	}
-}
-
 func UserFromGinContext(c *gin.Context) (id, email string, role Role, ok bool) {
 	idVal, ok1 := c.Get("userID")
 	emVal, ok2 := c.Get("email")
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware picks the response language from Accept-Language, names
+// it in Content-Language and stores it in the request context.
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader(i18n.Header))
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", i18n.Header)
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+		c.Next()
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDMiddleware reuses the caller's X-Request-ID or generates one,
+// echoes it in the response and stores it in the request context.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := requestctx.RequestID(c.GetHeader(requestctx.Header))
+		c.Header(requestctx.Header, id)
+		c.Request = c.Request.WithContext(requestctx.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
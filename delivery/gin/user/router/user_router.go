@@ -1,12 +1,21 @@
 package user_router
 
 import (
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
 	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
 	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
-	delivery_impl "github.com/celpung/gocleanarch/delivery/gin/user/impl"
-	"github.com/celpung/gocleanarch/delivery/gin/user/middleware"
+	"github.com/celpung/gocleanarch/delivery/gin/adapter"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
 	"github.com/celpung/gocleanarch/infrastructure/auth"
-	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,17 +23,26 @@ func Router(r *gin.RouterGroup) {
 	passwordService := auth.NewPasswordService()
 	jwtService := auth.NewJwtService()
 
-	repository := repository_impl.NewUserRepository(mysql.DB)
-	usecase := usecase_impl.NewUserUsecase(repository, passwordService, jwtService)
-	delivery := delivery_impl.NewUserDelivery(usecase)
+	repository := repository_impl.NewCachedUserRepository(repository_impl.NewUserRepository(database.DB), cache_impl.Shared, cache_impl.TTL)
+	searcher := search_impl.NewUserSearcher(database.DB)
+	index := index_impl.NewUserIndex(searchindex.Users)
+	events := event_impl.NewInProcessPublisher(index)
+	userOutbox := event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(database.DB))
+	unitOfWork := uow_impl.NewGormUnitOfWork(database.DB)
+	usecase := usecase_impl.NewUserUsecase(usecase_impl.UserUsecaseDeps{
+		Repo:            repository,
+		Searcher:        searcher,
+		Index:           index,
+		Events:          events,
+		Outbox:          userOutbox,
+		UoW:             unitOfWork,
+		PasswordService: passwordService,
+		JWTService:      jwtService,
+		Storage:         storage_impl.Shared,
+		Inspector:       checker.Shared,
+		MaxAvatarSize:   storage_impl.MaxUploadSize,
+	})
+	handlers := httpcore.NewUserHandlers(usecase, storage_impl.MaxUploadSize)
 
-	routes := r.Group("/users")
-	{
-		routes.POST("/register", delivery.Register)
-		routes.POST("/login", delivery.Login)
-		routes.GET("", middleware.AuthMiddleware(middleware.Admin, middleware.Super), delivery.GetAllUserData)
-		routes.GET("/search", middleware.AuthMiddleware(middleware.Admin), delivery.SearchUser)
-		routes.PATCH("", middleware.AuthMiddleware(middleware.User), delivery.UpdateUser)
-		routes.DELETE("/:id", middleware.AuthMiddleware(middleware.User), delivery.DeleteUser)
-	}
+	adapter.Register(r, handlers.Routes())
 }
@@ -0,0 +1,16 @@
+package webhook_router
+
+import (
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	"github.com/celpung/gocleanarch/delivery/gin/adapter"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/gin-gonic/gin"
+)
+
+// Router serves the webhook API to staff with the use case set up by
+// dispatcher_impl.ConnectWebhooks.
+func Router(r *gin.RouterGroup) {
+	handlers := httpcore.NewWebhookHandlers(dispatcher_impl.Shared)
+
+	adapter.Register(r, handlers.Routes())
+}
@@ -0,0 +1,155 @@
+package httpcore
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/delivery/openapi"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	"github.com/celpung/gocleanarch/infrastructure/validation"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type Role string
+
+const (
+	Super Role = "SUPER"
+	Admin Role = "ADMIN"
+	User  Role = "USER"
+)
+
+// roles lists every role a user may have.
+var roles = []Role{Super, Admin, User}
+
+// staff may manage other users.
+var staff = []Role{Admin, Super}
+
+// RegisterRules adds the validation rules the request DTOs use beyond the
+// built-in ones, and describes them in the OpenAPI document: role checks
+// role names, e.g. `validate:"required,role"`. Servers and cmd/openapi call
+// it during start-up, before handling requests or building Spec.
+func RegisterRules() error {
+	err := validation.Register("role", func(fl validator.FieldLevel) bool {
+		return hasRole(roles, roleOf(fl.Field().String()))
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = string(r)
+	}
+	openapi.DescribeRule("role", func(s *openapi.Schema, _ string) { s.Enum = names })
+	return nil
+}
+
+var (
+	// ErrUnauthorized means the request has no usable bearer token.
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTokenNotValidYet means the token's nbf is in the future.
+	ErrTokenNotValidYet = errors.New("token not valid yet")
+	// ErrForbidden means the token is valid but its role is not allowed.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Authenticate checks the bearer token in authorization, the value of an
+// Authorization header, and that its role is one of roles. Any role is
+// accepted when roles is empty.
+func Authenticate(authorization string, roles ...Role) (requestctx.Principal, error) {
+	parts := strings.Fields(authorization)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return requestctx.Principal{}, ErrUnauthorized
+	}
+
+	/* exp and nbf are checked below, to answer with the reason. */
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(parts[1], claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(environment.Env.JWT_SECRET), nil
+	})
+	if err != nil || !token.Valid {
+		return requestctx.Principal{}, ErrUnauthorized
+	}
+
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Unix(), false) {
+		return requestctx.Principal{}, ErrTokenExpired
+	}
+	if !claims.VerifyNotBefore(now.Unix(), false) {
+		return requestctx.Principal{}, ErrTokenNotValidYet
+	}
+
+	role := roleOf(claims["role"])
+	if len(roles) > 0 && !hasRole(roles, role) {
+		return requestctx.Principal{}, ErrForbidden
+	}
+
+	id, _ := claims["id"].(string)
+	email, _ := claims["email"].(string)
+	return requestctx.Principal{UserID: id, Email: email, Role: string(role)}, nil
+}
+
+// Denied is the response to an error returned by Authenticate.
+func Denied(err error) Response {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		return Fail(problem.New(http.StatusForbidden, "Forbidden", nil))
+	case errors.Is(err, ErrTokenExpired):
+		return Fail(problem.New(http.StatusUnauthorized, "Token expired", nil))
+	case errors.Is(err, ErrTokenNotValidYet):
+		return Fail(problem.New(http.StatusUnauthorized, "Token not valid yet", nil))
+	default:
+		return Fail(problem.New(http.StatusUnauthorized, "Unauthorized", nil))
+	}
+}
+
+// roleOf reads the role claim, either a name or the numeric codes older
+// tokens carry.
+func roleOf(v any) Role {
+	switch r := v.(type) {
+	case string:
+		return Role(strings.ToUpper(strings.TrimSpace(r)))
+	case float64:
+		switch int(r) {
+		case 1:
+			return User
+		case 2:
+			return Admin
+		case 3:
+			return Super
+		}
+	}
+	return ""
+}
+
+// rank orders roles by privilege, USER lowest; unknown roles rank 0.
+func rank(role Role) int {
+	switch role {
+	case User:
+		return 1
+	case Admin:
+		return 2
+	case Super:
+		return 3
+	}
+	return 0
+}
+
+func hasRole(roles []Role, role Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package httpcore
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+
+	"github.com/celpung/gocleanarch/delivery/openapi"
+	"github.com/celpung/gocleanarch/delivery/problem"
+)
+
+// Spec is the OpenAPI document of the API for servers that mount it at
+// basePath, e.g. "/api". It is built from the same route tables the
+// servers register; call RegisterRules first so the role rule is described.
+func Spec(basePath string) *openapi.Document {
+	endpoints := Endpoints("Users", (&UserHandlers{}).Routes())
+	endpoints = append(endpoints, Endpoints("Sliders", (&SliderHandlers{}).Routes())...)
+	endpoints = append(endpoints, Endpoints("Webhooks", (&WebhookHandlers{}).Routes())...)
+	return openapi.Build(basePath, endpoints)
+}
+
+// Endpoints documents routes under tag.
+func Endpoints(tag string, routes []Route) []openapi.Endpoint {
+	endpoints := make([]openapi.Endpoint, 0, len(routes))
+	for _, rt := range routes {
+		var names []string
+		for _, r := range rt.Roles {
+			names = append(names, string(r))
+		}
+		endpoints = append(endpoints, openapi.Endpoint{Method: rt.Method, Path: rt.Path, Tag: tag, Roles: names, Doc: rt.Doc})
+	}
+	return endpoints
+}
+
+// DocsRoutes serves Spec(basePath) at /openapi.json and a Swagger UI for it
+// at /docs, with its vendored assets below /docs/. Mount them at the root,
+// whatever the base path of the API.
+func DocsRoutes(basePath string) []Route {
+	spec := Spec(basePath)
+
+	return []Route{
+		{Method: http.MethodGet, Path: "/openapi.json", Handler: func(Request) Response {
+			return JSON(http.StatusOK, spec)
+		}},
+		{Method: http.MethodGet, Path: "/docs", Handler: func(Request) Response {
+			return Response{Status: http.StatusOK, ContentType: "text/html; charset=utf-8", Body: openapi.SwaggerUI}
+		}},
+		{Method: http.MethodGet, Path: "/docs/{file}", Handler: swaggerAsset},
+	}
+}
+
+// swaggerAsset serves one of openapi.SwaggerUIFiles.
+func swaggerAsset(req Request) Response {
+	name := req.Param("file")
+	if openapi.SwaggerUIAssets == nil || !slices.Contains(openapi.SwaggerUIFiles, name) {
+		return Fail(problem.New(http.StatusNotFound, "Not found", nil))
+	}
+	body, err := fs.ReadFile(openapi.SwaggerUIAssets, name)
+	if err != nil {
+		return Fail(problem.New(http.StatusNotFound, "Not found", nil))
+	}
+	return Response{Status: http.StatusOK, ContentType: mime.TypeByExtension(path.Ext(name)), Body: body}
+}
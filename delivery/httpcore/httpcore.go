@@ -0,0 +1,156 @@
+// Package httpcore holds the HTTP handlers shared by every delivery. Handlers
+// read a Request and return a Response; the adapters next to each framework
+// (delivery/gin/adapter, delivery/fiber/adapter, delivery/std/chi/adapter and
+// delivery/std/http/adapter) translate them to and from gin, fiber, chi and
+// net/http, so routes, roles, validation, status codes and bodies are the
+// same whichever framework serves them.
+package httpcore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+
+	"github.com/celpung/gocleanarch/delivery/openapi"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+)
+
+// ContentType is sent with every JSON response.
+const ContentType = "application/json; charset=utf-8"
+
+// Request is the part of an HTTP request the handlers use.
+type Request interface {
+	Context() context.Context
+	// Param returns the path parameter name, e.g. "id" in "/users/{id}".
+	Param(name string) string
+	// Query returns the query parameter name and whether it was sent.
+	Query(name string) (string, bool)
+	Header(name string) string
+	Body() io.Reader
+	// MultipartForm parses a multipart body of at most maxBytes bytes.
+	MultipartForm(maxBytes int64) (*multipart.Form, error)
+}
+
+// FormFile returns the file part name of a multipart body of at most
+// maxBytes bytes.
+func FormFile(req Request, name string, maxBytes int64) (*multipart.FileHeader, error) {
+	form, err := req.MultipartForm(maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	headers := form.File[name]
+	if len(headers) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return headers[0], nil
+}
+
+// LimitBody returns the body of req, failing with *http.MaxBytesError
+// after maxBytes bytes.
+func LimitBody(req Request, maxBytes int64) io.Reader {
+	return http.MaxBytesReader(nil, io.NopCloser(req.Body()), maxBytes)
+}
+
+// Response is what a handler answers. A nil Body sends no content and a
+// []byte one is sent as is; ContentType defaults to JSON.
+type Response struct {
+	Status      int
+	Headers     map[string]string
+	ContentType string
+	Body        any
+}
+
+// Handler serves one route.
+type Handler func(Request) Response
+
+// JSON answers body with status.
+func JSON(status int, body any) Response {
+	return Response{Status: status, Body: body}
+}
+
+// Fail answers the problem p.
+func Fail(p problem.Problem) Response {
+	return Response{Status: p.Status, ContentType: problem.ContentType, Body: p}
+}
+
+// WithHeader returns r with the header name set to value.
+func (r Response) WithHeader(name, value string) Response {
+	headers := make(map[string]string, len(r.Headers)+1)
+	for k, v := range r.Headers {
+		headers[k] = v
+	}
+	headers[name] = value
+	r.Headers = headers
+	return r
+}
+
+// Localize returns r with a problem body localized for ctx.
+func (r Response) Localize(ctx context.Context) Response {
+	if p, ok := r.Body.(problem.Problem); ok {
+		r.Body = p.Localize(ctx)
+	}
+	return r
+}
+
+// MediaType is the content type of r's body.
+func (r Response) MediaType() string {
+	if r.ContentType != "" {
+		return r.ContentType
+	}
+	return ContentType
+}
+
+// Encode returns the body of r, or nil when it has none.
+func (r Response) Encode() ([]byte, error) {
+	switch body := r.Body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return body, nil
+	}
+	return json.Marshal(r.Body)
+}
+
+// Route is one endpoint. Path is absolute and names parameters in braces,
+// the net/http and chi syntax; ColonPath converts it for gin and fiber.
+// Roles lists who may call it; nil means anyone, signed in or not. Doc
+// describes it in the OpenAPI document.
+type Route struct {
+	Method  string
+	Path    string
+	Roles   []Role
+	Handler Handler
+	Doc     openapi.Doc
+}
+
+// Serve authenticates the caller when the route needs it and runs its
+// handler with the principal in the request context.
+func (rt Route) Serve(req Request) Response {
+	if rt.Roles == nil {
+		return rt.Handler(req)
+	}
+
+	principal, err := Authenticate(req.Header("Authorization"), rt.Roles...)
+	if err != nil {
+		return Denied(err)
+	}
+	return rt.Handler(withContext{Request: req, ctx: requestctx.WithPrincipal(req.Context(), principal)})
+}
+
+type withContext struct {
+	Request
+	ctx context.Context
+}
+
+func (r withContext) Context() context.Context { return r.ctx }
+
+var braces = regexp.MustCompile(`\{([^}]+)\}`)
+
+// ColonPath rewrites "/users/{id}" as "/users/:id".
+func ColonPath(path string) string {
+	return braces.ReplaceAllString(path, ":$1")
+}
@@ -0,0 +1,545 @@
+package httpcore_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/celpung/gocleanarch/application/apperror"
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/usecase"
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
+	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
+	webhook_repository_impl "github.com/celpung/gocleanarch/application/webhook/impl/repository"
+	sender_impl "github.com/celpung/gocleanarch/application/webhook/impl/sender"
+	webhook_usecase_impl "github.com/celpung/gocleanarch/application/webhook/impl/usecase"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/internal/frameworks"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	http_middleware "github.com/celpung/gocleanarch/delivery/std/http/user/middleware"
+	"github.com/celpung/gocleanarch/infrastructure/auth"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	"github.com/celpung/gocleanarch/infrastructure/webhook"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide (Windows / macOS / Linux)
+
+1) Run all tests in this package from the folder containing this file:
+     go test -v .
+
+2) Run a specific test using a regex:
+     go test -v -run ^TestHTTPDelivery_ .
+
+Notes:
+- Every framework gets its own SQLite database, so the same script of
+  requests starts from the same state on each of them.
+- Responses are compared on status, content type, language, ETag presence,
+  problem type, message (the title of problem details) and validation
+  errors; IDs and tokens differ between runs by design.
+===============================================================================
+*/
+
+const testAvatarLimit = 1 << 20
+
+/* TestMain registers the validation rules the request DTOs use, as the servers do on start-up. */
+func TestMain(m *testing.M) {
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err, "failed to open in-memory SQLite database")
+
+	/* Every new connection to ":memory:" opens an empty database, so keep a single connection that transactions and plain queries share. */
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	require.NoError(t, migration.Migrate(context.Background(), db), "failed to migrate schema")
+	return db
+}
+
+// newUserUsecase is the user use case on a fresh database, with avatars
+// stored in memory.
+func newUserUsecase(t *testing.T) usecase.UserUsecase {
+	t.Helper()
+
+	db := setupTestDB(t)
+	return usecase_impl.NewUserUsecase(usecase_impl.UserUsecaseDeps{
+		Repo:            repository_impl.NewUserRepository(db),
+		Searcher:        search_impl.NewUserSearcher(db),
+		PasswordService: &auth.PasswordService{},
+		JWTService:      &auth.JwtService{},
+		Storage:         storage_impl.NewMemoryStorage("/files"),
+		Inspector:       checker.NewInspector(nil),
+		MaxAvatarSize:   testAvatarLimit,
+	})
+}
+
+func makeEntityUser(name, email, plainPassword, role string, active bool) *entity.User {
+	return &entity.User{Name: name, Email: email, Password: plainPassword, Role: role, Active: active}
+}
+
+// outcome is the part of a response that must not depend on the framework.
+type outcome struct {
+	Status      int
+	ContentType string
+	Language    string
+	ETag        bool
+	Type        string
+	Message     string
+	Errors      []apperror.FieldError
+}
+
+// step is one request of the script; it may read IDs and tokens from the
+// fixture it runs against.
+type step struct {
+	name    string
+	request func(f *deliveryFixture) *http.Request
+	want    int
+}
+
+type deliveryFixture struct {
+	userID, adminID, superID          string
+	userToken, adminToken, superToken string
+	expiredToken                      string
+}
+
+func jsonRequest(method, target, token, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// deliveryScript covers public, staff and signed in routes, the auth
+// failures and request validation.
+var deliveryScript = []step{
+	{"register malformed", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPost, "/users/register", "", `{"name":`)
+	}, http.StatusBadRequest},
+	{"register invalid", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPost, "/users/register", "", `{"name":"Eve","email":"nope","password":"secret123","role":"USER"}`)
+	}, http.StatusBadRequest},
+	{"register invalid in Indonesian", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPost, "/users/register", "", `{"name":"Eve","email":"nope","password":"short"}`)
+		req.Header.Set("Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+		return req
+	}, http.StatusBadRequest},
+	{"register", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPost, "/users/register", "", `{"name":"Eve","email":"eve@ex.com","password":"secret123","role":"USER"}`)
+	}, http.StatusCreated},
+	{"register duplicate", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPost, "/users/register", "", `{"name":"Eve","email":"eve@ex.com","password":"secret123","role":"USER"}`)
+	}, http.StatusConflict},
+	{"login wrong password", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPost, "/users/login", "", `{"email":"eve@ex.com","password":"wrongpass"}`)
+	}, http.StatusUnauthorized},
+	{"login inactive", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPost, "/users/login", "", `{"email":"eve@ex.com","password":"secret123"}`)
+	}, http.StatusForbidden},
+	{"login", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPost, "/users/login", "", `{"email":"dana@ex.com","password":"secret123"}`)
+	}, http.StatusOK},
+	{"list anonymous", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users", "", "")
+	}, http.StatusUnauthorized},
+	{"list expired token", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users", f.expiredToken, "")
+	}, http.StatusUnauthorized},
+	{"list as user", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users", f.userToken, "")
+	}, http.StatusForbidden},
+	{"list", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users?page=1&limit=2", f.adminToken, "")
+	}, http.StatusOK},
+	{"list bad page", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users?page=0", f.adminToken, "")
+	}, http.StatusBadRequest},
+	{"list by cursor", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users?cursor=&limit=2", f.adminToken, "")
+	}, http.StatusOK},
+	{"list bad cursor", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users?cursor=garbage", f.adminToken, "")
+	}, http.StatusBadRequest},
+	{"search", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodGet, "/users/search?q=eve", f.adminToken, "")
+	}, http.StatusOK},
+	{"update without precondition", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodPatch, "/users", f.userToken, `{"id":"`+f.userID+`","name":"Dan"}`)
+	}, http.StatusPreconditionRequired},
+	{"update stale", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.userToken, `{"id":"`+f.userID+`","name":"Dan"}`)
+		req.Header.Set("If-Match", `"9"`)
+		return req
+	}, http.StatusPreconditionFailed},
+	{"update", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.userToken, `{"id":"`+f.userID+`","name":"Dan"}`)
+		req.Header.Set("If-Match", `"1"`)
+		return req
+	}, http.StatusOK},
+	{"update someone else as user", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.userToken, `{"id":"`+f.adminID+`","name":"Mallory"}`)
+		req.Header.Set("If-Match", `"1"`)
+		return req
+	}, http.StatusForbidden},
+	{"update own role as user", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.userToken, `{"id":"`+f.userID+`","role":"SUPER"}`)
+		req.Header.Set("If-Match", `"2"`)
+		return req
+	}, http.StatusForbidden},
+	{"update someone else as admin", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.adminToken, `{"id":"`+f.userID+`","active":true}`)
+		req.Header.Set("If-Match", `"2"`)
+		return req
+	}, http.StatusOK},
+	{"update role as admin", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.adminToken, `{"id":"`+f.userID+`","role":"ADMIN"}`)
+		req.Header.Set("If-Match", `"3"`)
+		return req
+	}, http.StatusForbidden},
+	{"update own role as admin", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.adminToken, `{"id":"`+f.adminID+`","role":"SUPER"}`)
+		req.Header.Set("If-Match", `"1"`)
+		return req
+	}, http.StatusForbidden},
+	{"update super as admin", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.adminToken, `{"id":"`+f.superID+`","active":false}`)
+		req.Header.Set("If-Match", `"1"`)
+		return req
+	}, http.StatusForbidden},
+	{"update role as super", func(f *deliveryFixture) *http.Request {
+		req := jsonRequest(http.MethodPatch, "/users", f.superToken, `{"id":"`+f.userID+`","role":"ADMIN"}`)
+		req.Header.Set("If-Match", `"3"`)
+		return req
+	}, http.StatusOK},
+	{"avatar without file", func(f *deliveryFixture) *http.Request {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		_ = mw.WriteField("note", "no avatar")
+		_ = mw.Close()
+		req := httptest.NewRequest(http.MethodPut, "/users/avatar", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+f.userToken)
+		return req
+	}, http.StatusBadRequest},
+	{"remove avatar", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodDelete, "/users/avatar", f.userToken, "")
+	}, http.StatusOK},
+	{"delete someone else", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodDelete, "/users/"+f.adminID, f.userToken, "")
+	}, http.StatusForbidden},
+	{"delete as admin", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodDelete, "/users/"+f.adminID, f.adminToken, "")
+	}, http.StatusForbidden},
+	{"delete", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodDelete, "/users/"+f.userID, f.userToken, "")
+	}, http.StatusOK},
+	{"delete missing", func(f *deliveryFixture) *http.Request {
+		return jsonRequest(http.MethodDelete, "/users/"+f.userID, f.userToken, "")
+	}, http.StatusNotFound},
+}
+
+// runDeliveryScript serves the user routes with mount on a fresh database
+// and returns the outcome of every step.
+func runDeliveryScript(t *testing.T, mount func([]httpcore.Route) http.Handler) []outcome {
+	t.Helper()
+
+	ctx := context.Background()
+	uc := newUserUsecase(t)
+	handler := mount(httpcore.NewUserHandlers(uc, testAvatarLimit).Routes())
+
+	user, err := uc.Create(ctx, makeEntityUser("Dana", "dana@ex.com", "secret123", "USER", true))
+	require.NoError(t, err)
+	admin, err := uc.Create(ctx, makeEntityUser("Ada", "ada@ex.com", "secret123", "ADMIN", true))
+	require.NoError(t, err)
+
+	super, err := uc.Create(ctx, makeEntityUser("Sam", "sam@ex.com", "secret123", "SUPER", true))
+	require.NoError(t, err)
+
+	f := &deliveryFixture{userID: user.ID, adminID: admin.ID, superID: super.ID}
+	f.userToken, err = auth.NewJwtService().JWTGenerator(*user)
+	require.NoError(t, err)
+	f.adminToken, err = auth.NewJwtService().JWTGenerator(*admin)
+	require.NoError(t, err)
+	f.superToken, err = auth.NewJwtService().JWTGenerator(*super)
+	require.NoError(t, err)
+	f.expiredToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id": admin.ID, "email": admin.Email, "role": admin.Role,
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}).SignedString([]byte(environment.Env.JWT_SECRET))
+	require.NoError(t, err)
+
+	outcomes := make([]outcome, 0, len(deliveryScript))
+	for _, s := range deliveryScript {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, s.request(f))
+
+		var body struct {
+			Type    string                `json:"type"`
+			Title   string                `json:"title"`
+			Message string                `json:"message"`
+			Errors  []apperror.FieldError `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), "%s: %s", s.name, rec.Body.String())
+		require.Equal(t, s.want, rec.Code, "%s: %s", s.name, rec.Body.String())
+
+		outcomes = append(outcomes, outcome{
+			Status:      rec.Code,
+			ContentType: rec.Header().Get("Content-Type"),
+			Language:    rec.Header().Get("Content-Language"),
+			ETag:        rec.Header().Get("ETag") != "",
+			Type:        body.Type,
+			Message:     body.Message + body.Title,
+			Errors:      body.Errors,
+		})
+	}
+	return outcomes
+}
+
+/*
+TestHTTPDelivery_SameBehaviourOnEveryFramework verifies that the shared user
+handlers answer the same script of requests identically on gin, fiber, chi
+and net/http: same routes, roles, validation, status codes and messages.
+*/
+func TestHTTPDelivery_SameBehaviourOnEveryFramework(t *testing.T) {
+	want := runDeliveryScript(t, frameworks.All["net/http"])
+
+	for name, mount := range frameworks.All {
+		t.Run(name, func(t *testing.T) {
+			got := runDeliveryScript(t, mount)
+			for i, s := range deliveryScript {
+				require.Equal(t, want[i], got[i], s.name)
+			}
+		})
+	}
+}
+
+/*
+TestHTTPDelivery_ErrorsAreProblemDetails verifies that failed requests are
+answered with RFC 7807 problem details: a type per error kind, the fields at
+fault for validation errors and no internals for server errors.
+*/
+func TestHTTPDelivery_ErrorsAreProblemDetails(t *testing.T) {
+	ctx := context.Background()
+	uc := newUserUsecase(t)
+	handler := frameworks.All["net/http"](httpcore.NewUserHandlers(uc, testAvatarLimit).Routes())
+
+	_, err := uc.Create(ctx, makeEntityUser("Dana", "dana@ex.com", "secret123", "USER", true))
+	require.NoError(t, err)
+
+	send := func(req *http.Request) problem.Problem {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"), rec.Body.String())
+
+		var p problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		require.Equal(t, rec.Code, p.Status)
+		return p
+	}
+
+	p := send(jsonRequest(http.MethodPost, "/users/register", "", `{"name":"Eve","email":"nope","password":"secret123","role":"USER"}`))
+	require.Equal(t, "/problems/invalid-input", p.Type)
+	require.Equal(t, http.StatusBadRequest, p.Status)
+	require.Equal(t, []apperror.FieldError{
+		{Field: "email", Rule: "email", Message: "Email must be a valid email address"},
+	}, p.Errors)
+
+	p = send(jsonRequest(http.MethodPost, "/users/register", "", `{"name":"Dana","email":"dana@ex.com","password":"secret123","role":"USER"}`))
+	require.Equal(t, "/problems/conflict", p.Type)
+	require.Equal(t, http.StatusConflict, p.Status)
+	require.Equal(t, entity.ErrEmailTaken.Error(), p.Detail)
+
+	p = send(jsonRequest(http.MethodPost, "/users/login", "", `{"email":"nobody@ex.com","password":"secret123"}`))
+	require.Equal(t, "/problems/invalid-credentials", p.Type)
+	require.Equal(t, http.StatusUnauthorized, p.Status)
+
+	p = send(jsonRequest(http.MethodGet, "/users", "", ""))
+	require.Equal(t, "/problems/unauthorized", p.Type)
+
+	/* A token of a user that no longer exists. */
+	gone := makeEntityUser("Gus", "gus@ex.com", "secret123", "USER", true)
+	gone.ID = "00000000-0000-4000-8000-000000000000"
+	goneToken, err := auth.NewJwtService().JWTGenerator(*gone)
+	require.NoError(t, err)
+	p = send(jsonRequest(http.MethodDelete, "/users/"+gone.ID, goneToken, ""))
+	require.Equal(t, "/problems/not-found", p.Type)
+	require.Equal(t, http.StatusNotFound, p.Status)
+	require.Equal(t, entity.ErrUserNotFound.Error(), p.Detail)
+
+	internal := problem.New(http.StatusInternalServerError, "Failed to fetch user data", errors.New("dial tcp: connection refused"))
+	require.Equal(t, "/problems/internal-server-error", internal.Type)
+	require.Empty(t, internal.Detail, "server errors do not leak their cause")
+}
+
+/*
+TestHTTPDelivery_MessagesFollowAcceptLanguage verifies that titles, field
+errors and success messages are answered in the language the client
+prefers, and in English when it prefers none we support.
+*/
+func TestHTTPDelivery_MessagesFollowAcceptLanguage(t *testing.T) {
+	ctx := context.Background()
+	uc := newUserUsecase(t)
+	handler := frameworks.All["net/http"](httpcore.NewUserHandlers(uc, testAvatarLimit).Routes())
+
+	_, err := uc.Create(ctx, makeEntityUser("Dana", "dana@ex.com", "secret123", "USER", true))
+	require.NoError(t, err)
+
+	send := func(req *http.Request, language string) (*httptest.ResponseRecorder, map[string]any) {
+		req.Header.Set("Accept-Language", language)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	rec, body := send(jsonRequest(http.MethodPost, "/users/register", "", `{"name":"Eve","email":"nope","password":"secret123","role":"USER"}`), "id")
+	require.Equal(t, "id", rec.Header().Get("Content-Language"))
+	require.Equal(t, "Validasi gagal", body["title"])
+	require.Equal(t, "Email harus berupa alamat email yang valid", body["errors"].([]any)[0].(map[string]any)["message"])
+
+	_, body = send(jsonRequest(http.MethodPost, "/users/login", "", `{"email":"dana@ex.com","password":"secret123"}`), "id-ID")
+	require.Equal(t, "Login berhasil", body["message"])
+
+	rec, body = send(jsonRequest(http.MethodPost, "/users/login", "", `{"email":"dana@ex.com","password":"secret123"}`), "fr-CA, fr;q=0.9")
+	require.Equal(t, "en", rec.Header().Get("Content-Language"))
+	require.Equal(t, "Login success", body["message"])
+}
+
+/*
+TestHTTPDelivery_AuthenticateRejectsBadTokens verifies the token checks
+shared by the routes and the per framework auth middlewares.
+*/
+func TestHTTPDelivery_AuthenticateRejectsBadTokens(t *testing.T) {
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(environment.Env.JWT_SECRET))
+		require.NoError(t, err)
+		return "Bearer " + token
+	}
+	future := time.Now().Add(time.Hour).Unix()
+
+	p, err := httpcore.Authenticate(sign(jwt.MapClaims{"id": "u1", "email": "a@ex.com", "role": "admin", "exp": future}), httpcore.Admin)
+	require.NoError(t, err)
+	require.Equal(t, "u1", p.UserID)
+	require.Equal(t, string(httpcore.Admin), p.Role)
+
+	_, err = httpcore.Authenticate(sign(jwt.MapClaims{"id": "u1", "role": float64(3), "exp": future}), httpcore.Super)
+	require.NoError(t, err, "numeric roles are still accepted")
+
+	_, err = httpcore.Authenticate("")
+	require.ErrorIs(t, err, httpcore.ErrUnauthorized)
+	_, err = httpcore.Authenticate("Basic abc")
+	require.ErrorIs(t, err, httpcore.ErrUnauthorized)
+	_, err = httpcore.Authenticate(sign(jwt.MapClaims{"role": "USER", "exp": time.Now().Add(-time.Hour).Unix()}))
+	require.ErrorIs(t, err, httpcore.ErrTokenExpired)
+	_, err = httpcore.Authenticate(sign(jwt.MapClaims{"role": "USER", "nbf": future}))
+	require.ErrorIs(t, err, httpcore.ErrTokenNotValidYet)
+	_, err = httpcore.Authenticate(sign(jwt.MapClaims{"role": "USER", "exp": future}), httpcore.Admin, httpcore.Super)
+	require.ErrorIs(t, err, httpcore.ErrForbidden)
+
+	require.Equal(t, http.StatusForbidden, httpcore.Denied(httpcore.ErrForbidden).Status)
+	require.Equal(t, http.StatusUnauthorized, httpcore.Denied(httpcore.ErrTokenExpired).Status)
+}
+
+/*
+TestHTTPDelivery_StdMiddlewareAllowsEveryListedRole verifies that the
+net/http auth middleware, like the other frameworks', accepts each of the
+roles it is given, so SUPER reaches the routes open to ADMIN and SUPER.
+*/
+func TestHTTPDelivery_StdMiddlewareAllowsEveryListedRole(t *testing.T) {
+	handler := http_middleware.AuthMiddleware(http_middleware.Admin, http_middleware.Super)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	future := time.Now().Add(time.Hour).Unix()
+
+	for role, want := range map[string]int{"SUPER": http.StatusNoContent, "ADMIN": http.StatusNoContent, "USER": http.StatusForbidden} {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": "u1", "role": role, "exp": future}).
+			SignedString([]byte(environment.Env.JWT_SECRET))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		handler(rec, jsonRequest(http.MethodGet, "/webhooks", token, ""))
+		require.Equal(t, want, rec.Code, role)
+	}
+}
+
+/*
+TestHTTPDelivery_WebhookRoutesOnEveryFramework verifies that the shared
+webhook handlers are staff only and answer the same statuses on gin, fiber,
+chi and net/http, path parameters included.
+*/
+func TestHTTPDelivery_WebhookRoutesOnEveryFramework(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	token := func(role string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": "u1", "role": role, "exp": future}).
+			SignedString([]byte(environment.Env.JWT_SECRET))
+		require.NoError(t, err)
+		return signed
+	}
+	admin, super, user := token("ADMIN"), token("SUPER"), token("USER")
+
+	for name, mount := range frameworks.All {
+		t.Run(name, func(t *testing.T) {
+			uc := webhook_usecase_impl.NewWebhookUsecase(
+				webhook_repository_impl.NewWebhookRepository(setupTestDB(t)),
+				sender_impl.NewHTTPSender(webhook.NewClient(5*time.Second, true)),
+				nil,
+			)
+			handler := mount(httpcore.NewWebhookHandlers(uc).Routes())
+			do := func(method, target, token, body string) *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, jsonRequest(method, target, token, body))
+				return rec
+			}
+
+			require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/webhooks", "", "").Code)
+			require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/webhooks", user, "").Code)
+			require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/webhooks", admin, `{"url":"https://example.com/hook"}`).Code)
+
+			rec := do(http.MethodPost, "/webhooks", admin, `{"url":"https://example.com/hook","events":["*"]}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			var created struct {
+				Endpoint struct{ ID string } `json:"endpoint"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+			require.Equal(t, http.StatusOK, do(http.MethodGet, "/webhooks/"+created.Endpoint.ID, admin, "").Code)
+			require.Equal(t, http.StatusOK, do(http.MethodGet, "/webhooks", super, "").Code)
+			require.Equal(t, http.StatusOK, do(http.MethodGet, "/webhooks/"+created.Endpoint.ID+"/deliveries?status=failed", admin, "").Code)
+			require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/webhooks/"+created.Endpoint.ID+"/deliveries?page=0", admin, "").Code)
+			require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/webhooks/"+created.Endpoint.ID+"/deliveries/missing", admin, "").Code)
+			require.Equal(t, http.StatusOK, do(http.MethodDelete, "/webhooks/"+created.Endpoint.ID, admin, "").Code)
+			require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/webhooks/"+created.Endpoint.ID, admin, "").Code)
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package httpcore
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/slider/domain/entity"
+	"github.com/celpung/gocleanarch/application/slider/domain/usecase"
+	"github.com/celpung/gocleanarch/delivery/dto"
+	"github.com/celpung/gocleanarch/delivery/openapi"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/celpung/gocleanarch/infrastructure/mapper"
+	"github.com/celpung/gocleanarch/infrastructure/validation"
+)
+
+// SliderHandlers serves the slider endpoints listed by Routes.
+type SliderHandlers struct {
+	SliderUsecase usecase.SliderUsecase
+	MaxUploadSize int64
+}
+
+// Routes lists the slider endpoints: the active sliders for everyone and
+// their management for staff.
+func (h *SliderHandlers) Routes() []Route {
+	locale := openapi.Query("locale", "string", "Language of the title and description; defaults to the first Accept-Language tag.")
+	page := openapi.Query("page", "integer", "Page number, from 1.")
+	limit := openapi.Query("limit", "integer", "Page size.")
+	slider := openapi.Fields{"slider": dto.SliderResponse{}}
+	sliders := openapi.Fields{"data": openapi.Page("sliders", []dto.SliderResponse{})}
+
+	return []Route{
+		{Method: http.MethodGet, Path: "/sliders", Handler: h.Read, Doc: openapi.Doc{
+			Summary: "List the active sliders", Params: []openapi.Param{locale, page, limit}, Response: sliders,
+		}},
+		{Method: http.MethodGet, Path: "/sliders/all", Roles: staff, Handler: h.ReadAll, Doc: openapi.Doc{
+			Summary: "List every slider", Description: "Includes unpublished and scheduled sliders.",
+			Params: []openapi.Param{page, limit}, Response: sliders,
+		}},
+		{Method: http.MethodGet, Path: "/sliders/{id}", Handler: h.ReadByID, Doc: openapi.Doc{
+			Summary: "Get a slider", Params: []openapi.Param{locale}, Response: slider,
+		}},
+		{Method: http.MethodPost, Path: "/sliders", Roles: staff, Handler: h.Create, Doc: openapi.Doc{
+			Summary: "Create a slider", Description: "A multipart form may send the picture as an image part instead of a file URL.",
+			Body: dto.SliderCreateRequest{}, Files: []string{"image"}, Status: http.StatusCreated, Response: slider,
+		}},
+		{Method: http.MethodPut, Path: "/sliders/order", Roles: staff, Handler: h.Reorder, Doc: openapi.Doc{
+			Summary: "Reorder the sliders", Body: dto.SliderReorderRequest{},
+		}},
+		{Method: http.MethodPatch, Path: "/sliders/{id}", Roles: staff, Handler: h.Update, Doc: openapi.Doc{
+			Summary: "Update a slider", Description: "Changes the fields that are sent.",
+			Body: dto.SliderUpdateRequest{}, Files: []string{"image"}, Response: slider,
+		}},
+		{Method: http.MethodPost, Path: "/sliders/{id}/publish", Roles: staff, Handler: h.Publish, Doc: openapi.Doc{
+			Summary: "Publish a slider", Response: slider,
+		}},
+		{Method: http.MethodPost, Path: "/sliders/{id}/unpublish", Roles: staff, Handler: h.Unpublish, Doc: openapi.Doc{
+			Summary: "Unpublish a slider", Response: slider,
+		}},
+		{Method: http.MethodDelete, Path: "/sliders/{id}", Roles: staff, Handler: h.Delete, Doc: openapi.Doc{
+			Summary: "Delete a slider",
+		}},
+	}
+}
+
+// Create stores a slider read from JSON, or from a multipart form with an
+// optional "image" part.
+func (h *SliderHandlers) Create(req Request) Response {
+	var (
+		body  dto.SliderCreateRequest
+		image *entity.Image
+		err   error
+	)
+	if isMultipart(req) {
+		var form *multipart.Form
+		form, err = req.MultipartForm(h.MaxUploadSize + uploadOverhead)
+		if err == nil {
+			body.Title = formValue(form, "title")
+			body.Description = formValue(form, "description")
+			body.File = formValue(form, "file")
+			body.LinkURL = formValue(form, "link_url")
+			body.StartsAt = formField(form, "starts_at")
+			body.EndsAt = formField(form, "ends_at")
+			body.Published, err = formBool(form, "published")
+		}
+		if err == nil {
+			image, err = formImage(form)
+		}
+	} else {
+		err = json.NewDecoder(LimitBody(req, h.MaxUploadSize+uploadOverhead)).Decode(&body)
+	}
+	defer closeImage(image)
+	if err != nil {
+		return Fail(problem.New(requestStatus(err), "Invalid input data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	startsAt, err := scheduleTime(body.StartsAt)
+	if err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Validation failed", err))
+	}
+	endsAt, err := scheduleTime(body.EndsAt)
+	if err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Validation failed", err))
+	}
+
+	slider, err := h.SliderUsecase.Create(req.Context(), &entity.Slider{
+		Title:        body.Title,
+		Description:  body.Description,
+		File:         body.File,
+		LinkURL:      body.LinkURL,
+		Published:    body.Published == nil || *body.Published,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		Translations: toTranslations(body.Translations),
+	}, image)
+	if err != nil {
+		return Fail(problem.From("Failed to create slider", err))
+	}
+
+	return respondSlider(req, http.StatusCreated, slider, "Slider created")
+}
+
+// Read lists the sliders that are shown now, localized for the reader.
+func (h *SliderHandlers) Read(req Request) Response {
+	return h.list(req, publicQuery(req))
+}
+
+// ReadAll lists every slider with its translations, for staff.
+func (h *SliderHandlers) ReadAll(req Request) Response {
+	return h.list(req, entity.SliderQuery{})
+}
+
+func (h *SliderHandlers) list(req Request, query entity.SliderQuery) Response {
+	page, limit, res := pageParams(req)
+	if res != nil {
+		return *res
+	}
+
+	sliders, total, err := h.SliderUsecase.Read(req.Context(), query, page, limit)
+	if err != nil {
+		return Fail(problem.From("Failed to fetch sliders", err))
+	}
+
+	list, err := mapper.MapStructList[entity.Slider, dto.SliderResponse](sliders)
+	if err != nil {
+		return Fail(problem.From("Failed to map response list", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{
+		"message": i18n.T(req.Context(), "Sliders fetched successfully"),
+		"data":    pageData("sliders", list, total, page, limit),
+	})
+}
+
+func (h *SliderHandlers) ReadByID(req Request) Response {
+	slider, err := h.SliderUsecase.ReadByID(req.Context(), req.Param("id"), publicQuery(req))
+	if err != nil {
+		return Fail(problem.From("Failed to fetch slider", err))
+	}
+
+	return respondSlider(req, http.StatusOK, slider, "Slider fetched successfully")
+}
+
+// Update changes the fields sent as JSON, or as a multipart form with an
+// optional "image" part.
+func (h *SliderHandlers) Update(req Request) Response {
+	var (
+		body  dto.SliderUpdateRequest
+		image *entity.Image
+		err   error
+	)
+	if isMultipart(req) {
+		var form *multipart.Form
+		form, err = req.MultipartForm(h.MaxUploadSize + uploadOverhead)
+		if err == nil {
+			body.Title = formField(form, "title")
+			body.Description = formField(form, "description")
+			body.File = formField(form, "file")
+			body.LinkURL = formField(form, "link_url")
+			body.StartsAt = formField(form, "starts_at")
+			body.EndsAt = formField(form, "ends_at")
+			image, err = formImage(form)
+		}
+	} else {
+		err = json.NewDecoder(LimitBody(req, h.MaxUploadSize+uploadOverhead)).Decode(&body)
+	}
+	defer closeImage(image)
+	if err != nil {
+		return Fail(problem.New(requestStatus(err), "Invalid update data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	startsAt, err := scheduleTime(body.StartsAt)
+	if err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Validation failed", err))
+	}
+	endsAt, err := scheduleTime(body.EndsAt)
+	if err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Validation failed", err))
+	}
+
+	slider, err := h.SliderUsecase.Update(req.Context(), &entity.UpdateSliderPayload{
+		ID:           req.Param("id"),
+		Title:        body.Title,
+		Description:  body.Description,
+		File:         body.File,
+		Image:        image,
+		LinkURL:      body.LinkURL,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		Translations: toTranslations(body.Translations),
+	})
+	if err != nil {
+		return Fail(problem.From("Failed to update slider", err))
+	}
+
+	return respondSlider(req, http.StatusOK, slider, "Slider updated successfully")
+}
+
+func (h *SliderHandlers) Publish(req Request) Response {
+	return h.setPublished(req, true, "Slider published")
+}
+
+func (h *SliderHandlers) Unpublish(req Request) Response {
+	return h.setPublished(req, false, "Slider unpublished")
+}
+
+func (h *SliderHandlers) setPublished(req Request, published bool, message string) Response {
+	slider, err := h.SliderUsecase.SetPublished(req.Context(), req.Param("id"), published)
+	if err != nil {
+		return Fail(problem.From("Failed to change publish state", err))
+	}
+
+	return respondSlider(req, http.StatusOK, slider, message)
+}
+
+func (h *SliderHandlers) Reorder(req Request) Response {
+	var body dto.SliderReorderRequest
+	if err := json.NewDecoder(req.Body()).Decode(&body); err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Invalid input data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	if err := h.SliderUsecase.Reorder(req.Context(), body.IDs); err != nil {
+		return Fail(problem.From("Failed to reorder sliders", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{"message": i18n.T(req.Context(), "Sliders reordered successfully")})
+}
+
+func (h *SliderHandlers) Delete(req Request) Response {
+	if err := h.SliderUsecase.SoftDelete(req.Context(), req.Param("id")); err != nil {
+		return Fail(problem.From("Failed to delete slider", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{"message": i18n.T(req.Context(), "Slider deleted successfully")})
+}
+
+func respondSlider(req Request, status int, slider *entity.Slider, message string) Response {
+	var res dto.SliderResponse
+	if err := mapper.CopyTo(slider, &res); err != nil {
+		return Fail(problem.From("Failed to map response", err))
+	}
+
+	return JSON(status, map[string]any{"message": i18n.T(req.Context(), message), "slider": res})
+}
+
+func isMultipart(req Request) bool {
+	return strings.HasPrefix(req.Header("Content-Type"), "multipart/form-data")
+}
+
+// formImage opens the "image" part of form, which the use case inspects.
+// It returns nil when there is none.
+func formImage(form *multipart.Form) (*entity.Image, error) {
+	headers := form.File["image"]
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	header := headers[0]
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &entity.Image{Filename: header.Filename, Size: header.Size, Content: file}, nil
+}
+
+// formValue returns a form value, or "" when the form does not have it.
+func formValue(form *multipart.Form, name string) string {
+	if value := formField(form, name); value != nil {
+		return *value
+	}
+	return ""
+}
+
+// formField returns a form value, or nil when the form does not have it,
+// like a field missing from a JSON update.
+func formField(form *multipart.Form, name string) *string {
+	values, ok := form.Value[name]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	return &values[0]
+}
+
+// formBool parses a form value as a bool, or returns nil when the form does
+// not have it.
+func formBool(form *multipart.Form, name string) (*bool, error) {
+	value := formField(form, name)
+	if value == nil {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// publicQuery localizes public reads to the locale query parameter, or
+// else the first language in Accept-Language.
+func publicQuery(req Request) entity.SliderQuery {
+	locale, _ := req.Query("locale")
+	if locale == "" {
+		locale, _, _ = strings.Cut(req.Header("Accept-Language"), ",")
+		locale, _, _ = strings.Cut(locale, ";")
+	}
+	return entity.SliderQuery{Public: true, Locale: strings.TrimSpace(locale)}
+}
+
+// scheduleTime parses an RFC 3339 time. An empty value is the zero time,
+// which removes that end of the schedule.
+func scheduleTime(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if *value == "" {
+		return &time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func toTranslations(req map[string]dto.SliderTranslationRequest) map[string]entity.Translation {
+	if req == nil {
+		return nil
+	}
+
+	translations := make(map[string]entity.Translation, len(req))
+	for locale, t := range req {
+		translations[locale] = entity.Translation{Title: t.Title, Description: t.Description}
+	}
+	return translations
+}
+
+func closeImage(image *entity.Image) {
+	if image == nil {
+		return
+	}
+	if closer, ok := image.Content.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+func NewSliderHandlers(usecase usecase.SliderUsecase, maxUploadSize int64) *SliderHandlers {
+	return &SliderHandlers{SliderUsecase: usecase, MaxUploadSize: maxUploadSize}
+}
@@ -0,0 +1,458 @@
+package httpcore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/celpung/gocleanarch/application/user/domain/entity"
+	"github.com/celpung/gocleanarch/application/user/domain/usecase"
+	"github.com/celpung/gocleanarch/delivery/dto"
+	"github.com/celpung/gocleanarch/delivery/etag"
+	"github.com/celpung/gocleanarch/delivery/openapi"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/celpung/gocleanarch/infrastructure/mapper"
+	"github.com/celpung/gocleanarch/infrastructure/pagination"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	"github.com/celpung/gocleanarch/infrastructure/validation"
+)
+
+// uploadOverhead leaves room for form fields and multipart framing next to
+// an upload of MaxUploadSize bytes.
+const uploadOverhead = 1 << 20
+
+// UserHandlers serves the user endpoints listed by Routes.
+type UserHandlers struct {
+	UserUsecase   usecase.UserUsecase
+	MaxUploadSize int64
+}
+
+// Routes lists the user endpoints with the roles allowed to call them and
+// their documentation. Static paths come before parameterised ones for
+// routers that match in registration order.
+func (h *UserHandlers) Routes() []Route {
+	signedIn := []Role{User, Admin, Super}
+
+	page := openapi.Query("page", "integer", "Page number, from 1.")
+	limit := openapi.Query("limit", "integer", "Page size.")
+	cursor := openapi.Query("cursor", "string", "Lists by cursor instead of by page; send it empty for the first page.")
+	user := openapi.Fields{"user": dto.UserResponse{}}
+	etagHeader := map[string]string{"ETag": "Version of the user, for If-Match."}
+	cursorPage := openapi.Fields{"users": []dto.UserResponse{}, "limit": uint(0), "next_cursor": "", "prev_cursor": ""}
+
+	return []Route{
+		{Method: http.MethodPost, Path: "/users/register", Handler: h.Register, Doc: openapi.Doc{
+			Summary: "Register a user", Body: dto.UserCreateRequest{},
+			Status: http.StatusCreated, Response: user, Headers: etagHeader,
+		}},
+		{Method: http.MethodPost, Path: "/users/login", Handler: h.Login, Doc: openapi.Doc{
+			Summary: "Sign in", Description: "Answers a bearer token for the other endpoints.",
+			Body: dto.UserLoginRequest{}, Response: openapi.Fields{"token": ""},
+		}},
+		{Method: http.MethodGet, Path: "/users", Roles: staff, Handler: h.GetAllUserData, Doc: openapi.Doc{
+			Summary: "List users", Params: []openapi.Param{page, limit, cursor},
+			Response: openapi.Fields{"data": openapi.OneOf{openapi.Page("users", []dto.UserResponse{}), cursorPage}},
+		}},
+		{Method: http.MethodGet, Path: "/users/search", Roles: []Role{Admin}, Handler: h.SearchUser, Doc: openapi.Doc{
+			Summary: "Search users", Description: "Ranks users matching q; by cursor the results are plain users.",
+			Params:   []openapi.Param{openapi.Query("q", "string", "Search keyword."), page, limit, cursor},
+			Response: openapi.Fields{"data": openapi.OneOf{openapi.Page("users", []dto.UserSearchResponse{}), cursorPage}},
+		}},
+		{Method: http.MethodGet, Path: "/users/search/fuzzy", Roles: []Role{Admin}, Handler: h.FuzzySearchUser, Doc: openapi.Doc{
+			Summary: "Search the user index", Description: "Tolerates typos and reports facets by role and activity.",
+			Params: []openapi.Param{
+				openapi.Query("q", "string", "Search keyword."),
+				openapi.Query("role", "string", "Only users with this role."),
+				openapi.Query("active", "boolean", "Only active or inactive users."),
+				page, limit,
+			},
+			Response: openapi.Fields{"data": facetedPage()},
+		}},
+		{Method: http.MethodPatch, Path: "/users", Roles: signedIn, Handler: h.UpdateUser, Doc: openapi.Doc{
+			Summary: "Update a user", Description: "Changes the fields that are sent, if the user is still at the version named by If-Match or version. " +
+				"Users other than staff may only change their own name, email and password; staff may not change users that outrank them, " +
+				"and only SUPER may change roles.",
+			Params: []openapi.Param{openapi.HeaderParam("If-Match", "ETag of the user as last read.")},
+			Body:   dto.UserUpdateRequest{}, Response: user, Headers: etagHeader,
+		}},
+		{Method: http.MethodPut, Path: "/users/avatar", Roles: signedIn, Handler: h.UploadAvatar, Doc: openapi.Doc{
+			Summary: "Upload the caller's avatar", Files: []string{"avatar"}, Response: user, Headers: etagHeader,
+		}},
+		{Method: http.MethodDelete, Path: "/users/avatar", Roles: signedIn, Handler: h.DeleteAvatar, Doc: openapi.Doc{
+			Summary: "Remove the caller's avatar", Response: user, Headers: etagHeader,
+		}},
+		{Method: http.MethodDelete, Path: "/users/{id}", Roles: []Role{User}, Handler: h.DeleteUser, Doc: openapi.Doc{
+			Summary: "Delete the caller's account", Description: "The id must be the caller's own.",
+		}},
+	}
+}
+
+// facetedPage documents the data of a fuzzy search: a page of results and
+// counts by facet value.
+func facetedPage() openapi.Fields {
+	data := openapi.Page("users", []dto.UserSearchResponse{})
+	data["facets"] = map[string]map[string]int{}
+	return data
+}
+
+func (h *UserHandlers) Register(req Request) Response {
+	var body dto.UserCreateRequest
+	if err := json.NewDecoder(req.Body()).Decode(&body); err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Invalid input data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	var e entity.User
+	if err := mapper.CopyTo(&body, &e); err != nil {
+		return Fail(problem.From("Failed to map request", err))
+	}
+
+	user, err := h.UserUsecase.Create(req.Context(), &e)
+	if err != nil {
+		return Fail(problem.From("Failed to create user", err))
+	}
+
+	return respondUser(req, http.StatusCreated, user, "Register success")
+}
+
+func (h *UserHandlers) Login(req Request) Response {
+	var body dto.UserLoginRequest
+	if err := json.NewDecoder(req.Body()).Decode(&body); err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Invalid login data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	token, err := h.UserUsecase.Login(req.Context(), body.Email, body.Password)
+	if err != nil {
+		return Fail(problem.From("Login failed", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{"message": i18n.T(req.Context(), "Login success"), "token": token})
+}
+
+// GetAllUserData lists users by page, or by cursor when a `cursor` query
+// parameter is sent.
+func (h *UserHandlers) GetAllUserData(req Request) Response {
+	if cursor, ok := req.Query("cursor"); ok {
+		return h.respondCursorPage(req, cursor, h.UserUsecase.ReadByCursor)
+	}
+
+	page, limit, res := pageParams(req)
+	if res != nil {
+		return *res
+	}
+
+	users, total, err := h.UserUsecase.Read(req.Context(), page, limit)
+	if err != nil {
+		return Fail(problem.From("Failed to fetch user data", err))
+	}
+
+	list, err := mapper.MapStructList[entity.User, dto.UserResponse](users)
+	if err != nil {
+		return Fail(problem.From("Failed to map response list", err))
+	}
+
+	return respondPage(req, list, total, page, limit, nil)
+}
+
+// SearchUser ranks users matching `q`, by page or by cursor.
+func (h *UserHandlers) SearchUser(req Request) Response {
+	keyword, _ := req.Query("q")
+	if cursor, ok := req.Query("cursor"); ok {
+		return h.respondCursorPage(req, cursor, func(ctx context.Context, cursor string, limit uint) ([]*entity.User, *pagination.CursorPage, error) {
+			return h.UserUsecase.SearchByCursor(ctx, cursor, limit, keyword)
+		})
+	}
+
+	page, limit, res := pageParams(req)
+	if res != nil {
+		return *res
+	}
+
+	results, total, err := h.UserUsecase.SearchRanked(req.Context(), keyword, page, limit)
+	if err != nil {
+		return Fail(problem.From("Failed to search users", err))
+	}
+
+	list, err := mapper.MapStructList[entity.UserSearchResult, dto.UserSearchResponse](results)
+	if err != nil {
+		return Fail(problem.From("Failed to map response list", err))
+	}
+
+	return respondPage(req, list, total, page, limit, nil)
+}
+
+// FuzzySearchUser searches the user index, optionally filtered by `role`
+// and `active`, and reports facets next to the page.
+func (h *UserHandlers) FuzzySearchUser(req Request) Response {
+	page, limit, res := pageParams(req)
+	if res != nil {
+		return *res
+	}
+
+	var active *bool
+	if v, _ := req.Query("active"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Fail(problem.New(http.StatusBadRequest, "Invalid active parameter", nil))
+		}
+		active = &b
+	}
+
+	keyword, _ := req.Query("q")
+	role, _ := req.Query("role")
+	result, err := h.UserUsecase.FuzzySearch(req.Context(), keyword, role, active, page, limit)
+	if err != nil {
+		return Fail(problem.From("Failed to search users", err))
+	}
+
+	list, err := mapper.MapStructList[entity.UserSearchResult, dto.UserSearchResponse](result.Results)
+	if err != nil {
+		return Fail(problem.From("Failed to map response list", err))
+	}
+
+	return respondPage(req, list, result.Total, page, limit, result.Facets)
+}
+
+// UpdateUser applies a partial update guarded by If-Match or `version`.
+// Users may only change their own name, email and password. Staff may
+// update users up to their own rank, and only SUPER may change roles.
+func (h *UserHandlers) UpdateUser(req Request) Response {
+	var body dto.UserUpdateRequest
+	if err := json.NewDecoder(req.Body()).Decode(&body); err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Invalid update data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	principal, ok := requestctx.PrincipalFrom(req.Context())
+	if !ok {
+		return Denied(ErrUnauthorized)
+	}
+	if res := h.authorizeUpdate(req, principal, &body); res != nil {
+		return *res
+	}
+
+	var payload entity.UpdateUserPayload
+	if err := mapper.CopyTo(&body, &payload); err != nil {
+		return Fail(problem.From("Failed to map update payload", err))
+	}
+
+	version, err := etag.Resolve(req.Header("If-Match"), body.Version)
+	if errors.Is(err, etag.ErrMissing) {
+		return Fail(problem.From("Precondition required", err))
+	}
+	if err != nil {
+		return Fail(problem.From("Invalid precondition", err))
+	}
+	payload.Version = version
+
+	user, err := h.UserUsecase.Update(req.Context(), &payload)
+	var conflict *entity.VersionConflictError
+	if errors.As(err, &conflict) {
+		return Fail(problem.From("User was modified by someone else", err)).
+			WithHeader("ETag", etag.Format(conflict.Current))
+	}
+	if err != nil {
+		return Fail(problem.From("Failed to update user", err))
+	}
+
+	return respondUser(req, http.StatusOK, user, "User updated successfully")
+}
+
+// authorizeUpdate answers a response when principal may not apply body.
+func (h *UserHandlers) authorizeUpdate(req Request, principal requestctx.Principal, body *dto.UserUpdateRequest) *Response {
+	caller := Role(principal.Role)
+	denied := Denied(ErrForbidden)
+
+	if !hasRole(staff, caller) {
+		if body.ID != principal.UserID || body.Role != nil || body.Active != nil {
+			return &denied
+		}
+		return nil
+	}
+	if body.Role != nil && (caller != Super || rank(roleOf(*body.Role)) > rank(caller)) {
+		return &denied
+	}
+	if body.ID == principal.UserID {
+		return nil
+	}
+
+	target, err := h.UserUsecase.ReadByID(req.Context(), body.ID)
+	if err != nil {
+		res := Fail(problem.From("Failed to update user", err))
+		return &res
+	}
+	if rank(roleOf(target.Role)) > rank(caller) {
+		return &denied
+	}
+	return nil
+}
+
+// DeleteUser soft deletes the caller's own account.
+func (h *UserHandlers) DeleteUser(req Request) Response {
+	principal, ok := requestctx.PrincipalFrom(req.Context())
+	if !ok {
+		return Denied(ErrUnauthorized)
+	}
+	if req.Param("id") != principal.UserID {
+		return Denied(ErrForbidden)
+	}
+
+	if err := h.UserUsecase.SoftDelete(req.Context(), req.Param("id")); err != nil {
+		return Fail(problem.From("Failed to delete user", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{"message": i18n.T(req.Context(), "User deleted successfully")})
+}
+
+// UploadAvatar replaces the caller's avatar with the "avatar" part of a
+// multipart request.
+func (h *UserHandlers) UploadAvatar(req Request) Response {
+	principal, ok := requestctx.PrincipalFrom(req.Context())
+	if !ok {
+		return Denied(ErrUnauthorized)
+	}
+
+	header, err := FormFile(req, "avatar", h.MaxUploadSize+uploadOverhead)
+	if err != nil {
+		return Fail(problem.New(requestStatus(err), "Invalid avatar", err))
+	}
+	file, err := header.Open()
+	if err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Invalid avatar", err))
+	}
+	defer file.Close()
+
+	user, err := h.UserUsecase.SetAvatar(req.Context(), principal.UserID, &entity.Avatar{Filename: header.Filename, Size: header.Size, Content: file})
+	if err != nil {
+		return Fail(problem.From("Failed to update avatar", err))
+	}
+
+	return respondUser(req, http.StatusOK, user, "Avatar updated successfully")
+}
+
+// DeleteAvatar removes the caller's avatar.
+func (h *UserHandlers) DeleteAvatar(req Request) Response {
+	principal, ok := requestctx.PrincipalFrom(req.Context())
+	if !ok {
+		return Denied(ErrUnauthorized)
+	}
+
+	user, err := h.UserUsecase.RemoveAvatar(req.Context(), principal.UserID)
+	if err != nil {
+		return Fail(problem.From("Failed to remove avatar", err))
+	}
+
+	return respondUser(req, http.StatusOK, user, "Avatar removed successfully")
+}
+
+// respondCursorPage serves keyset pagination, selected when the client sends a
+// `cursor` query parameter (empty for the first page).
+func (h *UserHandlers) respondCursorPage(req Request, cursor string, fetch func(ctx context.Context, cursor string, limit uint) ([]*entity.User, *pagination.CursorPage, error)) Response {
+	limit := pagination.DefaultLimit
+	if v, _ := req.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Fail(problem.New(http.StatusBadRequest, "Invalid limit parameter", nil))
+		}
+		limit = uint(n)
+	}
+
+	users, page, err := fetch(req.Context(), cursor, limit)
+	if err != nil {
+		return Fail(problem.From("Failed to fetch user data", err))
+	}
+
+	list, err := mapper.MapStructList[entity.User, dto.UserResponse](users)
+	if err != nil {
+		return Fail(problem.From("Failed to map response list", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{
+		"message": i18n.T(req.Context(), "Users fetched successfully"),
+		"data": map[string]any{
+			"users":       list,
+			"limit":       pagination.NormalizeLimit(limit),
+			"next_cursor": page.Next,
+			"prev_cursor": page.Prev,
+		},
+	})
+}
+
+// pageParams reads `page` and `limit`. Missing values fall back to the
+// first page and the default limit; limits above the maximum are clamped.
+func pageParams(req Request) (page, limit uint, res *Response) {
+	page, limit = 1, pagination.DefaultLimit
+
+	if v, _ := req.Query("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			bad := Fail(problem.New(http.StatusBadRequest, "Invalid page parameter", nil))
+			return 0, 0, &bad
+		}
+		page = uint(n)
+	}
+	if v, _ := req.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			bad := Fail(problem.New(http.StatusBadRequest, "Invalid limit parameter", nil))
+			return 0, 0, &bad
+		}
+		limit = uint(n)
+	}
+
+	return page, pagination.NormalizeLimit(limit), nil
+}
+
+// respondPage answers a page of users; facets are left out when nil.
+func respondPage(req Request, users any, total int64, page, limit uint, facets any) Response {
+	data := pageData("users", users, total, page, limit)
+	if facets != nil {
+		data["facets"] = facets
+	}
+
+	return JSON(http.StatusOK, map[string]any{"message": i18n.T(req.Context(), "Users fetched successfully"), "data": data})
+}
+
+// pageData lists a page of items under member with the counts of
+// openapi.Page.
+func pageData(member string, items any, total int64, page, limit uint) map[string]any {
+	return map[string]any{
+		member:         items,
+		"count":        total,
+		"current_page": page,
+		"total_page":   (total + int64(limit) - 1) / int64(limit),
+	}
+}
+
+func respondUser(req Request, status int, user *entity.User, message string) Response {
+	var res dto.UserResponse
+	if err := mapper.CopyTo(user, &res); err != nil {
+		return Fail(problem.From("Failed to map response", err))
+	}
+
+	return JSON(status, map[string]any{"message": i18n.T(req.Context(), message), "user": res}).
+		WithHeader("ETag", etag.Format(res.Version))
+}
+
+// requestStatus maps errors reading the request body to HTTP status codes.
+func requestStatus(err error) int {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func NewUserHandlers(usecase usecase.UserUsecase, maxUploadSize int64) *UserHandlers {
+	return &UserHandlers{UserUsecase: usecase, MaxUploadSize: maxUploadSize}
+}
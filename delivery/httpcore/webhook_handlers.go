@@ -0,0 +1,232 @@
+package httpcore
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/celpung/gocleanarch/application/webhook/domain/entity"
+	"github.com/celpung/gocleanarch/application/webhook/domain/usecase"
+	"github.com/celpung/gocleanarch/delivery/dto"
+	"github.com/celpung/gocleanarch/delivery/openapi"
+	"github.com/celpung/gocleanarch/delivery/problem"
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/celpung/gocleanarch/infrastructure/mapper"
+	"github.com/celpung/gocleanarch/infrastructure/validation"
+)
+
+// WebhookHandlers serves the webhook endpoints listed by Routes.
+type WebhookHandlers struct {
+	WebhookUsecase usecase.WebhookUsecase
+}
+
+// Routes lists the webhook endpoints, which are all for staff.
+func (h *WebhookHandlers) Routes() []Route {
+	endpoint := openapi.Fields{"endpoint": dto.WebhookEndpointResponse{}}
+
+	return []Route{
+		{Method: http.MethodPost, Path: "/webhooks", Roles: staff, Handler: h.CreateEndpoint, Doc: openapi.Doc{
+			Summary: "Create a webhook endpoint", Description: "The response carries the signing secret; it is not shown again.",
+			Body: dto.WebhookEndpointCreateRequest{}, Status: http.StatusCreated, Response: endpoint,
+		}},
+		{Method: http.MethodGet, Path: "/webhooks", Roles: staff, Handler: h.ListEndpoints, Doc: openapi.Doc{
+			Summary: "List webhook endpoints", Response: openapi.Fields{"endpoints": []dto.WebhookEndpointResponse{}},
+		}},
+		{Method: http.MethodGet, Path: "/webhooks/{id}", Roles: staff, Handler: h.GetEndpoint, Doc: openapi.Doc{
+			Summary: "Get a webhook endpoint", Response: endpoint,
+		}},
+		{Method: http.MethodPatch, Path: "/webhooks/{id}", Roles: staff, Handler: h.UpdateEndpoint, Doc: openapi.Doc{
+			Summary: "Update a webhook endpoint", Body: dto.WebhookEndpointUpdateRequest{}, Response: endpoint,
+		}},
+		{Method: http.MethodDelete, Path: "/webhooks/{id}", Roles: staff, Handler: h.DeleteEndpoint, Doc: openapi.Doc{
+			Summary: "Delete a webhook endpoint",
+		}},
+		{Method: http.MethodPost, Path: "/webhooks/{id}/rotate-secret", Roles: staff, Handler: h.RotateSecret, Doc: openapi.Doc{
+			Summary: "Rotate the signing secret", Response: endpoint,
+		}},
+		{Method: http.MethodGet, Path: "/webhooks/{id}/deliveries", Roles: staff, Handler: h.ListDeliveries, Doc: openapi.Doc{
+			Summary: "List deliveries",
+			Params: []openapi.Param{
+				openapi.Query("status", "string", "Only deliveries with this status."),
+				openapi.Query("page", "integer", "Page number, from 1."),
+				openapi.Query("limit", "integer", "Page size."),
+			},
+			Response: openapi.Fields{"data": openapi.Page("deliveries", []dto.WebhookDeliveryResponse{})},
+		}},
+		{Method: http.MethodGet, Path: "/webhooks/{id}/deliveries/{deliveryID}", Roles: staff, Handler: h.GetDelivery, Doc: openapi.Doc{
+			Summary: "Get a delivery with its attempts", Response: openapi.Fields{"delivery": dto.WebhookDeliveryDetailResponse{}},
+		}},
+		{Method: http.MethodPost, Path: "/webhooks/{id}/deliveries/{deliveryID}/redeliver", Roles: staff, Handler: h.Redeliver, Doc: openapi.Doc{
+			Summary: "Send a delivery again", Response: openapi.Fields{"attempt": dto.WebhookAttemptResponse{}},
+		}},
+	}
+}
+
+func (h *WebhookHandlers) CreateEndpoint(req Request) Response {
+	var body dto.WebhookEndpointCreateRequest
+	if err := json.NewDecoder(req.Body()).Decode(&body); err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Invalid input data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	endpoint, err := h.WebhookUsecase.CreateEndpoint(req.Context(), &entity.Endpoint{
+		URL:         body.URL,
+		Description: body.Description,
+		Events:      body.Events,
+	})
+	if err != nil {
+		return Fail(problem.From("Failed to create webhook endpoint", err))
+	}
+
+	return respondEndpoint(req, http.StatusCreated, endpoint, "Webhook endpoint created")
+}
+
+func (h *WebhookHandlers) ListEndpoints(req Request) Response {
+	endpoints, err := h.WebhookUsecase.ListEndpoints(req.Context())
+	if err != nil {
+		return Fail(problem.From("Failed to fetch webhook endpoints", err))
+	}
+
+	list, err := mapper.MapStructList[entity.Endpoint, dto.WebhookEndpointResponse](endpoints)
+	if err != nil {
+		return Fail(problem.From("Failed to map response list", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{
+		"message":   i18n.T(req.Context(), "Webhook endpoints fetched successfully"),
+		"endpoints": list,
+	})
+}
+
+func (h *WebhookHandlers) GetEndpoint(req Request) Response {
+	endpoint, err := h.WebhookUsecase.GetEndpoint(req.Context(), req.Param("id"))
+	if err != nil {
+		return Fail(problem.From("Failed to fetch webhook endpoint", err))
+	}
+
+	return respondEndpoint(req, http.StatusOK, endpoint, "Webhook endpoint fetched successfully")
+}
+
+func (h *WebhookHandlers) UpdateEndpoint(req Request) Response {
+	var body dto.WebhookEndpointUpdateRequest
+	if err := json.NewDecoder(req.Body()).Decode(&body); err != nil {
+		return Fail(problem.New(http.StatusBadRequest, "Invalid update data", err))
+	}
+	if err := validation.ValidateStructCtx(req.Context(), body); err != nil {
+		return Fail(problem.From("Validation failed", err))
+	}
+
+	endpoint, err := h.WebhookUsecase.UpdateEndpoint(req.Context(), &entity.UpdateEndpointPayload{
+		ID:          req.Param("id"),
+		URL:         body.URL,
+		Description: body.Description,
+		Events:      body.Events,
+		Active:      body.Active,
+	})
+	if err != nil {
+		return Fail(problem.From("Failed to update webhook endpoint", err))
+	}
+
+	return respondEndpoint(req, http.StatusOK, endpoint, "Webhook endpoint updated successfully")
+}
+
+func (h *WebhookHandlers) DeleteEndpoint(req Request) Response {
+	if err := h.WebhookUsecase.DeleteEndpoint(req.Context(), req.Param("id")); err != nil {
+		return Fail(problem.From("Failed to delete webhook endpoint", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{"message": i18n.T(req.Context(), "Webhook endpoint deleted successfully")})
+}
+
+func (h *WebhookHandlers) RotateSecret(req Request) Response {
+	endpoint, err := h.WebhookUsecase.RotateSecret(req.Context(), req.Param("id"))
+	if err != nil {
+		return Fail(problem.From("Failed to rotate webhook secret", err))
+	}
+
+	return respondEndpoint(req, http.StatusOK, endpoint, "Webhook secret rotated")
+}
+
+// ListDeliveries pages through the deliveries of an endpoint, optionally
+// only those with the `status` query parameter.
+func (h *WebhookHandlers) ListDeliveries(req Request) Response {
+	page, limit, res := pageParams(req)
+	if res != nil {
+		return *res
+	}
+
+	status, _ := req.Query("status")
+	deliveries, total, err := h.WebhookUsecase.ListDeliveries(req.Context(), req.Param("id"), status, page, limit)
+	if err != nil {
+		return Fail(problem.From("Failed to fetch webhook deliveries", err))
+	}
+
+	list, err := mapper.MapStructList[entity.Delivery, dto.WebhookDeliveryResponse](deliveries)
+	if err != nil {
+		return Fail(problem.From("Failed to map response list", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{
+		"message": i18n.T(req.Context(), "Webhook deliveries fetched successfully"),
+		"data":    pageData("deliveries", list, total, page, limit),
+	})
+}
+
+func (h *WebhookHandlers) GetDelivery(req Request) Response {
+	delivery, attempts, err := h.WebhookUsecase.GetDelivery(req.Context(), req.Param("id"), req.Param("deliveryID"))
+	if err != nil {
+		return Fail(problem.From("Failed to fetch webhook delivery", err))
+	}
+
+	res := dto.WebhookDeliveryDetailResponse{Payload: string(delivery.Payload)}
+	if err := mapper.CopyTo(delivery, &res.WebhookDeliveryResponse); err != nil {
+		return Fail(problem.From("Failed to map response", err))
+	}
+	for _, a := range attempts {
+		res.Attempts = append(res.Attempts, toAttemptResponse(a))
+	}
+
+	return JSON(http.StatusOK, map[string]any{
+		"message":  i18n.T(req.Context(), "Webhook delivery fetched successfully"),
+		"delivery": res,
+	})
+}
+
+func (h *WebhookHandlers) Redeliver(req Request) Response {
+	attempt, err := h.WebhookUsecase.Redeliver(req.Context(), req.Param("id"), req.Param("deliveryID"))
+	if err != nil {
+		return Fail(problem.From("Failed to redeliver webhook", err))
+	}
+
+	return JSON(http.StatusOK, map[string]any{
+		"message": i18n.T(req.Context(), "Webhook redelivered"),
+		"attempt": toAttemptResponse(attempt),
+	})
+}
+
+func respondEndpoint(req Request, status int, endpoint *entity.Endpoint, message string) Response {
+	var res dto.WebhookEndpointResponse
+	if err := mapper.CopyTo(endpoint, &res); err != nil {
+		return Fail(problem.From("Failed to map response", err))
+	}
+
+	return JSON(status, map[string]any{"message": i18n.T(req.Context(), message), "endpoint": res})
+}
+
+func toAttemptResponse(a *entity.Attempt) dto.WebhookAttemptResponse {
+	return dto.WebhookAttemptResponse{
+		ID:           a.ID,
+		Attempt:      a.Attempt,
+		Manual:       a.Manual,
+		StatusCode:   a.StatusCode,
+		ResponseBody: a.ResponseBody,
+		Error:        a.Error,
+		DurationMs:   a.Duration.Milliseconds(),
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+func NewWebhookHandlers(usecase usecase.WebhookUsecase) *WebhookHandlers {
+	return &WebhookHandlers{WebhookUsecase: usecase}
+}
@@ -0,0 +1,51 @@
+// Package frameworks mounts the shared route tables on every supported
+// framework, so tests can check that they all serve them alike.
+package frameworks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	fiber_adapter "github.com/celpung/gocleanarch/delivery/fiber/adapter"
+	fiber_middleware "github.com/celpung/gocleanarch/delivery/fiber/user/middleware"
+	gin_adapter "github.com/celpung/gocleanarch/delivery/gin/adapter"
+	gin_middleware "github.com/celpung/gocleanarch/delivery/gin/user/middleware"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	chi_adapter "github.com/celpung/gocleanarch/delivery/std/chi/adapter"
+	chi_middleware "github.com/celpung/gocleanarch/delivery/std/chi/user/middleware"
+	http_adapter "github.com/celpung/gocleanarch/delivery/std/http/adapter"
+	http_middleware "github.com/celpung/gocleanarch/delivery/std/http/user/middleware"
+)
+
+// All mounts routes on each supported framework behind its locale
+// middleware.
+var All = map[string]func(routes []httpcore.Route) http.Handler{
+	"gin": func(routes []httpcore.Route) http.Handler {
+		gin.SetMode(gin.TestMode)
+		engine := gin.New()
+		engine.Use(gin_middleware.LocaleMiddleware())
+		gin_adapter.Register(engine, routes)
+		return engine
+	},
+	"fiber": func(routes []httpcore.Route) http.Handler {
+		app := fiber.New()
+		app.Use(fiber_middleware.LocaleMiddleware())
+		fiber_adapter.Register(app, routes)
+		return adaptor.FiberApp(app)
+	},
+	"chi": func(routes []httpcore.Route) http.Handler {
+		r := chi.NewRouter()
+		r.Use(chi_middleware.LocaleMiddleware)
+		chi_adapter.Register(r, routes)
+		return r
+	},
+	"net/http": func(routes []httpcore.Route) http.Handler {
+		mux := http.NewServeMux()
+		http_adapter.Register(mux, routes)
+		return http_middleware.LocaleMiddleware(mux)
+	},
+}
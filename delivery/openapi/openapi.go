@@ -0,0 +1,357 @@
+// Package openapi builds the OpenAPI 3.1 document of the API. Endpoints
+// name their route, the roles allowed to call it and a Doc; request and
+// response schemas are reflected from the DTOs, with constraints read from
+// their `validate` tags. openapi.json next to this file is the committed
+// document, written by cmd/openapi and checked against Build by the tests.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/celpung/gocleanarch/delivery/problem"
+)
+
+// Version is the OpenAPI version of the documents Build returns.
+const Version = "3.1.0"
+
+// BearerAuth names the security scheme of routes that need a token.
+const BearerAuth = "bearerAuth"
+
+// problemRef is the response every operation answers failures with.
+const problemRef = "#/components/responses/Problem"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem maps lower-case methods to the operations of one path.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response is either a reference to a shared response or one of its own.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]*Response      `json:"responses"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Endpoint is one documented route. Roles lists who may call it; nil
+// means anyone, signed in or not.
+type Endpoint struct {
+	Method string
+	Path   string
+	Tag    string
+	Roles  []string
+	Doc
+}
+
+// Doc describes a route apart from its method, path and roles.
+type Doc struct {
+	Summary     string
+	Description string
+	// Params lists query parameters and headers; path parameters are read
+	// from the path.
+	Params []Param
+	// Body is a value of the JSON request body, nil when there is none.
+	Body any
+	// Files names the file parts of a multipart body, which also carries
+	// the `form` fields of Body. They are required when Body is nil, as
+	// the files are then the whole request.
+	Files []string
+	// Status is the status of a success, 200 when zero.
+	Status int
+	// Response lists what a success body holds next to its message.
+	Response Fields
+	// Headers maps success response headers to their descriptions.
+	Headers map[string]string
+}
+
+// Param is a query parameter or header. Type is a JSON schema type.
+type Param struct {
+	Name        string
+	In          string
+	Type        string
+	Description string
+}
+
+// Query describes a query parameter.
+func Query(name, typ, description string) Param {
+	return Param{Name: name, In: "query", Type: typ, Description: description}
+}
+
+// HeaderParam describes a request header.
+func HeaderParam(name, description string) Param {
+	return Param{Name: name, In: "header", Type: "string", Description: description}
+}
+
+// Fields describes the members of a JSON object. Values are examples of
+// each member: DTOs, slices, maps, nested Fields or a OneOf.
+type Fields map[string]any
+
+// OneOf is a member that takes one of several shapes.
+type OneOf []any
+
+// Page is the data of a page of items listed under member.
+func Page(member string, items any) Fields {
+	return Fields{member: items, "count": int64(0), "current_page": uint(0), "total_page": int64(0)}
+}
+
+// Build returns the document of endpoints for an API mounted at basePath.
+func Build(basePath string, endpoints []Endpoint) *Document {
+	if basePath == "" {
+		basePath = "/"
+	}
+
+	g := newGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "GoCleanArch API",
+			Description: "Users, sliders and webhooks. Failures are answered with RFC 7807 problem details, localized by Accept-Language.",
+			Version:     "1.0.0",
+		},
+		Servers: []Server{{URL: basePath}},
+		Paths:   map[string]PathItem{},
+	}
+
+	seen := map[string]bool{}
+	for _, e := range endpoints {
+		if e.Tag != "" && !seen[e.Tag] {
+			seen[e.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: e.Tag})
+		}
+		item, ok := doc.Paths[e.Path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[e.Path] = item
+		}
+		item[strings.ToLower(e.Method)] = g.operation(e)
+	}
+
+	doc.Components = Components{
+		Schemas: g.schemas,
+		Responses: map[string]*Response{
+			"Problem": {
+				Description: "The request failed; the body says why.",
+				Content:     map[string]MediaType{problem.ContentType: {Schema: g.schema(reflect.TypeOf(problem.Problem{}))}},
+			},
+		},
+		SecuritySchemes: map[string]SecurityScheme{
+			BearerAuth: {
+				Type:         "http",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+				Description:  "The token answered by POST /users/login. Operations name the roles they allow.",
+			},
+		},
+	}
+	return doc
+}
+
+func (g *generator) operation(e Endpoint) *Operation {
+	op := &Operation{
+		Summary:     e.Summary,
+		Description: e.Description,
+		OperationID: operationID(e.Method, e.Path),
+		Responses:   map[string]*Response{"default": {Ref: problemRef}},
+	}
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+	}
+
+	for _, m := range pathParams.FindAllStringSubmatch(e.Path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for _, p := range e.Params {
+		op.Parameters = append(op.Parameters, Parameter{Name: p.Name, In: p.In, Description: p.Description, Schema: &Schema{Type: p.Type}})
+	}
+
+	op.RequestBody = g.requestBody(e.Doc)
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{
+		Description: http.StatusText(status),
+		Content:     map[string]MediaType{"application/json": {Schema: g.envelope(e.Response)}},
+	}
+	for name, description := range e.Headers {
+		if success.Headers == nil {
+			success.Headers = map[string]Header{}
+		}
+		success.Headers[name] = Header{Description: description, Schema: &Schema{Type: "string"}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	if e.Roles != nil {
+		op.Security = []map[string][]string{{BearerAuth: {}}}
+		op.Responses["401"] = &Response{Ref: problemRef}
+		op.Responses["403"] = &Response{Ref: problemRef}
+		roles := "Roles: " + strings.Join(e.Roles, ", ") + "."
+		if op.Description == "" {
+			op.Description = roles
+		} else {
+			op.Description += "\n\n" + roles
+		}
+	}
+	return op
+}
+
+func (g *generator) requestBody(d Doc) *RequestBody {
+	if d.Body == nil && len(d.Files) == 0 {
+		return nil
+	}
+
+	content := map[string]MediaType{}
+	if d.Body != nil {
+		content["application/json"] = MediaType{Schema: g.schema(reflect.TypeOf(d.Body))}
+	}
+	if len(d.Files) > 0 {
+		form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		if d.Body != nil {
+			form = g.object(reflect.TypeOf(d.Body), "form")
+		}
+		for _, name := range d.Files {
+			form.Properties[name] = &Schema{Type: "string", Format: "binary"}
+			if d.Body == nil {
+				form.Required = append(form.Required, name)
+			}
+		}
+		content["multipart/form-data"] = MediaType{Schema: form}
+	}
+	return &RequestBody{Required: true, Content: content}
+}
+
+// envelope is the schema of a success body: a message and fields.
+func (g *generator) envelope(fields Fields) *Schema {
+	s := g.fields(fields)
+	s.Properties["message"] = &Schema{Type: "string", Description: "What happened, in the language of the request."}
+	s.Required = append([]string{"message"}, s.Required...)
+	return s
+}
+
+func (g *generator) fields(fields Fields) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.Properties[name] = g.value(fields[name])
+		s.Required = append(s.Required, name)
+	}
+	return s
+}
+
+func (g *generator) value(v any) *Schema {
+	switch v := v.(type) {
+	case Fields:
+		return g.fields(v)
+	case OneOf:
+		s := &Schema{}
+		for _, alt := range v {
+			s.OneOf = append(s.OneOf, g.value(alt))
+		}
+		return s
+	default:
+		return g.schema(reflect.TypeOf(v))
+	}
+}
+
+// operationID names an operation after its method and path, e.g.
+// "deleteUsersId" for DELETE /users/{id}.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// File is where cmd/openapi writes the document, relative to the module
+// root.
+const File = "delivery/openapi/openapi.json"
+
+// Marshal encodes doc as File holds it: indented, with a final newline.
+func Marshal(doc *Document) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
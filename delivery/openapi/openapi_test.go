@@ -0,0 +1,241 @@
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/internal/frameworks"
+	"github.com/celpung/gocleanarch/delivery/openapi"
+	slider_router "github.com/celpung/gocleanarch/delivery/std/chi/slider/router"
+	user_router "github.com/celpung/gocleanarch/delivery/std/chi/user/router"
+	webhook_router "github.com/celpung/gocleanarch/delivery/std/chi/webhook/router"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the OpenAPI tests from the project root:
+     go test -v ./delivery/openapi
+
+Notes:
+- The committed document is read from this folder. When a change to
+  routes or DTOs makes TestOpenAPI_CommittedDocumentIsCurrent fail, rewrite
+  it from the project root with:
+     go run ./cmd/openapi
+- The chi routers are built on an in-memory database but only walked,
+  never served. Every framework then serves the same route tables with
+  stub handlers, so routing is checked without any use case.
+===============================================================================
+*/
+
+/* TestMain registers the validation rules the request DTOs use, as cmd/openapi does, so the role rule is described. */
+func TestMain(m *testing.M) {
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// setupTestDB opens a migrated in-memory SQLite database for the routers to
+// build their use cases on.
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err, "failed to open in-memory SQLite database")
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, migration.Migrate(context.Background(), db), "failed to migrate schema")
+	return db
+}
+
+/*
+TestOpenAPI_CommittedDocumentIsCurrent verifies that delivery/openapi/openapi.json
+is what the routes and DTOs generate, so the published spec cannot drift.
+*/
+func TestOpenAPI_CommittedDocumentIsCurrent(t *testing.T) {
+	want, err := openapi.Marshal(httpcore.Spec(""))
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(filepath.Join("..", "..", filepath.FromSlash(openapi.File)))
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got), "the committed document is stale; run go run ./cmd/openapi")
+}
+
+/*
+TestOpenAPI_DocumentsEveryRegisteredRoute verifies that the document lists
+exactly the routes the user, slider and webhook routers register, and that
+only routes open to everyone go without the bearer scheme.
+*/
+func TestOpenAPI_DocumentsEveryRegisteredRoute(t *testing.T) {
+	prevDB := database.DB
+	t.Cleanup(func() { database.DB = prevDB })
+	database.DB = setupTestDB(t)
+
+	r := chi.NewRouter()
+	user_router.Router(r)
+	slider_router.Router(r)
+	webhook_router.Router(r)
+
+	var registered []string
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		registered = append(registered, method+" "+route)
+		return nil
+	})
+	require.NoError(t, err)
+
+	doc := httpcore.Spec("")
+	var documented []string
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+			_, secured := op.Responses["401"]
+			require.Equal(t, secured, op.Security != nil, "%s %s", method, path)
+		}
+	}
+	sort.Strings(registered)
+	sort.Strings(documented)
+	require.Equal(t, registered, documented)
+
+	require.Nil(t, doc.Paths["/users/login"]["post"].Security)
+	require.Equal(t, []map[string][]string{{openapi.BearerAuth: {}}}, doc.Paths["/users/{id}"]["delete"].Security)
+	require.Contains(t, doc.Paths["/users/{id}"]["delete"].Description, "Roles: USER.")
+	require.Equal(t, "bearer", doc.Components.SecuritySchemes[openapi.BearerAuth].Scheme)
+}
+
+/*
+TestOpenAPI_EveryFrameworkRoutesTheDocumentedPaths verifies that gin,
+fiber, chi and net/http send each documented operation to its own route,
+with its path parameters, whatever the order and overlap of the paths.
+*/
+func TestOpenAPI_EveryFrameworkRoutesTheDocumentedPaths(t *testing.T) {
+	var routes []httpcore.Route
+	routes = append(routes, (&httpcore.UserHandlers{}).Routes()...)
+	routes = append(routes, (&httpcore.SliderHandlers{}).Routes()...)
+	routes = append(routes, (&httpcore.WebhookHandlers{}).Routes()...)
+	for i, rt := range routes {
+		name := rt.Method + " " + rt.Path
+		routes[i].Roles = nil
+		routes[i].Handler = func(req httpcore.Request) httpcore.Response {
+			return httpcore.JSON(http.StatusOK, map[string]string{"route": name, "id": req.Param("id"), "deliveryID": req.Param("deliveryID")})
+		}
+	}
+	params := regexp.MustCompile(`\{([^}]+)\}`)
+
+	for name, mount := range frameworks.All {
+		t.Run(name, func(t *testing.T) {
+			handler := mount(routes)
+			for path, item := range httpcore.Spec("").Paths {
+				target := params.ReplaceAllString(path, "v-$1")
+				for method := range item {
+					method = strings.ToUpper(method)
+					rec := httptest.NewRecorder()
+					handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+					require.Equal(t, http.StatusOK, rec.Code, "%s %s", method, target)
+
+					var got map[string]string
+					require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+					require.Equal(t, method+" "+path, got["route"])
+					for _, m := range params.FindAllStringSubmatch(path, -1) {
+						require.Equal(t, "v-"+m[1], got[m[1]], "%s %s", method, path)
+					}
+				}
+			}
+		})
+	}
+}
+
+/*
+TestOpenAPI_SchemasFollowValidateTags verifies that required, min, email and
+uuid4 in a DTO's validate tags become the constraints of its schema, and
+that registered rules such as role are described too.
+*/
+func TestOpenAPI_SchemasFollowValidateTags(t *testing.T) {
+	schemas := httpcore.Spec("").Components.Schemas
+
+	create := schemas["UserCreateRequest"]
+	require.Equal(t, []string{"name", "email", "password", "role"}, create.Required)
+	require.Equal(t, "email", create.Properties["email"].Format)
+	require.Equal(t, 8, *create.Properties["password"].MinLength)
+	require.Equal(t, []string{"SUPER", "ADMIN", "USER"}, create.Properties["role"].Enum)
+
+	update := schemas["UserUpdateRequest"]
+	require.Equal(t, []string{"id"}, update.Required)
+	require.Equal(t, "uuid", update.Properties["id"].Format)
+	require.Equal(t, []string{"string", "null"}, update.Properties["password"].Type)
+	require.Equal(t, 1.0, *update.Properties["version"].Minimum)
+
+	reorder := schemas["SliderReorderRequest"].Properties["ids"]
+	require.Equal(t, 1, *reorder.MinItems)
+	require.Equal(t, 1, *reorder.Items.MinLength, "rules after dive constrain the items")
+
+	require.Equal(t, "date-time", schemas["SliderCreateRequest"].Properties["starts_at"].Format)
+	require.Contains(t, schemas["WebhookDeliveryDetailResponse"].Properties, "event_id", "embedded structs add their fields")
+}
+
+/*
+TestOpenAPI_ServedWithSwaggerUI verifies that every framework serves the
+document at /openapi.json, the Swagger UI page at /docs and its vendored
+assets below /docs/.
+*/
+func TestOpenAPI_ServedWithSwaggerUI(t *testing.T) {
+	for name, mount := range frameworks.All {
+		t.Run(name, func(t *testing.T) {
+			handler := mount(httpcore.DocsRoutes("/api"))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+
+			var doc openapi.Document
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+			require.Equal(t, openapi.Version, doc.OpenAPI)
+			require.Equal(t, "/api", doc.Servers[0].URL)
+			require.Contains(t, doc.Paths, "/users/register")
+
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+			require.Contains(t, rec.Body.String(), `url: "/openapi.json"`)
+
+			/* Only the page's own assets are served, and only once vendored. */
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/swagger-ui-bundle.js", nil))
+			if openapi.SwaggerUIAssets == nil {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+				require.Contains(t, string(openapi.SwaggerUI), "swagger-ui-dist@"+openapi.SwaggerUIVersion+"/")
+			} else {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Header().Get("Content-Type"), "javascript")
+				require.Contains(t, string(openapi.SwaggerUI), `src="/docs/swagger-ui-bundle.js"`)
+			}
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.go", nil))
+			require.Equal(t, http.StatusNotFound, rec.Code)
+		})
+	}
+}
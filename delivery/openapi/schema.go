@@ -0,0 +1,264 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var pathParams = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Schema is a JSON Schema as OpenAPI 3.1 uses it. Type is a type name or,
+// for values that may be null, a list of the type and "null".
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+}
+
+// baseType is the type of s without "null".
+func (s *Schema) baseType() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []string:
+		return t[0]
+	}
+	return ""
+}
+
+// RuleFunc constrains the schema of a field by a validation rule with its
+// param, e.g. "8" for min=8.
+type RuleFunc func(s *Schema, param string)
+
+// rules describes the validation rules the document shows. Rules without
+// an entry, such as cross-field ones, only hold on the server.
+var rules = map[string]RuleFunc{
+	"email":    format("email"),
+	"url":      format("uri"),
+	"uri":      format("uri"),
+	"uuid":     format("uuid"),
+	"uuid4":    format("uuid"),
+	"datetime": datetime,
+	"oneof":    func(s *Schema, param string) { s.Enum = strings.Fields(param) },
+	"min":      bound(true, false),
+	"gte":      bound(true, false),
+	"gt":       bound(true, true),
+	"max":      bound(false, false),
+	"lte":      bound(false, false),
+	"lt":       bound(false, true),
+	"len": func(s *Schema, param string) {
+		bound(true, false)(s, param)
+		bound(false, false)(s, param)
+	},
+}
+
+// DescribeRule shows the validation rule tag in the document, for rules
+// registered with the validation package. Like them, it is meant to be
+// called during start-up.
+func DescribeRule(tag string, fn RuleFunc) {
+	rules[tag] = fn
+}
+
+func format(name string) RuleFunc {
+	return func(s *Schema, _ string) { s.Format = name }
+}
+
+func datetime(s *Schema, layout string) {
+	if layout == time.RFC3339 {
+		s.Format = "date-time"
+	} else {
+		s.Description = "Layout " + layout
+	}
+}
+
+// bound sets the lower or upper bound the type of s supports: length for
+// strings, items for arrays, properties for objects and value for numbers.
+func bound(lower, exclusive bool) RuleFunc {
+	return func(s *Schema, param string) {
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		count := int(n)
+		switch s.baseType() {
+		case "string":
+			pick(lower, &s.MinLength, &s.MaxLength, count+excl(exclusive, lower))
+		case "array":
+			pick(lower, &s.MinItems, &s.MaxItems, count+excl(exclusive, lower))
+		case "object":
+			pick(lower, &s.MinProperties, &s.MaxProperties, count+excl(exclusive, lower))
+		case "integer", "number":
+			switch {
+			case exclusive && lower:
+				s.ExclusiveMinimum = &n
+			case exclusive:
+				s.ExclusiveMaximum = &n
+			case lower:
+				s.Minimum = &n
+			default:
+				s.Maximum = &n
+			}
+		}
+	}
+}
+
+func pick(lower bool, min, max **int, n int) {
+	if lower {
+		*min = &n
+	} else {
+		*max = &n
+	}
+}
+
+// excl turns an exclusive count bound into an inclusive one.
+func excl(exclusive, lower bool) int {
+	switch {
+	case !exclusive:
+		return 0
+	case lower:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// generator reflects Go types into schemas, collecting named structs as
+// components.
+type generator struct {
+	schemas map[string]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{schemas: map[string]*Schema{}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of t. Named structs become references to
+// components of the same name.
+func (g *generator) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schema(t.Elem())
+		if typ, ok := s.Type.(string); ok {
+			s.Type = []string{typ, "null"}
+		}
+		return s
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.object(t, "json")
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = &Schema{}
+			*g.schemas[t.Name()] = *g.object(t, "json")
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	return &Schema{}
+}
+
+// object returns the schema of the struct t with its fields named by the
+// struct tag key, "json" or "form". Embedded structs add their fields, as
+// encoding/json does.
+func (g *generator) object(t reflect.Type, key string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(s, t, key)
+	return s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type, key string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.addFields(s, f.Type, key)
+			continue
+		}
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		field, required := g.field(f)
+		s.Properties[name] = field
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// field returns the schema of f constrained by its validate tag and whether
+// f is required. Rules after "dive" apply to the items of a slice or the
+// values of a map, where required means a non-empty string.
+func (g *generator) field(f reflect.StructField) (*Schema, bool) {
+	s := g.schema(f.Type)
+	tags := strings.Split(f.Tag.Get("validate"), ",")
+
+	target, required := s, false
+	for _, tag := range tags {
+		rule, param, _ := strings.Cut(tag, "=")
+		switch rule {
+		case "", "omitempty":
+		case "required":
+			if target != s && target.baseType() == "string" {
+				one := 1
+				target.MinLength = &one
+			}
+			required = required || target == s
+		case "dive":
+			switch {
+			case target.Items != nil:
+				target = target.Items
+			case target.AdditionalProperties != nil:
+				target = target.AdditionalProperties
+			}
+		default:
+			if fn, ok := rules[rule]; ok && target.Ref == "" {
+				fn(target, param)
+			}
+		}
+	}
+	return s, required
+}
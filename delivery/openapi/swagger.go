@@ -0,0 +1,50 @@
+package openapi
+
+import (
+	"embed"
+	"io/fs"
+	"strings"
+)
+
+// SwaggerUIVersion is the swagger-ui-dist release the docs page uses.
+const SwaggerUIVersion = "5.17.14"
+
+// SwaggerUIDir is where cmd/swaggerui vendors the Swagger UI assets,
+// relative to the module root.
+const SwaggerUIDir = "delivery/openapi/swaggerui"
+
+// SwaggerUIFiles are the assets of the docs page.
+var SwaggerUIFiles = []string{"swagger-ui.css", "swagger-ui-bundle.js"}
+
+//go:embed swagger.html
+var swaggerPage string
+
+//go:embed swaggerui
+var swaggerUI embed.FS
+
+// SwaggerUIAssets holds the vendored SwaggerUIFiles, served below /docs/.
+// It is nil until cmd/swaggerui has vendored them.
+var SwaggerUIAssets fs.FS
+
+// SwaggerUI is a page that browses the document served at /openapi.json.
+// It loads the vendored assets from /docs/, or the pinned release from
+// unpkg while none are vendored.
+var SwaggerUI []byte
+
+func init() {
+	assets := "https://unpkg.com/swagger-ui-dist@" + SwaggerUIVersion + "/"
+	if dir, err := fs.Sub(swaggerUI, "swaggerui"); err == nil && vendored(dir) {
+		SwaggerUIAssets = dir
+		assets = "/docs/"
+	}
+	SwaggerUI = []byte(strings.ReplaceAll(swaggerPage, "{{assets}}", assets))
+}
+
+func vendored(dir fs.FS) bool {
+	for _, name := range SwaggerUIFiles {
+		if _, err := fs.Stat(dir, name); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+// Package problem renders errors as RFC 7807 problem details, the body every
+// delivery answers failed requests with.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/celpung/gocleanarch/application/apperror"
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// typePrefix starts the type of every problem; the rest names its kind,
+// e.g. "/problems/not-found".
+const typePrefix = "/problems/"
+
+// Problem is an RFC 7807 problem details object. Errors lists the fields at
+// fault for validation problems.
+type Problem struct {
+	Type   string                `json:"type"`
+	Title  string                `json:"title"`
+	Status int                   `json:"status"`
+	Detail string                `json:"detail,omitempty"`
+	Errors []apperror.FieldError `json:"errors,omitempty"`
+}
+
+// kinds maps error kinds to their status and type name, most specific
+// first.
+var kinds = []struct {
+	kind   error
+	status int
+	name   string
+}{
+	{apperror.ErrNotFound, http.StatusNotFound, "not-found"},
+	{apperror.ErrConflict, http.StatusConflict, "conflict"},
+	{apperror.ErrInvalid, http.StatusBadRequest, "invalid-input"},
+	{apperror.ErrTooLarge, http.StatusRequestEntityTooLarge, "too-large"},
+	{apperror.ErrInvalidCredentials, http.StatusUnauthorized, "invalid-credentials"},
+	{apperror.ErrInactive, http.StatusForbidden, "inactive"},
+	{apperror.ErrUnauthenticated, http.StatusUnauthorized, "unauthenticated"},
+	{apperror.ErrPreconditionRequired, http.StatusPreconditionRequired, "precondition-required"},
+	{apperror.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition-failed"},
+}
+
+// Status returns the HTTP status for err: the one of its kind, 413 for
+// bodies over a http.MaxBytesReader limit and 500 otherwise.
+func Status(err error) int {
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.status
+		}
+	}
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+// From describes err under title, with the status of its kind.
+func From(title string, err error) Problem {
+	return New(Status(err), title, err)
+}
+
+// New describes a failure with status under title. err, which may be nil,
+// becomes the detail; for server errors it is logged instead, so internals
+// do not reach clients.
+func New(status int, title string, err error) Problem {
+	p := Problem{Type: typePrefix + typeName(status, err), Title: title, Status: status}
+	if err == nil {
+		return p
+	}
+
+	if status >= http.StatusInternalServerError {
+		log.Printf("%s: %v", title, err)
+		return p
+	}
+
+	p.Detail = err.Error()
+	var invalid *apperror.ValidationError
+	if errors.As(err, &invalid) {
+		p.Errors = invalid.Fields
+	}
+	return p
+}
+
+// typeName names the kind of err, or the status when err has none.
+func typeName(status int, err error) string {
+	for _, k := range kinds {
+		if k.status == status && errors.Is(err, k.kind) {
+			return k.name
+		}
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "-")
+}
+
+// Localize returns p with its title in the locale of ctx. Details read as
+// the errors they come from; field errors are localized by validation.
+func (p Problem) Localize(ctx context.Context) Problem {
+	p.Title = i18n.T(ctx, p.Title)
+	return p
+}
+
+// Write sends p, localized for r.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	p = p.Localize(r.Context())
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, p.Title, p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("failed to write problem: %v", err)
+	}
+}
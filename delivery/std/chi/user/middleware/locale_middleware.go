@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+)
+
+// LocaleMiddleware picks the response language from Accept-Language, names
+// it in Content-Language and stores it in the request context.
+func LocaleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := i18n.Negotiate(r.Header.Get(i18n.Header))
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", i18n.Header)
+		next.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), locale)))
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDMiddleware stores the request ID in the request context and echoes
+// it in the response. It reuses the ID assigned by chi's RequestID middleware
+// when that runs first, so logs and use cases see the same value.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chimw.GetReqID(r.Context())
+		if id == "" {
+			id = requestctx.RequestID(r.Header.Get(requestctx.Header))
+		}
+		w.Header().Set(requestctx.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestctx.WithRequestID(r.Context(), id)))
+	})
+}
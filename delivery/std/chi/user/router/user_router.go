@@ -3,12 +3,21 @@ package user_router
 import (
 	"github.com/go-chi/chi/v5"
 
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
 	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
 	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
-	delivery_impl "github.com/celpung/gocleanarch/delivery/std/chi/user/impl"
-	"github.com/celpung/gocleanarch/delivery/std/chi/user/middleware"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/std/chi/adapter"
 	"github.com/celpung/gocleanarch/infrastructure/auth"
-	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
 )
 
 // Router mendaftarkan semua route user ke router utama
@@ -16,24 +25,26 @@ func Router(r chi.Router) {
 	passwordService := auth.NewPasswordService()
 	jwtService := auth.NewJwtService()
 
-	repository := repository_impl.NewUserRepository(mysql.DB)
-	usecase := usecase_impl.NewUserUsecase(repository, passwordService, jwtService)
-	delivery := delivery_impl.NewUserDelivery(usecase)
-
-	r.Route("/users", func(r chi.Router) {
-		r.Post("/register", delivery.Register)
-		r.Post("/login", delivery.Login)
-
-		r.Group(func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware(middleware.Admin, middleware.Super))
-			r.Get("/", delivery.GetAllUserData)
-			r.Delete("/{id}", delivery.DeleteUser)
-			r.Get("/search", delivery.SearchUser)
-		})
-
-		r.Group(func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware(middleware.User, middleware.Admin, middleware.Super))
-			r.Patch("/update", delivery.UpdateUser)
-		})
+	repository := repository_impl.NewCachedUserRepository(repository_impl.NewUserRepository(database.DB), cache_impl.Shared, cache_impl.TTL)
+	searcher := search_impl.NewUserSearcher(database.DB)
+	index := index_impl.NewUserIndex(searchindex.Users)
+	events := event_impl.NewInProcessPublisher(index)
+	userOutbox := event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(database.DB))
+	unitOfWork := uow_impl.NewGormUnitOfWork(database.DB)
+	usecase := usecase_impl.NewUserUsecase(usecase_impl.UserUsecaseDeps{
+		Repo:            repository,
+		Searcher:        searcher,
+		Index:           index,
+		Events:          events,
+		Outbox:          userOutbox,
+		UoW:             unitOfWork,
+		PasswordService: passwordService,
+		JWTService:      jwtService,
+		Storage:         storage_impl.Shared,
+		Inspector:       checker.Shared,
+		MaxAvatarSize:   storage_impl.MaxUploadSize,
 	})
+	handlers := httpcore.NewUserHandlers(usecase, storage_impl.MaxUploadSize)
+
+	adapter.Register(r, handlers.Routes())
 }
@@ -0,0 +1,17 @@
+package webhook_router
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/std/chi/adapter"
+)
+
+// Router serves the webhook API to staff with the use case set up by
+// dispatcher_impl.ConnectWebhooks.
+func Router(r chi.Router) {
+	handlers := httpcore.NewWebhookHandlers(dispatcher_impl.Shared)
+
+	adapter.Register(r, handlers.Routes())
+}
@@ -0,0 +1,77 @@
+// Package adapter serves httpcore handlers with net/http.
+package adapter
+
+import (
+	"context"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/problem"
+)
+
+type request struct {
+	w http.ResponseWriter
+	r *http.Request
+}
+
+func (r request) Context() context.Context  { return r.r.Context() }
+func (r request) Param(name string) string  { return r.r.PathValue(name) }
+func (r request) Header(name string) string { return r.r.Header.Get(name) }
+func (r request) Body() io.Reader           { return r.r.Body }
+
+func (r request) Query(name string) (string, bool) {
+	values, ok := r.r.URL.Query()[name]
+	if !ok || len(values) == 0 {
+		return "", ok
+	}
+	return values[0], true
+}
+
+func (r request) MultipartForm(maxBytes int64) (*multipart.Form, error) {
+	r.r.Body = http.MaxBytesReader(r.w, r.r.Body, maxBytes)
+	if err := r.r.ParseMultipartForm(maxBytes); err != nil {
+		return nil, err
+	}
+	return r.r.MultipartForm, nil
+}
+
+// Handle serves h.
+func Handle(h httpcore.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, h(request{w: w, r: r}))
+	}
+}
+
+// Write sends res, localized for r.
+func Write(w http.ResponseWriter, r *http.Request, res httpcore.Response) {
+	res = res.Localize(r.Context())
+	for name, value := range res.Headers {
+		w.Header().Set(name, value)
+	}
+
+	body, err := res.Encode()
+	if err != nil {
+		problem.Write(w, r, problem.New(http.StatusInternalServerError, "Failed to encode response", err))
+		return
+	}
+	if body == nil {
+		w.WriteHeader(res.Status)
+		return
+	}
+	w.Header().Set("Content-Type", res.MediaType())
+	w.WriteHeader(res.Status)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+// Register adds routes to mux with method patterns such as
+// "GET /users/{id}".
+func Register(mux *http.ServeMux, routes []httpcore.Route) {
+	for _, route := range routes {
+		mux.HandleFunc(route.Method+" "+route.Path, Handle(route.Serve))
+	}
+}
@@ -0,0 +1,26 @@
+package slider_router
+
+import (
+	"net/http"
+
+	repository_impl "github.com/celpung/gocleanarch/application/slider/impl/repository"
+	usecase_impl "github.com/celpung/gocleanarch/application/slider/impl/usecase"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/std/http/adapter"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+)
+
+// Router serves the active sliders to everyone and lets staff manage,
+// schedule and reorder them. Uploads are inspected by checker.Shared and
+// go to the storage set up by storage_impl.ConnectStorage.
+func Router() {
+	repository := repository_impl.NewSliderRepository(database.DB)
+	unitOfWork := uow_impl.NewGormUnitOfWork(database.DB)
+	usecase := usecase_impl.NewSliderUsecase(repository, unitOfWork, storage_impl.Shared, checker.Shared, storage_impl.MaxUploadSize)
+	handlers := httpcore.NewSliderHandlers(usecase, storage_impl.MaxUploadSize)
+
+	adapter.Register(http.DefaultServeMux, handlers.Routes())
+}
@@ -2,22 +2,19 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"net/http"
-	"strings"
-	"time"
 
-	"github.com/celpung/gocleanarch/infrastructure/environment"
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/std/http/adapter"
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
 )
 
-type Role string
+type Role = httpcore.Role
 
 const (
-	Super Role = "SUPER"
-	Admin Role = "ADMIN"
-	User  Role = "USER"
+	Super = httpcore.Super
+	Admin = httpcore.Admin
+	User  = httpcore.User
 )
 
 type contextKey string
@@ -28,73 +25,24 @@ const (
 	ContextKeyRole   contextKey = "role"
 )
 
-type Claims struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
-	jwt.RegisteredClaims
-}
-
-func writeJSONError(w http.ResponseWriter, status int, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(map[string]any{"message": msg})
-}
-
-func getBearerToken(r *http.Request) (string, error) {
-	h := strings.TrimSpace(r.Header.Get("Authorization"))
-	if h == "" {
-		return "", errors.New("missing Authorization header")
-	}
-	parts := strings.Fields(h) // e.g. ["Bearer", "<token>"]
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return "", errors.New("invalid Authorization format")
-	}
-	return parts[1], nil
-}
-
-func AuthMiddleware(requiredRole Role, next http.HandlerFunc) http.HandlerFunc {
-	secret := []byte(environment.Env.JWT_SECRET)
-
-	return func(w http.ResponseWriter, r *http.Request) {
-		tokStr, err := getBearerToken(r)
-		if err != nil {
-			writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
-			return
-		}
-
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokStr, claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
+// AuthMiddleware lets requests through whose bearer token carries one of
+// roles, or any role when none are given.
+func AuthMiddleware(roles ...Role) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, err := httpcore.Authenticate(r.Header.Get("Authorization"), roles...)
+			if err != nil {
+				adapter.Write(w, r, httpcore.Denied(err))
+				return
 			}
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
-			writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
-			return
-		}
 
-		if claims.ExpiresAt != nil && !claims.ExpiresAt.After(time.Now().Add(-30*time.Second)) {
-			writeJSONError(w, http.StatusUnauthorized, "Token expired")
-			return
-		}
-		if claims.NotBefore != nil && claims.NotBefore.After(time.Now().Add(30*time.Second)) {
-			writeJSONError(w, http.StatusUnauthorized, "Token not valid yet")
-			return
-		}
+			ctx := context.WithValue(r.Context(), ContextKeyUserID, principal.UserID)
+			ctx = context.WithValue(ctx, ContextKeyEmail, principal.Email)
+			ctx = context.WithValue(ctx, ContextKeyRole, principal.Role)
+			ctx = requestctx.WithPrincipal(ctx, principal)
 
-		userRole := Role(strings.ToUpper(strings.TrimSpace(claims.Role)))
-		if userRole != requiredRole {
-			writeJSONError(w, http.StatusForbidden, "Forbidden access: Unauthorized")
-			return
+			next(w, r.WithContext(ctx))
 		}
-
-		ctx := context.WithValue(r.Context(), ContextKeyUserID, claims.ID)
-		ctx = context.WithValue(ctx, ContextKeyEmail, claims.Email)
-		ctx = context.WithValue(ctx, ContextKeyRole, string(userRole))
-
-		next(w, r.WithContext(ctx))
 	}
 }
 
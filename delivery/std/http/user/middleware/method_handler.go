@@ -1,16 +0,0 @@
-package middleware
-
-import (
-	"net/http"
-)
-
-func MethodHandler(method string, next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != method {
-			w.Header().Set("Allow", method)
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		next(w, r)
-	}
-}
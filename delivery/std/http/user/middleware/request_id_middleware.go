@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/celpung/gocleanarch/infrastructure/requestctx"
+)
+
+// RequestIDMiddleware reuses the caller's X-Request-ID or generates one,
+// echoes it in the response and stores it in the request context.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestctx.RequestID(r.Header.Get(requestctx.Header))
+		w.Header().Set(requestctx.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestctx.WithRequestID(r.Context(), id)))
+	})
+}
@@ -3,26 +3,47 @@ package user_router
 import (
 	"net/http"
 
+	event_impl "github.com/celpung/gocleanarch/application/user/impl/event"
+	index_impl "github.com/celpung/gocleanarch/application/user/impl/index"
 	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
 	usecase_impl "github.com/celpung/gocleanarch/application/user/impl/usecase"
-	delivery_impl "github.com/celpung/gocleanarch/delivery/std/http/user/impl"
-	"github.com/celpung/gocleanarch/delivery/std/http/user/middleware"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/std/http/adapter"
 	"github.com/celpung/gocleanarch/infrastructure/auth"
-	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	cache_impl "github.com/celpung/gocleanarch/infrastructure/cache/impl"
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	outbox_impl "github.com/celpung/gocleanarch/infrastructure/outbox/impl"
+	"github.com/celpung/gocleanarch/infrastructure/searchindex"
+	storage_impl "github.com/celpung/gocleanarch/infrastructure/storage/impl"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
 )
 
 func Router() {
 	passwordService := auth.NewPasswordService()
 	jwtService := auth.NewJwtService()
 
-	repository := repository_impl.NewUserRepository(mysql.DB)
-	usecase := usecase_impl.NewUserUsecase(repository, passwordService, jwtService)
-	delivery := delivery_impl.NewUserDelivery(usecase)
+	repository := repository_impl.NewCachedUserRepository(repository_impl.NewUserRepository(database.DB), cache_impl.Shared, cache_impl.TTL)
+	searcher := search_impl.NewUserSearcher(database.DB)
+	index := index_impl.NewUserIndex(searchindex.Users)
+	events := event_impl.NewInProcessPublisher(index)
+	userOutbox := event_impl.NewOutboxRecorder(outbox_impl.NewGormStore(database.DB))
+	unitOfWork := uow_impl.NewGormUnitOfWork(database.DB)
+	usecase := usecase_impl.NewUserUsecase(usecase_impl.UserUsecaseDeps{
+		Repo:            repository,
+		Searcher:        searcher,
+		Index:           index,
+		Events:          events,
+		Outbox:          userOutbox,
+		UoW:             unitOfWork,
+		PasswordService: passwordService,
+		JWTService:      jwtService,
+		Storage:         storage_impl.Shared,
+		Inspector:       checker.Shared,
+		MaxAvatarSize:   storage_impl.MaxUploadSize,
+	})
+	handlers := httpcore.NewUserHandlers(usecase, storage_impl.MaxUploadSize)
 
-	http.HandleFunc("/users/register", middleware.MethodHandler(http.MethodPost, delivery.Register))
-	http.HandleFunc("/users/login", middleware.MethodHandler(http.MethodPost, delivery.Login))
-	http.HandleFunc("/users", middleware.MethodHandler(http.MethodGet, middleware.AuthMiddleware(middleware.Admin, delivery.GetAllUserData)))
-	http.HandleFunc("/search", middleware.MethodHandler(http.MethodGet, middleware.AuthMiddleware(middleware.Admin, delivery.SearchUser)))
-	http.HandleFunc("/users/update", middleware.MethodHandler(http.MethodPatch, middleware.AuthMiddleware(middleware.User, delivery.UpdateUser)))
-	http.HandleFunc("/users/delete", middleware.MethodHandler(http.MethodDelete, middleware.AuthMiddleware(middleware.Admin, delivery.DeleteUser)))
+	adapter.Register(http.DefaultServeMux, handlers.Routes())
 }
@@ -1,12 +0,0 @@
-package delivery
-
-import "net/http"
-
-type UserDelivery interface {
-	Register(w http.ResponseWriter, r *http.Request)
-	Login(w http.ResponseWriter, r *http.Request)
-	GetAllUserData(w http.ResponseWriter, r *http.Request)
-	SearchUser(w http.ResponseWriter, r *http.Request)
-	UpdateUser(w http.ResponseWriter, r *http.Request)
-	DeleteUser(w http.ResponseWriter, r *http.Request)
-}
@@ -0,0 +1,17 @@
+package webhook_router
+
+import (
+	"net/http"
+
+	dispatcher_impl "github.com/celpung/gocleanarch/application/webhook/impl/dispatcher"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/delivery/std/http/adapter"
+)
+
+// Router serves the webhook API to staff with the use case set up by
+// dispatcher_impl.ConnectWebhooks.
+func Router() {
+	handlers := httpcore.NewWebhookHandlers(dispatcher_impl.Shared)
+
+	adapter.Register(http.DefaultServeMux, handlers.Routes())
+}
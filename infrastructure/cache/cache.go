@@ -0,0 +1,17 @@
+// Package cache defines the byte oriented key/value cache that repository
+// decorators use, independent of where entries are stored.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores opaque values under string keys for at most a TTL. A miss is
+// reported with ok false and a nil error; errors are reserved for a broken
+// backend, which callers treat as a miss.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
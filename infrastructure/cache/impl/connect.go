@@ -0,0 +1,64 @@
+package cache_impl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/cache"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/redis/go-redis/v9"
+)
+
+// Shared is the cache chosen by CACHE_DRIVER; nil when caching is off.
+var Shared cache.Cache
+
+// TTL is how long entries in Shared live, from CACHE_TTL.
+var TTL time.Duration
+
+// ConnectCache sets Shared from the environment. CACHE_DRIVER selects
+// "memory" (per process, the default), "redis" (shared by all instances,
+// at REDIS_ADDR) or "none". With several instances behind a load balancer
+// use redis, or a short CACHE_TTL: an in-process cache only learns about
+// the writes its own instance makes.
+func ConnectCache() error {
+	ttl, err := time.ParseDuration(environment.Env.CACHE_TTL)
+	if err != nil || ttl < 0 {
+		return fmt.Errorf("CACHE_TTL must be a duration such as 30s or 5m, got %q", environment.Env.CACHE_TTL)
+	}
+	TTL = ttl
+
+	switch strings.ToLower(environment.Env.CACHE_DRIVER) {
+	case "", "none", "off":
+		Shared = nil
+	case "memory":
+		size, err := strconv.Atoi(environment.Env.CACHE_SIZE)
+		if err != nil {
+			return fmt.Errorf("CACHE_SIZE must be an integer, got %q", environment.Env.CACHE_SIZE)
+		}
+		Shared = NewLRUCache(size)
+	case "redis":
+		db, err := strconv.Atoi(environment.Env.REDIS_DB)
+		if err != nil {
+			return fmt.Errorf("REDIS_DB must be an integer, got %q", environment.Env.REDIS_DB)
+		}
+		client := redis.NewClient(&redis.Options{
+			Addr:     environment.Env.REDIS_ADDR,
+			Password: environment.Env.REDIS_PASSWORD,
+			DB:       db,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return fmt.Errorf("failed to connect redis at %s: %w", environment.Env.REDIS_ADDR, err)
+		}
+		Shared = NewRedisCache(client, environment.Env.CACHE_PREFIX)
+	default:
+		return fmt.Errorf("unsupported CACHE_DRIVER %q (use memory, redis or none)", environment.Env.CACHE_DRIVER)
+	}
+	return nil
+}
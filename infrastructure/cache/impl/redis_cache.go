@@ -0,0 +1,51 @@
+package cache_impl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache stores entries in Redis, or any server speaking its protocol,
+// so every instance of the application shares them. Prefix namespaces the
+// keys when the server is shared with other applications.
+type RedisCache struct {
+	Client redis.UniversalClient
+	Prefix string
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.Client.Get(ctx, c.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value with a ttl; zero keeps it until Redis evicts it.
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.Client.Set(ctx, c.Prefix+key, value, ttl).Err()
+}
+
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.Prefix + key
+	}
+	return c.Client.Del(ctx, prefixed...).Err()
+}
+
+func NewRedisCache(client redis.UniversalClient, prefix string) *RedisCache {
+	return &RedisCache{Client: client, Prefix: prefix}
+}
+
+var _ cache.Cache = (*RedisCache)(nil)
@@ -0,0 +1,187 @@
+// Package checker inspects uploaded files before they are stored. Every
+// use site describes what it accepts in a Policy, and an Inspector checks
+// files against it: the content must start with the magic number of an
+// allowed type that matches the file extension, stay within the size and
+// image dimension limits, and pass the optional virus scanner.
+package checker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+// ErrRejected is wrapped by every error that means the file itself is not
+// acceptable, as opposed to a failure while checking it, such as a virus
+// scanner that cannot be reached.
+var ErrRejected = errors.New("file rejected")
+
+var (
+	ErrTooLarge           error = rejection("file is too large")
+	ErrUnsupportedType    error = rejection("file type is not allowed")
+	ErrExtensionMismatch  error = rejection("file extension does not match its content")
+	ErrDimensionsTooLarge error = rejection("image dimensions are too large")
+	ErrDecompressionBomb  error = rejection("image has too many pixels")
+	ErrCorrupt            error = rejection("file is corrupt")
+	ErrInfected           error = rejection("file is infected")
+)
+
+type rejection string
+
+func (r rejection) Error() string { return string(r) }
+
+func (rejection) Unwrap() error { return ErrRejected }
+
+// FileType is a kind of file a Policy can allow. Match reports whether
+// content starts with its magic number.
+type FileType struct {
+	MIME       string
+	Extensions []string
+	Image      bool
+	Match      func(content []byte) bool
+}
+
+var (
+	JPEG = FileType{MIME: "image/jpeg", Extensions: []string{".jpg", ".jpeg"}, Image: true, Match: prefix("\xff\xd8\xff")}
+	PNG  = FileType{MIME: "image/png", Extensions: []string{".png"}, Image: true, Match: prefix("\x89PNG\r\n\x1a\n")}
+	GIF  = FileType{MIME: "image/gif", Extensions: []string{".gif"}, Image: true, Match: func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte("GIF87a")) || bytes.HasPrefix(b, []byte("GIF89a"))
+	}}
+	WebP = FileType{MIME: "image/webp", Extensions: []string{".webp"}, Image: true, Match: func(b []byte) bool {
+		return len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WEBP"
+	}}
+	PDF = FileType{MIME: "application/pdf", Extensions: []string{".pdf"}, Match: prefix("%PDF-")}
+)
+
+// Policy is what one use site accepts. Zero limits are not checked, except
+// MaxSize, which is required. MaxPixels guards against decompression bombs:
+// small files that decode into huge images.
+type Policy struct {
+	Name      string
+	Types     []FileType
+	MaxSize   int64
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int
+	// StripMetadata removes EXIF, XMP, IPTC, comments and text chunks from
+	// images without re-encoding them. JPEG orientation is part of EXIF,
+	// so rotated photos are stored as the camera recorded them.
+	StripMetadata bool
+}
+
+// File is an upload that passed inspection. Content has its metadata
+// removed when the policy asks for it.
+type File struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+	Content     []byte
+}
+
+// Scanner looks for malware. Scan returns an error wrapping ErrInfected
+// when content is infected, and other errors when it could not scan.
+type Scanner interface {
+	Scan(ctx context.Context, content io.Reader) error
+}
+
+// Inspector checks files against policies. Without a Scanner, files are
+// not scanned for malware.
+type Inspector struct {
+	Scanner Scanner
+}
+
+// Inspect reads at most policy.MaxSize bytes of content and checks them.
+// size is the length the client announced, so oversized uploads are
+// rejected before they are read.
+func (i *Inspector) Inspect(ctx context.Context, policy Policy, filename string, content io.Reader, size int64) (*File, error) {
+	if size > policy.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	/* The announced size comes from the client, so count the bytes actually read as well. */
+	data, err := io.ReadAll(io.LimitReader(content, policy.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > policy.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	idx := slices.IndexFunc(policy.Types, func(t FileType) bool { return t.Match(data) })
+	if idx < 0 {
+		return nil, fmt.Errorf("%w for %s", ErrUnsupportedType, policy.Name)
+	}
+	kind := policy.Types[idx]
+	if !slices.Contains(kind.Extensions, strings.ToLower(filepath.Ext(filename))) {
+		return nil, fmt.Errorf("%w: %q is %s", ErrExtensionMismatch, filepath.Ext(filename), kind.MIME)
+	}
+
+	file := &File{Filename: filename, ContentType: kind.MIME, Size: int64(len(data)), Content: data}
+
+	if kind.Image {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+		}
+		if cfg.Width <= 0 || cfg.Height <= 0 {
+			return nil, fmt.Errorf("%w: %dx%d", ErrCorrupt, cfg.Width, cfg.Height)
+		}
+		if (policy.MaxWidth > 0 && cfg.Width > policy.MaxWidth) || (policy.MaxHeight > 0 && cfg.Height > policy.MaxHeight) {
+			return nil, fmt.Errorf("%w: %dx%d", ErrDimensionsTooLarge, cfg.Width, cfg.Height)
+		}
+		if policy.MaxPixels > 0 && cfg.Width*cfg.Height > policy.MaxPixels {
+			return nil, fmt.Errorf("%w: %dx%d", ErrDecompressionBomb, cfg.Width, cfg.Height)
+		}
+		file.Width, file.Height = cfg.Width, cfg.Height
+
+		if policy.StripMetadata {
+			stripped, err := StripMetadata(kind, data)
+			if err != nil {
+				return nil, err
+			}
+			file.Content, file.Size = stripped, int64(len(stripped))
+		}
+	}
+
+	/* Scan what the client sent, metadata included. */
+	if i.Scanner != nil {
+		if err := i.Scanner.Scan(ctx, bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+// InspectFileHeader inspects a multipart file part.
+func (i *Inspector) InspectFileHeader(ctx context.Context, policy Policy, header *multipart.FileHeader) (*File, error) {
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return i.Inspect(ctx, policy, header.Filename, f, header.Size)
+}
+
+func prefix(magic string) func([]byte) bool {
+	return func(b []byte) bool { return bytes.HasPrefix(b, []byte(magic)) }
+}
+
+func NewInspector(scanner Scanner) *Inspector {
+	return &Inspector{Scanner: scanner}
+}
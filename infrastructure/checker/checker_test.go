@@ -0,0 +1,384 @@
+package checker_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/checker"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide (Windows / macOS / Linux)
+
+1) Run all tests in this package from the folder containing this file:
+     go test -v .
+
+2) Run a specific test using a regex:
+     go test -v -run ^TestUploadInspection_ .
+     go test -v -run ^TestClamdScanner_ .
+
+Notes:
+- Virus scanning talks to a fake clamd on 127.0.0.1, so no ClamAV install
+  is needed. It reports the EICAR test string as infected.
+- Images with metadata are built in memory; no fixtures are needed.
+===============================================================================
+*/
+
+// eicar is the standard antivirus test string.
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// secret stands in for metadata that must not survive an upload.
+const secret = "GPS 52.5200N 13.4050E"
+
+// tinyWebP is a 1x1 lossless WebP.
+const tinyWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+// fakeClamd answers the zPING and zINSTREAM commands like clamd does and
+// returns its address.
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveClamd(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	switch command {
+	case "zPING\x00":
+		io.WriteString(conn, "PONG\x00")
+	case "zINSTREAM\x00":
+		var stream bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&stream, r, int64(size)); err != nil {
+				return
+			}
+		}
+		if bytes.Contains(stream.Bytes(), []byte(eicar)) {
+			io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+			return
+		}
+		io.WriteString(conn, "stream: OK\x00")
+	default:
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+	}
+}
+
+const testUploadLimit = 1 << 20
+
+// imagePolicy accepts the common image formats, like the slider and avatar
+// policies do.
+func imagePolicy() checker.Policy {
+	return checker.Policy{
+		Name:          "images",
+		Types:         []checker.FileType{checker.JPEG, checker.PNG, checker.GIF, checker.WebP},
+		MaxSize:       testUploadLimit,
+		MaxWidth:      10_000,
+		MaxHeight:     10_000,
+		MaxPixels:     40_000_000,
+		StripMetadata: true,
+	}
+}
+
+// pngImage encodes a width x height PNG.
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// pngChunk encodes one PNG chunk with its checksum.
+func pngChunk(kind string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// pngHeader is the start of a PNG claiming width x height pixels, enough
+// for image.DecodeConfig but far smaller than the image it announces.
+func pngHeader(width, height int) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, uint32(width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(height))
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+	return append([]byte("\x89PNG\r\n\x1a\n"), pngChunk("IHDR", ihdr)...)
+}
+
+// jpegSegment encodes one JPEG marker segment.
+func jpegSegment(marker byte, data string) []byte {
+	segment := []byte{0xff, marker}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(data)+2))
+	return append(segment, data...)
+}
+
+// riffChunk encodes one WebP chunk, padded to an even length.
+func riffChunk(kind string, data []byte) []byte {
+	chunk := append([]byte(kind), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// withMetadata returns a filename and content for each image format, each
+// carrying secret in its metadata.
+func withMetadata(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := range 20 {
+		for x := range 40 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 12), B: 90, A: 255})
+		}
+	}
+
+	var jpg bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpg, img, nil))
+	withExif := append([]byte{0xff, 0xd8}, jpegSegment(0xe1, "Exif\x00\x00"+secret)...)
+	withExif = append(withExif, jpegSegment(0xe2, "ICC_PROFILE\x00\x01\x01profile")...)
+	withExif = append(withExif, jpegSegment(0xfe, secret)...)
+	withExif = append(withExif, jpg.Bytes()[2:]...)
+	/* Data after the end of the image is a favourite hiding place. */
+	withExif = append(withExif, "<?php "+secret+" ?>"...)
+
+	plain := pngImage(t, 40, 20)
+	withText := append([]byte(nil), plain[:33]...)
+	withText = append(withText, pngChunk("tEXt", []byte("Comment\x00"+secret))...)
+	withText = append(withText, pngChunk("eXIf", []byte(secret))...)
+	withText = append(withText, plain[33:]...)
+
+	var animated bytes.Buffer
+	require.NoError(t, gif.Encode(&animated, img, nil))
+	g := animated.Bytes()
+	withComment := append([]byte(nil), g[:len(g)-1]...)
+	withComment = append(withComment, 0x21, 0xfe, byte(len(secret)))
+	withComment = append(withComment, secret...)
+	withComment = append(withComment, 0x00, 0x3b)
+
+	sample, err := base64.StdEncoding.DecodeString(tinyWebP)
+	require.NoError(t, err)
+	/* VP8X with the EXIF flag and a 1x1 canvas, stored as width-1 and height-1. */
+	vp8x := riffChunk("VP8X", []byte{0x08, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	body := append([]byte("WEBP"), vp8x...)
+	body = append(body, sample[12:]...)
+	body = append(body, riffChunk("EXIF", []byte(secret))...)
+	webp := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	webp = append(webp, body...)
+
+	return map[string][]byte{
+		"photo.jpg":  withExif,
+		"photo.png":  withText,
+		"photo.gif":  withComment,
+		"photo.webp": webp,
+	}
+}
+
+/*
+TestUploadInspection_ChecksContentAgainstPolicy verifies that the magic
+number decides the type, that it must match the extension, and that the
+size, dimension and pixel limits of the policy apply. Another policy can
+allow other types.
+*/
+func TestUploadInspection_ChecksContentAgainstPolicy(t *testing.T) {
+	ctx := context.Background()
+	inspector := checker.NewInspector(nil)
+	policy := imagePolicy()
+	inspect := func(filename string, content []byte) (*checker.File, error) {
+		return inspector.Inspect(ctx, policy, filename, bytes.NewReader(content), int64(len(content)))
+	}
+
+	file, err := inspect("photo.PNG", pngImage(t, 64, 32))
+	require.NoError(t, err)
+	require.Equal(t, "image/png", file.ContentType)
+	require.Equal(t, 64, file.Width)
+	require.Equal(t, 32, file.Height)
+
+	_, err = inspect("photo.jpg", pngImage(t, 64, 32))
+	require.ErrorIs(t, err, checker.ErrExtensionMismatch)
+
+	_, err = inspect("photo.png", []byte("GIF89a"))
+	require.ErrorIs(t, err, checker.ErrExtensionMismatch)
+
+	_, err = inspect("photo.gif", []byte("GIF89a"))
+	require.ErrorIs(t, err, checker.ErrCorrupt)
+
+	_, err = inspect("report.pdf", []byte("%PDF-1.7 quarterly report"))
+	require.ErrorIs(t, err, checker.ErrUnsupportedType)
+
+	_, err = inspect("photo.png", pngHeader(12_000, 10))
+	require.ErrorIs(t, err, checker.ErrDimensionsTooLarge)
+
+	/* 9000x9000 fits the width and height limits but is 81 megapixels in 41 bytes. */
+	_, err = inspect("bomb.png", pngHeader(9000, 9000))
+	require.ErrorIs(t, err, checker.ErrDecompressionBomb)
+
+	_, err = inspector.Inspect(ctx, policy, "photo.png", bytes.NewReader(pngImage(t, 4, 4)), testUploadLimit+1)
+	require.ErrorIs(t, err, checker.ErrTooLarge)
+
+	/* Every rejection above is one kind of error for callers. */
+	for _, rejected := range []error{checker.ErrTooLarge, checker.ErrUnsupportedType, checker.ErrExtensionMismatch,
+		checker.ErrDimensionsTooLarge, checker.ErrDecompressionBomb, checker.ErrCorrupt, checker.ErrInfected} {
+		require.ErrorIs(t, rejected, checker.ErrRejected)
+	}
+
+	documents := checker.Policy{Name: "documents", Types: []checker.FileType{checker.PDF}, MaxSize: 1024}
+	file, err = inspector.Inspect(ctx, documents, "report.pdf", strings.NewReader("%PDF-1.7 quarterly report"), 25)
+	require.NoError(t, err)
+	require.Equal(t, "application/pdf", file.ContentType)
+}
+
+/*
+TestUploadInspection_StripsMetadata verifies that EXIF, comments and text
+chunks are removed from every image format without breaking the image,
+that JPEG color profiles are kept and that data after the image is dropped.
+*/
+func TestUploadInspection_StripsMetadata(t *testing.T) {
+	ctx := context.Background()
+	inspector := checker.NewInspector(nil)
+
+	for filename, content := range withMetadata(t) {
+		t.Run(filename, func(t *testing.T) {
+			require.Contains(t, string(content), secret)
+
+			file, err := inspector.Inspect(ctx, imagePolicy(), filename, bytes.NewReader(content), int64(len(content)))
+			require.NoError(t, err)
+			require.NotContains(t, string(file.Content), secret)
+			require.Equal(t, int64(len(file.Content)), file.Size)
+
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(file.Content))
+			require.NoError(t, err)
+			require.Equal(t, file.Width, cfg.Width)
+			require.Equal(t, file.Height, cfg.Height)
+			_, _, err = image.Decode(bytes.NewReader(file.Content))
+			require.NoError(t, err)
+
+			if file.ContentType == "image/jpeg" {
+				require.Contains(t, string(file.Content), "ICC_PROFILE")
+			}
+			if file.ContentType == "image/webp" {
+				require.Zero(t, file.Content[20]&0x08, "the VP8X EXIF flag is cleared")
+			}
+		})
+	}
+
+	/* A policy can keep metadata. */
+	policy := imagePolicy()
+	policy.StripMetadata = false
+	content := withMetadata(t)["photo.png"]
+	file, err := inspector.Inspect(ctx, policy, "photo.png", bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	require.Equal(t, content, file.Content)
+}
+
+/*
+TestClamdScanner_RejectsInfectedUploads verifies that uploads go through
+clamd, that infected ones are rejected and that uploads fail closed while
+clamd cannot be reached.
+*/
+func TestClamdScanner_RejectsInfectedUploads(t *testing.T) {
+	ctx := context.Background()
+	scanner := checker.NewClamdScanner("tcp://"+fakeClamd(t), time.Second)
+	require.NoError(t, scanner.Ping(ctx))
+
+	inspector := checker.NewInspector(scanner)
+	clean := pngImage(t, 32, 32)
+	_, err := inspector.Inspect(ctx, imagePolicy(), "clean.png", bytes.NewReader(clean), int64(len(clean)))
+	require.NoError(t, err)
+
+	/* The virus sits after IEND, so stripping would drop it; the original is scanned. */
+	infected := append(append([]byte(nil), clean...), eicar...)
+	_, err = inspector.Inspect(ctx, imagePolicy(), "infected.png", bytes.NewReader(infected), int64(len(infected)))
+	require.ErrorIs(t, err, checker.ErrInfected)
+	require.ErrorContains(t, err, "Eicar-Test-Signature")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	gone := ln.Addr().String()
+	ln.Close()
+
+	offline := checker.NewInspector(checker.NewClamdScanner(gone, time.Second))
+	_, err = offline.Inspect(ctx, imagePolicy(), "clean.png", bytes.NewReader(clean), int64(len(clean)))
+	require.Error(t, err, "uploads are refused when clamd is down")
+	require.NotErrorIs(t, err, checker.ErrInfected)
+	require.NotErrorIs(t, err, checker.ErrRejected, "an unreachable scanner is not the file's fault")
+}
+
+/*
+TestClamdScanner_ConnectInspector verifies that ConnectInspector only scans
+when CLAMD_ADDRESS is set and refuses to start without a reachable clamd.
+*/
+func TestClamdScanner_ConnectInspector(t *testing.T) {
+	prevEnv, prevShared := environment.Env, checker.Shared
+	t.Cleanup(func() { environment.Env, checker.Shared = prevEnv, prevShared })
+
+	environment.Env.CLAMD_ADDRESS = ""
+	require.NoError(t, checker.ConnectInspector())
+	require.Nil(t, checker.Shared.Scanner)
+
+	environment.Env.CLAMD_ADDRESS = fakeClamd(t)
+	environment.Env.CLAMD_TIMEOUT = "5s"
+	require.NoError(t, checker.ConnectInspector())
+	require.IsType(t, &checker.ClamdScanner{}, checker.Shared.Scanner)
+
+	environment.Env.CLAMD_TIMEOUT = "soon"
+	require.Error(t, checker.ConnectInspector())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	environment.Env.CLAMD_ADDRESS = ln.Addr().String()
+	environment.Env.CLAMD_TIMEOUT = "5s"
+	ln.Close()
+	require.Error(t, checker.ConnectInspector())
+}
@@ -0,0 +1,38 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+)
+
+// Shared is the inspector uploads are checked with. It does not scan for
+// malware until ConnectInspector finds a CLAMD_ADDRESS.
+var Shared = NewInspector(nil)
+
+// ConnectInspector sets Shared from the environment. With CLAMD_ADDRESS
+// set, every upload is streamed to that clamd and rejected when it finds
+// something or cannot be reached; CLAMD_TIMEOUT bounds each scan.
+func ConnectInspector() error {
+	if environment.Env.CLAMD_ADDRESS == "" {
+		Shared = NewInspector(nil)
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(environment.Env.CLAMD_TIMEOUT)
+	if err != nil || timeout <= 0 {
+		return fmt.Errorf("CLAMD_TIMEOUT must be a duration such as 30s, got %q", environment.Env.CLAMD_TIMEOUT)
+	}
+	scanner := NewClamdScanner(environment.Env.CLAMD_ADDRESS, timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := scanner.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to reach clamd at %s: %w", environment.Env.CLAMD_ADDRESS, err)
+	}
+
+	Shared = NewInspector(scanner)
+	return nil
+}
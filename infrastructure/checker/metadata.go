@@ -0,0 +1,243 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// StripMetadata returns content without the metadata of its image format,
+// leaving the image data untouched. Anything after the end of the image is
+// dropped as well, so nothing can hide behind it. Other file types are
+// returned unchanged.
+func StripMetadata(kind FileType, content []byte) ([]byte, error) {
+	var (
+		out []byte
+		err error
+	)
+	switch kind.MIME {
+	case JPEG.MIME:
+		out, err = stripJPEG(content)
+	case PNG.MIME:
+		out, err = stripPNG(content)
+	case GIF.MIME:
+		out, err = stripGIF(content)
+	case WebP.MIME:
+		out, err = stripWebP(content)
+	default:
+		return content, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+	return out, nil
+}
+
+var errTruncated = errors.New("unexpected end of image")
+
+// stripJPEG keeps the segments needed to render the image: APP0 (JFIF),
+// ICC profiles in APP2 and APP14 (Adobe color transform). Other APPn
+// segments, which carry EXIF, XMP and IPTC, and comments are dropped.
+func stripJPEG(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	out = append(out, b[:2]...)
+
+	pos := 2
+	for {
+		if pos+2 > len(b) || b[pos] != 0xff {
+			return nil, errTruncated
+		}
+		marker := b[pos+1]
+		if marker == 0xff {
+			/* Fill byte before a marker. */
+			pos++
+			continue
+		}
+		if marker == 0xd9 {
+			return append(out, 0xff, 0xd9), nil
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			out = append(out, b[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(b) {
+			return nil, errTruncated
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(b[pos+2:]))
+		if end > len(b) || end < pos+4 {
+			return nil, errTruncated
+		}
+
+		keep := true
+		switch {
+		case marker == 0xfe:
+			keep = false
+		case marker == 0xe2:
+			keep = bytes.HasPrefix(b[pos+4:end], []byte("ICC_PROFILE\x00"))
+		case marker >= 0xe1 && marker <= 0xef:
+			keep = marker == 0xee
+		}
+		if keep {
+			out = append(out, b[pos:end]...)
+		}
+		pos = end
+
+		if marker == 0xda {
+			/* Entropy coded data follows the scan header. It ends at the first marker that is not a restart marker or a stuffed 0xff. */
+			start := pos
+			for pos+1 < len(b) && !(b[pos] == 0xff && b[pos+1] != 0 && (b[pos+1] < 0xd0 || b[pos+1] > 0xd7)) {
+				pos++
+			}
+			if pos+1 >= len(b) {
+				return nil, errTruncated
+			}
+			out = append(out, b[start:pos]...)
+		}
+	}
+}
+
+// stripPNG drops the text, EXIF and time chunks and everything after IEND.
+func stripPNG(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	out = append(out, b[:8]...)
+
+	pos := 8
+	for {
+		if pos+12 > len(b) {
+			return nil, errTruncated
+		}
+		length := int(binary.BigEndian.Uint32(b[pos:]))
+		end := pos + 12 + length
+		if end > len(b) {
+			return nil, errTruncated
+		}
+
+		switch string(b[pos+4 : pos+8]) {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+		default:
+			out = append(out, b[pos:end]...)
+		}
+		if string(b[pos+4:pos+8]) == "IEND" {
+			return out, nil
+		}
+		pos = end
+	}
+}
+
+// stripGIF drops comment extensions, XMP application extensions and
+// everything after the trailer.
+func stripGIF(b []byte) ([]byte, error) {
+	if len(b) < 13 {
+		return nil, errTruncated
+	}
+	header := 13
+	if b[10]&0x80 != 0 {
+		header += 3 << (int(b[10]&0x07) + 1)
+	}
+	if header > len(b) {
+		return nil, errTruncated
+	}
+	out := make([]byte, 0, len(b))
+	out = append(out, b[:header]...)
+
+	/* subBlocks returns the end of the data sub-blocks starting at pos. */
+	subBlocks := func(pos int) (int, error) {
+		for {
+			if pos >= len(b) {
+				return 0, errTruncated
+			}
+			size := int(b[pos])
+			pos += 1 + size
+			if size == 0 {
+				return pos, nil
+			}
+		}
+	}
+
+	pos := header
+	for {
+		if pos >= len(b) {
+			return nil, errTruncated
+		}
+		switch b[pos] {
+		case 0x3b:
+			return append(out, 0x3b), nil
+		case 0x21:
+			if pos+2 > len(b) {
+				return nil, errTruncated
+			}
+			end, err := subBlocks(pos + 2)
+			if err != nil {
+				return nil, err
+			}
+			label := b[pos+1]
+			xmp := label == 0xff && bytes.HasPrefix(b[pos+2:end], []byte("\x0bXMP DataXMP"))
+			if label != 0xfe && !xmp {
+				out = append(out, b[pos:end]...)
+			}
+			pos = end
+		case 0x2c:
+			if pos+10 > len(b) {
+				return nil, errTruncated
+			}
+			start := pos
+			pos += 10
+			if b[start+9]&0x80 != 0 {
+				pos += 3 << (int(b[start+9]&0x07) + 1)
+			}
+			/* Skip the LZW minimum code size, then the image data. */
+			end, err := subBlocks(pos + 1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b[start:end]...)
+			pos = end
+		default:
+			return nil, fmt.Errorf("unknown GIF block 0x%02x", b[pos])
+		}
+	}
+}
+
+// stripWebP drops the EXIF and XMP chunks, clears their flags in the VP8X
+// header and fixes the RIFF size.
+func stripWebP(b []byte) ([]byte, error) {
+	size := int(binary.LittleEndian.Uint32(b[4:8]))
+	if size+8 > len(b) || size < 4 {
+		return nil, errTruncated
+	}
+	b = b[:size+8]
+
+	out := make([]byte, 0, len(b))
+	out = append(out, b[:12]...)
+
+	pos := 12
+	for pos < len(b) {
+		if pos+8 > len(b) {
+			return nil, errTruncated
+		}
+		length := int(binary.LittleEndian.Uint32(b[pos+4:]))
+		end := pos + 8 + length + length%2
+		if end > len(b) || end < pos {
+			return nil, errTruncated
+		}
+
+		switch string(b[pos : pos+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), b[pos:end]...)
+			if length > 0 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, b[pos:end]...)
+		}
+		pos = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,115 @@
+// Package database picks the database backend named by DB_DIALECT so the
+// entrypoints do not depend on a particular driver.
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	"github.com/celpung/gocleanarch/infrastructure/db/postgres"
+	"github.com/celpung/gocleanarch/infrastructure/db/sqlite"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"gorm.io/gorm"
+)
+
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// DB is the connection opened by ConnectDatabase for the configured dialect.
+var DB *gorm.DB
+
+// Dialect returns the normalised DB_DIALECT.
+func Dialect() (string, error) {
+	switch strings.ToLower(strings.TrimSpace(environment.Env.DB_DIALECT)) {
+	case "", "mysql", "mariadb":
+		return MySQL, nil
+	case "postgres", "postgresql", "pgsql":
+		return Postgres, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	default:
+		return "", fmt.Errorf("unsupported DB_DIALECT %q (use mysql, postgres or sqlite)", environment.Env.DB_DIALECT)
+	}
+}
+
+// CreateDatabaseIfNotExists prepares the configured backend so that
+// ConnectDatabase can succeed on a fresh server. It is the first call to
+// reach the server, so it retries while the server is still starting.
+func CreateDatabaseIfNotExists() error {
+	dialect, err := Dialect()
+	if err != nil {
+		return err
+	}
+	retry, err := RetryConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	create := mysql.CreateDatabaseIfNotExists
+	switch dialect {
+	case Postgres:
+		create = postgres.CreateDatabaseIfNotExists
+	case SQLite:
+		create = sqlite.CreateDatabaseIfNotExists
+	}
+	return Retry(context.Background(), retry, "preparing the database", create)
+}
+
+// ConnectDatabase opens the primary with retries, sizes its pool from the
+// environment and registers the DB_REPLICAS read replicas.
+func ConnectDatabase() error {
+	dialect, err := Dialect()
+	if err != nil {
+		return err
+	}
+	retry, err := RetryConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	pool, err := PoolConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	replicas, err := ParseReplicas(environment.Env.DB_REPLICAS, environment.Env.DB_PORT)
+	if err != nil {
+		return err
+	}
+
+	err = Retry(context.Background(), retry, "connecting to the database", func() error {
+		switch dialect {
+		case Postgres:
+			err := postgres.ConnectDatabase()
+			DB = postgres.DB
+			return err
+		case SQLite:
+			err := sqlite.ConnectDatabase()
+			DB = sqlite.DB
+			return err
+		default:
+			err := mysql.ConnectDatabase()
+			DB = mysql.DB
+			return err
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ConfigurePool(DB, pool); err != nil {
+		return err
+	}
+	if len(replicas) > 0 {
+		err = Retry(context.Background(), retry, "connecting to the read replicas", func() error {
+			return pingReplicas(dialect, replicas)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return UseReplicas(DB, dialect, replicas, pool)
+}
@@ -0,0 +1,280 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/database"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	"github.com/celpung/gocleanarch/infrastructure/db/postgres"
+	"github.com/celpung/gocleanarch/infrastructure/db/sqlite"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the database configuration tests from the project root:
+     go test -v ./infrastructure/db/database
+
+Notes:
+- The tests change environment.Env and restore it afterwards; they never
+  reach a MySQL or PostgreSQL server.
+===============================================================================
+*/
+
+func withEnv(t *testing.T, change func(env *environment.Environment)) {
+	t.Helper()
+
+	saved := environment.Env
+	t.Cleanup(func() { environment.Env = saved })
+	change(&environment.Env)
+}
+
+/*
+TestDatabase_DialectAliases verifies that DB_DIALECT accepts the usual
+spellings of each backend and rejects unknown ones.
+*/
+func TestDatabase_DialectAliases(t *testing.T) {
+	cases := map[string]string{
+		"":           database.MySQL,
+		"MySQL":      database.MySQL,
+		"postgres":   database.Postgres,
+		"postgresql": database.Postgres,
+		" pgsql ":    database.Postgres,
+		"sqlite3":    database.SQLite,
+	}
+	for value, want := range cases {
+		withEnv(t, func(env *environment.Environment) { env.DB_DIALECT = value })
+		got, err := database.Dialect()
+		require.NoError(t, err, value)
+		require.Equal(t, want, got, value)
+	}
+
+	withEnv(t, func(env *environment.Environment) { env.DB_DIALECT = "oracle" })
+	_, err := database.Dialect()
+	require.Error(t, err)
+}
+
+/*
+TestDatabase_ConnectsSQLite verifies that the factory opens a SQLite file
+named by DB_NAME with WAL, foreign keys and a busy timeout, and that the
+servers' migration step creates every table on it.
+*/
+func TestDatabase_ConnectsSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "app")
+	withEnv(t, func(env *environment.Environment) {
+		env.DB_DIALECT = "sqlite"
+		env.DB_NAME = path
+	})
+
+	require.NoError(t, database.CreateDatabaseIfNotExists())
+	require.NoError(t, database.ConnectDatabase())
+	require.Equal(t, "sqlite", database.DB.Dialector.Name())
+	require.FileExists(t, path+".db")
+	require.NoError(t, migration.Migrate(context.Background(), database.DB))
+	require.True(t, database.DB.Migrator().HasTable("users"))
+	require.True(t, database.DB.Migrator().HasTable("sliders"))
+
+	var journalMode string
+	require.NoError(t, database.DB.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+	require.Equal(t, "wal", journalMode)
+	var foreignKeys, busyTimeout int
+	require.NoError(t, database.DB.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error)
+	require.Equal(t, 1, foreignKeys)
+	require.NoError(t, database.DB.Raw("PRAGMA busy_timeout").Scan(&busyTimeout).Error)
+	require.Equal(t, int(sqlite.BusyTimeout.Milliseconds()), busyTimeout)
+
+	sqlDB, err := database.DB.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
+
+/*
+TestPostgres_DSNQuotesValues verifies that credentials with spaces, quotes
+and backslashes survive DSN building and that empty SSL settings are left
+out.
+*/
+func TestPostgres_DSNQuotesValues(t *testing.T) {
+	withEnv(t, func(env *environment.Environment) {
+		env.DB_HOST = "db.internal"
+		env.DB_PORT = "5432"
+		env.DB_USERNAME = "app"
+		env.DB_PASSWORD = `p a'ss\word`
+		env.DB_SSL_MODE = "verify-full"
+		env.DB_SSL_ROOT_CERT = "/etc/ssl/ca.pem"
+		env.DB_SSL_CERT = ""
+		env.DB_SSL_KEY = ""
+	})
+
+	dsn := postgres.DSN("db.internal", "5432", "gocleanarch")
+	require.Contains(t, dsn, `password='p a\'ss\\word'`)
+	require.Contains(t, dsn, "host='db.internal'")
+	require.Contains(t, dsn, "dbname='gocleanarch'")
+	require.Contains(t, dsn, "sslmode='verify-full'")
+	require.Contains(t, dsn, "sslrootcert='/etc/ssl/ca.pem'")
+	require.NotContains(t, dsn, "sslcert=")
+	require.NotContains(t, dsn, "sslkey=")
+
+	require.Equal(t, `"my""db"`, postgres.QuoteIdentifier(`my"db`))
+}
+
+/*
+TestDatabase_SQLiteFileHandlesConcurrentWriters verifies that parallel
+transactions on a file database wait for each other instead of failing with
+SQLITE_BUSY.
+*/
+func TestDatabase_SQLiteFileHandlesConcurrentWriters(t *testing.T) {
+	db, err := sqlite.SetupDB(filepath.Join(t.TempDir(), "app.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+
+	const writers = 8
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			errs <- db.Transaction(func(tx *gorm.DB) error {
+				return tx.Create(&model.Slider{Title: fmt.Sprintf("slide %d", i), Description: "d", File: "f.jpg"}).Error
+			})
+		}(i)
+	}
+	for i := 0; i < writers; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	var count int64
+	require.NoError(t, db.Model(&model.Slider{}).Count(&count).Error)
+	require.EqualValues(t, writers, count)
+}
+
+/*
+TestMySQL_DSNEscapesCredentials verifies that credentials containing DSN
+separators survive a round trip through the driver's parser, and that an
+unknown DB_SSL_MODE is rejected instead of silently connecting in clear
+text.
+*/
+func TestMySQL_DSNEscapesCredentials(t *testing.T) {
+	withEnv(t, func(env *environment.Environment) {
+		env.DB_USERNAME = "app"
+		env.DB_PASSWORD = "p@ss:w/rd?x=1"
+		env.DB_SSL_MODE = "require"
+	})
+
+	dsn, err := mysql.DSN("db.internal", "3306", "gocleanarch")
+	require.NoError(t, err)
+
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	require.NoError(t, err)
+	require.Equal(t, "p@ss:w/rd?x=1", cfg.Passwd)
+	require.Equal(t, "db.internal:3306", cfg.Addr)
+	require.Equal(t, "gocleanarch", cfg.DBName)
+	require.Equal(t, "skip-verify", cfg.TLSConfig)
+	require.True(t, cfg.ParseTime)
+
+	withEnv(t, func(env *environment.Environment) { env.DB_SSL_MODE = "sometimes" })
+	_, err = mysql.DSN("db.internal", "3306", "gocleanarch")
+	require.Error(t, err)
+
+	require.Equal(t, "`odd``name`", mysql.QuoteIdentifier("odd`name"))
+}
+
+/*
+TestDatabase_PoolAndRetryConfig verifies parsing of the pool and retry
+settings, including the idle limit being capped by the open limit.
+*/
+func TestDatabase_PoolAndRetryConfig(t *testing.T) {
+	withEnv(t, func(env *environment.Environment) {
+		env.DB_MAX_OPEN_CONNS = "4"
+		env.DB_MAX_IDLE_CONNS = "10"
+		env.DB_CONN_MAX_LIFETIME = "1h"
+		env.DB_CONN_MAX_IDLE_TIME = ""
+		env.DB_CONNECT_ATTEMPTS = "3"
+		env.DB_CONNECT_BACKOFF = "10ms"
+	})
+
+	pool, err := database.PoolConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, database.PoolConfig{MaxOpenConns: 4, MaxIdleConns: 4, ConnMaxLifetime: time.Hour}, pool)
+
+	retry, err := database.RetryConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, database.RetryConfig{Attempts: 3, Backoff: 10 * time.Millisecond}, retry)
+
+	withEnv(t, func(env *environment.Environment) { env.DB_MAX_OPEN_CONNS = "-1" })
+	_, err = database.PoolConfigFromEnv()
+	require.Error(t, err)
+
+	withEnv(t, func(env *environment.Environment) { env.DB_CONNECT_BACKOFF = "soon" })
+	_, err = database.RetryConfigFromEnv()
+	require.Error(t, err)
+}
+
+/*
+TestDatabase_RetryBacksOff verifies that Retry stops at the first success,
+gives up after the configured attempts with the last error wrapped, and
+waits longer after each failure.
+*/
+func TestDatabase_RetryBacksOff(t *testing.T) {
+	ctx := context.Background()
+	down := errors.New("connection refused")
+
+	calls := 0
+	err := database.Retry(ctx, database.RetryConfig{Attempts: 5, Backoff: time.Millisecond}, "connecting", func() error {
+		calls++
+		if calls < 3 {
+			return down
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	calls = 0
+	start := time.Now()
+	err = database.Retry(ctx, database.RetryConfig{Attempts: 3, Backoff: 20 * time.Millisecond}, "connecting", func() error {
+		calls++
+		return down
+	})
+	require.ErrorIs(t, err, down)
+	require.Equal(t, 3, calls)
+	require.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond, "waits 20ms then 40ms")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = database.Retry(cancelled, database.RetryConfig{Attempts: 3, Backoff: time.Hour}, "connecting", func() error { return down })
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+/*
+TestDatabase_ParseReplicas verifies host and host:port entries, the default
+port and bracketed IPv6 addresses.
+*/
+func TestDatabase_ParseReplicas(t *testing.T) {
+	addrs, err := database.ParseReplicas(" r1.internal, r2.internal:3307 ,[::1]:3308,", "3306")
+	require.NoError(t, err)
+	require.Equal(t, []database.Address{
+		{Host: "r1.internal", Port: "3306"},
+		{Host: "r2.internal", Port: "3307"},
+		{Host: "::1", Port: "3308"},
+	}, addrs)
+
+	addrs, err = database.ParseReplicas("", "3306")
+	require.NoError(t, err)
+	require.Empty(t, addrs)
+
+	_, err = database.ParseReplicas(":3307", "3306")
+	require.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/mysql"
+	"github.com/celpung/gocleanarch/infrastructure/db/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// Address is a database server location.
+type Address struct {
+	Host string
+	Port string
+}
+
+// ParseReplicas reads a comma separated list of host or host:port entries,
+// as found in DB_REPLICAS. Entries without a port use defaultPort; IPv6
+// hosts with a port are written in brackets.
+func ParseReplicas(value, defaultPort string) ([]Address, error) {
+	var addrs []Address
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil {
+			host, port = strings.Trim(entry, "[]"), defaultPort
+		}
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("invalid replica address %q", entry)
+		}
+		addrs = append(addrs, Address{Host: host, Port: port})
+	}
+	return addrs, nil
+}
+
+// resolver routes reads to the replicas once UseReplicas registered it.
+var resolver *dbresolver.DBResolver
+
+// UseReplicas sends queries that only read, outside transactions, to the
+// replicas while writes and transactions stay on db. Repository methods
+// that must not see replication lag opt out with
+// Clauses(dbresolver.Write). pool sizes each replica like the primary.
+func UseReplicas(db *gorm.DB, dialect string, replicas []Address, pool PoolConfig) error {
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	dialectors, err := replicaDialectors(dialect, replicas)
+	if err != nil {
+		return err
+	}
+
+	r := dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   dbresolver.RandomPolicy{},
+	})
+	if err := db.Use(r); err != nil {
+		return fmt.Errorf("failed to connect read replicas: %w", err)
+	}
+	r.SetMaxOpenConns(pool.MaxOpenConns).
+		SetMaxIdleConns(pool.MaxIdleConns).
+		SetConnMaxLifetime(pool.ConnMaxLifetime).
+		SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	resolver = r
+	return nil
+}
+
+func replicaDialectors(dialect string, replicas []Address) ([]gorm.Dialector, error) {
+	dialectors := make([]gorm.Dialector, 0, len(replicas))
+	for _, r := range replicas {
+		switch dialect {
+		case MySQL:
+			d, err := mysql.Dialector(r.Host, r.Port)
+			if err != nil {
+				return nil, err
+			}
+			dialectors = append(dialectors, d)
+		case Postgres:
+			dialectors = append(dialectors, postgres.Dialector(r.Host, r.Port))
+		default:
+			return nil, fmt.Errorf("read replicas are not supported on %s", dialect)
+		}
+	}
+	return dialectors, nil
+}
+
+// pingReplicas opens and closes a connection to every replica, so startup
+// can wait for them before the resolver is registered; a failed
+// registration can not be undone.
+func pingReplicas(dialect string, replicas []Address) error {
+	dialectors, err := replicaDialectors(dialect, replicas)
+	if err != nil {
+		return err
+	}
+	for i, d := range dialectors {
+		db, err := gorm.Open(d, &gorm.Config{})
+		if err != nil {
+			return fmt.Errorf("replica %s: %w", net.JoinHostPort(replicas[i].Host, replicas[i].Port), err)
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	return nil
+}
+
+// Health pings the primary and every replica; readiness probes use it.
+func Health(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database is not connected")
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("primary: %w", err)
+	}
+
+	if resolver == nil {
+		return nil
+	}
+	return resolver.Call(func(pool gorm.ConnPool) error {
+		if p, ok := pool.(interface{ PingContext(context.Context) error }); ok {
+			if err := p.PingContext(ctx); err != nil {
+				return fmt.Errorf("replica: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+)
+
+// maxBackoff caps the wait between two connection attempts.
+const maxBackoff = 30 * time.Second
+
+// RetryConfig controls how often startup retries a failing connection.
+// Attempts of 1 or less means a single try.
+type RetryConfig struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// RetryConfigFromEnv reads DB_CONNECT_ATTEMPTS and DB_CONNECT_BACKOFF.
+func RetryConfigFromEnv() (RetryConfig, error) {
+	attempts, err := envInt("DB_CONNECT_ATTEMPTS", environment.Env.DB_CONNECT_ATTEMPTS)
+	if err != nil {
+		return RetryConfig{}, err
+	}
+	backoff, err := envDuration("DB_CONNECT_BACKOFF", environment.Env.DB_CONNECT_BACKOFF)
+	if err != nil {
+		return RetryConfig{}, err
+	}
+	return RetryConfig{Attempts: attempts, Backoff: backoff}, nil
+}
+
+// Retry runs fn until it succeeds, the attempts are used up or ctx ends. The
+// wait doubles after every failure, so a database that is still starting
+// (a fresh container, a failover) does not crash the application.
+func Retry(ctx context.Context, cfg RetryConfig, what string, fn func() error) error {
+	attempts := cfg.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	wait := cfg.Backoff
+
+	var err error
+	for i := 1; ; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if i >= attempts {
+			break
+		}
+
+		log.Printf("%s failed (attempt %d/%d), retrying in %s: %v", what, i, attempts, wait, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxBackoff)
+	}
+	return fmt.Errorf("%s failed after %d attempts: %w", what, attempts, err)
+}
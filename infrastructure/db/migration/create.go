@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Dialects lists the migration directories create writes to.
+var Dialects = []string{"mysql", "postgres", "sqlite"}
+
+var nonName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes empty up and down files with the next free version for every
+// dialect under dir and returns their paths.
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(nonName.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	var next uint64 = 1
+	for _, d := range Dialects {
+		migrations, err := LoadFS(os.DirFS(filepath.Join(dir, d)))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("read %s migrations: %w", d, err)
+		}
+		if n := len(migrations); n > 0 && migrations[n-1].Version >= next {
+			next = migrations[n-1].Version + 1
+		}
+	}
+
+	var paths []string
+	for _, d := range Dialects {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			return nil, err
+		}
+		for _, direction := range []string{"up", "down"} {
+			p := filepath.Join(dir, d, fmt.Sprintf("%06d_%s.%s.sql", next, name, direction))
+			body := fmt.Sprintf("-- %s migration %06d_%s (%s)\n", direction, next, name, d)
+			if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+				return nil, err
+			}
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
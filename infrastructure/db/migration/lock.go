@@ -0,0 +1,127 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrLocked is returned when another process holds the migration lock for
+// longer than the lock timeout.
+var ErrLocked = errors.New("migration lock is held by another process")
+
+// locker serialises migration runs across processes. conn is pinned to one
+// connection, which session scoped locks such as GET_LOCK require.
+type locker interface {
+	lock(ctx context.Context, conn *gorm.DB, timeout time.Duration) error
+	unlock(conn *gorm.DB) error
+}
+
+func lockerFor(dialect string) locker {
+	switch dialect {
+	case "mysql":
+		return mysqlLocker{}
+	case "postgres":
+		return postgresLocker{}
+	default:
+		return tableLocker{}
+	}
+}
+
+const lockName = "schema_migrations"
+
+// mysqlLocker uses a named lock, released by MySQL when the session ends
+// even if the process dies.
+type mysqlLocker struct{}
+
+func (mysqlLocker) lock(ctx context.Context, conn *gorm.DB, timeout time.Duration) error {
+	var got *int
+	if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, int(timeout.Seconds())).Scan(&got).Error; err != nil {
+		return err
+	}
+	if got == nil || *got != 1 {
+		return ErrLocked
+	}
+	return nil
+}
+
+func (mysqlLocker) unlock(conn *gorm.DB) error {
+	return conn.Exec("SELECT RELEASE_LOCK(?)", lockName).Error
+}
+
+// postgresLockKey is the advisory lock key, "gocleanarch" hashed to a bigint
+// once so that unrelated applications sharing the server do not collide.
+const postgresLockKey int64 = 7061382915307764721
+
+// postgresLocker polls a session level advisory lock; PostgreSQL drops it
+// when the connection closes.
+type postgresLocker struct{}
+
+func (postgresLocker) lock(ctx context.Context, conn *gorm.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var got bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", postgresLockKey).Scan(&got).Error; err != nil {
+			return err
+		}
+		if got {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (postgresLocker) unlock(conn *gorm.DB) error {
+	return conn.Exec("SELECT pg_advisory_unlock(?)", postgresLockKey).Error
+}
+
+// tableLocker claims the single row of schema_migrations_lock. It works on
+// any dialect but, unlike session locks, survives a crash: delete the row by
+// hand if no migration is running and ErrLocked persists.
+type tableLocker struct{}
+
+const pollInterval = 100 * time.Millisecond
+
+func (tableLocker) lock(ctx context.Context, conn *gorm.DB, timeout time.Duration) error {
+	if err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+		id INTEGER NOT NULL PRIMARY KEY,
+		locked_at TIMESTAMP NOT NULL
+	)`).Error; err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		res := conn.Exec("INSERT INTO schema_migrations_lock (id, locked_at) SELECT 1, ? WHERE NOT EXISTS (SELECT 1 FROM schema_migrations_lock WHERE id = 1)", time.Now().UTC())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w (remove the row from schema_migrations_lock if no migration is running)", ErrLocked)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (tableLocker) unlock(conn *gorm.DB) error {
+	return conn.Exec("DELETE FROM schema_migrations_lock WHERE id = 1").Error
+}
@@ -0,0 +1,297 @@
+package migration_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	repository_impl "github.com/celpung/gocleanarch/application/user/impl/repository"
+	search_impl "github.com/celpung/gocleanarch/application/user/impl/search"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the migration tests from the project root:
+     go test -v ./infrastructure/db/migration
+
+Notes:
+- Each test opens its own empty in-memory SQLite database and runs the
+  embedded SQLite migrations, so the shipped files are exercised directly.
+===============================================================================
+*/
+
+func openEmptyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+/*
+TestMigrator_UpDownStatus verifies that up applies every embedded migration
+once, that status reports them, and that down reverts the newest first.
+*/
+func TestMigrator_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openEmptyDB(t)
+
+	m, err := migration.New(db)
+	require.NoError(t, err)
+	require.NotEmpty(t, m.Migrations)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, len(m.Migrations))
+	require.True(t, db.Migrator().HasTable("users"))
+	require.True(t, db.Migrator().HasTable("sliders"))
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied, "a second run has nothing to do")
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		require.True(t, s.Applied, "%d_%s should be applied", s.Version, s.Name)
+		require.False(t, s.Modified)
+	}
+
+	last := m.Migrations[len(m.Migrations)-1]
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	require.Equal(t, last.Version, reverted[0].Version)
+
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	require.False(t, statuses[len(statuses)-1].Applied)
+
+	reverted, err = m.Down(ctx, len(m.Migrations))
+	require.NoError(t, err)
+	require.Len(t, reverted, len(m.Migrations)-1)
+	require.False(t, db.Migrator().HasTable("users"))
+}
+
+/*
+TestMigrator_DetectsModifiedMigration verifies that changing the up script
+of an applied migration blocks further runs instead of silently diverging.
+*/
+func TestMigrator_DetectsModifiedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openEmptyDB(t)
+
+	m, err := migration.New(db)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	m.Migrations[0].Up += "\n-- edited after release\n"
+	m.Migrations[0].Checksum = migration.Checksum(m.Migrations[0].Up)
+
+	_, err = m.Up(ctx)
+	require.ErrorContains(t, err, "modified after it was applied")
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.True(t, statuses[0].Modified)
+
+	m.Migrations = m.Migrations[1:]
+	_, err = m.Up(ctx)
+	require.ErrorContains(t, err, "files are missing")
+}
+
+/*
+TestMigrator_WaitsForLock verifies that a run does not start while another
+process holds the migration lock and gives up after the lock timeout.
+*/
+func TestMigrator_WaitsForLock(t *testing.T) {
+	ctx := context.Background()
+	db := openEmptyDB(t)
+
+	m, err := migration.New(db)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	/* Simulate a concurrent instance holding the lock. */
+	require.NoError(t, db.Exec("INSERT INTO schema_migrations_lock (id, locked_at) VALUES (1, ?)", time.Now()).Error)
+
+	m.LockTimeout = 300 * time.Millisecond
+	start := time.Now()
+	_, err = m.Up(ctx)
+	require.ErrorIs(t, err, migration.ErrLocked)
+	require.GreaterOrEqual(t, time.Since(start), m.LockTimeout)
+
+	require.NoError(t, db.Exec("DELETE FROM schema_migrations_lock").Error)
+	_, err = m.Up(ctx)
+	require.NoError(t, err, "the lock is free again")
+}
+
+/*
+TestMigrator_FailedMigrationRollsBack verifies that a failing migration
+leaves neither partial changes nor a schema_migrations row behind.
+*/
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := openEmptyDB(t)
+
+	m, err := migration.New(db)
+	require.NoError(t, err)
+	broken := migration.Migration{
+		Version: 999999,
+		Name:    "broken",
+		Up:      "CREATE TABLE half_done (id INTEGER);\nINSERT INTO no_such_table VALUES (1);",
+		Down:    "DROP TABLE half_done;",
+	}
+	broken.Checksum = migration.Checksum(broken.Up)
+	m.Migrations = append(m.Migrations, broken)
+
+	_, err = m.Up(ctx)
+	require.ErrorContains(t, err, "999999_broken up")
+	require.False(t, db.Migrator().HasTable("half_done"))
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.False(t, statuses[len(statuses)-1].Applied)
+	require.True(t, statuses[0].Applied, "earlier migrations stay applied")
+}
+
+// preSeriesUser is the user model as GORM AutoMigrate created the table
+// before the schema was managed by migrations.
+type preSeriesUser struct {
+	ID        string `gorm:"type:char(36);primaryKey"`
+	Name      string
+	Email     string `gorm:"unique"`
+	Password  string `gorm:"not null"`
+	Active    bool   `gorm:"default:0"`
+	Role      string `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (preSeriesUser) TableName() string { return "users" }
+
+/*
+TestMigrator_UpgradesAutoMigratedDatabase verifies that a database created
+by the old AutoMigrate adopts the history and still receives every later
+change: the version column, the full-text index, the avatar key and the
+integer seq key the index follows.
+*/
+func TestMigrator_UpgradesAutoMigratedDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := openEmptyDB(t)
+
+	require.NoError(t, db.AutoMigrate(&preSeriesUser{}))
+	old := &preSeriesUser{ID: "5f0c2d1e-0000-4000-8000-000000000001", Name: "Maria Silva", Email: "maria@ex.com", Password: "x", Role: "USER"}
+	require.NoError(t, db.Create(old).Error)
+
+	m, err := migration.New(db)
+	require.NoError(t, err)
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, len(m.Migrations))
+	require.True(t, db.Migrator().HasColumn("users", "version"))
+	require.True(t, db.Migrator().HasColumn("users", "avatar_key"))
+	require.True(t, db.Migrator().HasColumn("users", "seq"))
+
+	/* The index must follow seq, not the implicit rowid VACUUM may renumber. */
+	var ddl string
+	require.NoError(t, db.Raw("SELECT sql FROM sqlite_master WHERE name = 'users_fts'").Scan(&ddl).Error)
+	require.Contains(t, ddl, "content_rowid = 'seq'")
+
+	updated, err := repository_impl.NewUserRepository(db).UpdateFields(ctx, old.ID, 1, map[string]any{"name": "Mariana Silva"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, updated.Version)
+
+	hits, total, err := search_impl.NewUserSearcher(db).Search(ctx, "mariana", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Equal(t, old.ID, hits[0].User.ID)
+}
+
+/*
+TestMigration_SplitKeepsTriggerBodies verifies statement splitting for
+drivers without multi-statement support.
+*/
+func TestMigration_SplitKeepsTriggerBodies(t *testing.T) {
+	stmts := migration.Split(`-- comment
+CREATE TABLE a (id INTEGER);
+
+CREATE TRIGGER a_ai AFTER INSERT ON a BEGIN
+    INSERT INTO b VALUES (new.id);
+    INSERT INTO c VALUES (new.id);
+END;
+DROP TABLE x;`)
+
+	require.Len(t, stmts, 3)
+	require.Equal(t, "CREATE TABLE a (id INTEGER)", stmts[0])
+	require.Contains(t, stmts[1], "INSERT INTO c VALUES (new.id);")
+	require.Equal(t, "DROP TABLE x", stmts[2])
+}
+
+/*
+TestMigration_CreateUsesNextVersion verifies that create numbers new files
+after the highest existing version for every dialect.
+*/
+func TestMigration_CreateUsesNextVersion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sqlite"), 0o755))
+	for _, f := range []string{"000004_old.up.sql", "000004_old.down.sql"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "sqlite", f), []byte("SELECT 1;"), 0o644))
+	}
+
+	paths, err := migration.Create(dir, "Add Avatar URL")
+	require.NoError(t, err)
+	require.Len(t, paths, 2*len(migration.Dialects))
+	require.FileExists(t, filepath.Join(dir, "mysql", "000005_add_avatar_url.up.sql"))
+	require.FileExists(t, filepath.Join(dir, "sqlite", "000005_add_avatar_url.down.sql"))
+
+	loaded, err := migration.LoadFS(os.DirFS(filepath.Join(dir, "sqlite")))
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+
+	_, err = migration.Create(dir, "!!!")
+	require.Error(t, err)
+}
+
+/*
+TestMigration_DialectsShareHistory verifies that every dialect ships the
+same migration versions and names, so a schema change is never written for
+one backend only.
+*/
+func TestMigration_DialectsShareHistory(t *testing.T) {
+	var reference []migration.Migration
+	for i, d := range migration.Dialects {
+		migrations, err := migration.Load(d)
+		require.NoError(t, err, d)
+		require.NotEmpty(t, migrations, d)
+
+		for _, m := range migrations {
+			require.NotEmpty(t, migration.Split(m.Up), "%s %06d has an empty up script", d, m.Version)
+			require.NotEmpty(t, migration.Split(m.Down), "%s %06d has an empty down script", d, m.Version)
+		}
+
+		if i == 0 {
+			reference = migrations
+			continue
+		}
+		require.Len(t, migrations, len(reference), d)
+		for j, m := range migrations {
+			require.Equal(t, reference[j].Version, m.Version, d)
+			require.Equal(t, reference[j].Name, m.Name, d)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+-- The users table as GORM AutoMigrate created it before migrations were
+-- introduced. IF NOT EXISTS lets such databases adopt the migration history;
+-- every later change to users comes in its own migration.
+CREATE TABLE IF NOT EXISTS users (
+    id CHAR(36) NOT NULL,
+    name LONGTEXT NULL,
+    email VARCHAR(191) NULL,
+    password LONGTEXT NOT NULL,
+    active TINYINT(1) NULL DEFAULT 0,
+    role VARCHAR(191) NOT NULL DEFAULT '1',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY uni_users_email (email),
+    KEY idx_users_deleted_at (deleted_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
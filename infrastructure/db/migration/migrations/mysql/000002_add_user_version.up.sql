@@ -0,0 +1,2 @@
+-- Optimistic locking: every update bumps the version.
+ALTER TABLE users ADD COLUMN version BIGINT UNSIGNED NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+ALTER TABLE users DROP KEY idx_users_fulltext;
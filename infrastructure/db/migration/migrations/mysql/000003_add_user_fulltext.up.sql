@@ -0,0 +1,2 @@
+-- FULLTEXT index used by the user search.
+ALTER TABLE users ADD FULLTEXT KEY idx_users_fulltext (name, email);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS sliders (
+    id CHAR(36) NOT NULL,
+    title LONGTEXT NOT NULL,
+    description LONGTEXT NOT NULL,
+    file LONGTEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    KEY idx_sliders_deleted_at (deleted_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,17 @@
+-- Events written in the same transaction as the change they describe and
+-- delivered to the configured sinks by the outbox relay.
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id CHAR(36) NOT NULL,
+    topic VARCHAR(191) NOT NULL,
+    message_key VARCHAR(191) NOT NULL DEFAULT '',
+    payload LONGTEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    available_at DATETIME(3) NOT NULL,
+    locked_until DATETIME(3) NULL,
+    published_at DATETIME(3) NULL,
+    dead_at DATETIME(3) NULL,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_outbox_messages_pending (published_at, dead_at, available_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,54 @@
+-- Outgoing webhooks: endpoints registered by admins, one delivery per
+-- event and endpoint, and a log row for every attempt to deliver it.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id CHAR(36) NOT NULL,
+    owner_id CHAR(36) NOT NULL,
+    url TEXT NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    secret VARCHAR(191) NOT NULL,
+    events TEXT NOT NULL,
+    active TINYINT(1) NOT NULL DEFAULT 1,
+    failure_count INT NOT NULL DEFAULT 0,
+    disabled_at DATETIME(3) NULL,
+    disabled_reason TEXT NULL,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_webhook_endpoints_owner_id (owner_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id CHAR(36) NOT NULL,
+    endpoint_id CHAR(36) NOT NULL,
+    event_id CHAR(36) NOT NULL,
+    event_type VARCHAR(191) NOT NULL,
+    payload LONGTEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NULL,
+    locked_until DATETIME(3) NULL,
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    delivered_at DATETIME(3) NULL,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY uni_webhook_deliveries_endpoint_event (endpoint_id, event_id),
+    KEY idx_webhook_deliveries_due (status, next_attempt_at),
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints (id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id CHAR(36) NOT NULL,
+    delivery_id CHAR(36) NOT NULL,
+    attempt INT NOT NULL,
+    manual TINYINT(1) NOT NULL DEFAULT 0,
+    status_code INT NOT NULL DEFAULT 0,
+    response_body TEXT NULL,
+    error TEXT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_webhook_attempts_delivery_id (delivery_id),
+    CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries (id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
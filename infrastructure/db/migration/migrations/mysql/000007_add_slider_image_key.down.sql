@@ -0,0 +1 @@
+ALTER TABLE sliders DROP COLUMN image_key;
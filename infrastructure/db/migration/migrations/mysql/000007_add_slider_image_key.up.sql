@@ -0,0 +1 @@
+ALTER TABLE sliders ADD COLUMN image_key VARCHAR(255) NOT NULL DEFAULT '';
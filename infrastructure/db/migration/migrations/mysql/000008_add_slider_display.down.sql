@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS slider_translations;
+
+ALTER TABLE sliders
+    DROP KEY idx_sliders_published_position,
+    DROP COLUMN ends_at,
+    DROP COLUMN starts_at,
+    DROP COLUMN published,
+    DROP COLUMN position,
+    DROP COLUMN link_url;
@@ -0,0 +1,21 @@
+-- Carousel settings: display order, publish state, an optional schedule and
+-- link, and titles and descriptions per locale. Existing sliders stay
+-- visible.
+ALTER TABLE sliders
+    ADD COLUMN link_url VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN position INT NOT NULL DEFAULT 0,
+    ADD COLUMN published TINYINT(1) NOT NULL DEFAULT 1,
+    ADD COLUMN starts_at DATETIME(3) NULL,
+    ADD COLUMN ends_at DATETIME(3) NULL,
+    ADD KEY idx_sliders_published_position (published, position);
+
+CREATE TABLE IF NOT EXISTS slider_translations (
+    slider_id CHAR(36) NOT NULL,
+    locale VARCHAR(16) NOT NULL,
+    title LONGTEXT NOT NULL,
+    description LONGTEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (slider_id, locale),
+    CONSTRAINT fk_slider_translations_slider FOREIGN KEY (slider_id) REFERENCES sliders (id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
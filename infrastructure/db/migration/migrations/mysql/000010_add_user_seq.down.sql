@@ -0,0 +1,3 @@
+-- Only SQLite indexes users by an integer key for full-text search; this
+-- version keeps the numbering in line with the other dialects.
+SELECT 1;
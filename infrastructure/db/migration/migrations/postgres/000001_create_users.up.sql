@@ -0,0 +1,18 @@
+-- The users table as GORM AutoMigrate created it before migrations were
+-- introduced. IF NOT EXISTS lets such databases adopt the migration history;
+-- every later change to users comes in its own migration.
+CREATE TABLE IF NOT EXISTS users (
+    id CHAR(36) NOT NULL,
+    name TEXT,
+    email TEXT,
+    password TEXT NOT NULL,
+    active BOOLEAN DEFAULT false,
+    role TEXT NOT NULL DEFAULT '1',
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT users_pkey PRIMARY KEY (id),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,2 @@
+-- Optimistic locking: every update bumps the version.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- PostgreSQL uses the LIKE searcher, which needs no index; this version
+-- only keeps the numbering in line with the other dialects.
+SELECT 1;
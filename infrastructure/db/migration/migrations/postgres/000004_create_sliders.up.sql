@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sliders (
+    id CHAR(36) NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    file TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT sliders_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sliders_deleted_at ON sliders (deleted_at);
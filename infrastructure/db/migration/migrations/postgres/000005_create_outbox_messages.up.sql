@@ -0,0 +1,18 @@
+-- Events written in the same transaction as the change they describe and
+-- delivered to the configured sinks by the outbox relay.
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id CHAR(36) NOT NULL,
+    topic TEXT NOT NULL,
+    message_key TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    published_at TIMESTAMPTZ,
+    dead_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT outbox_messages_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages (published_at, dead_at, available_at);
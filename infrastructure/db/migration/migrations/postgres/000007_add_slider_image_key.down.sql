@@ -0,0 +1 @@
+ALTER TABLE sliders DROP COLUMN IF EXISTS image_key;
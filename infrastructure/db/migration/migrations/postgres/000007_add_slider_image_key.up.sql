@@ -0,0 +1 @@
+ALTER TABLE sliders ADD COLUMN IF NOT EXISTS image_key VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS slider_translations;
+
+DROP INDEX IF EXISTS idx_sliders_published_position;
+
+ALTER TABLE sliders
+    DROP COLUMN IF EXISTS ends_at,
+    DROP COLUMN IF EXISTS starts_at,
+    DROP COLUMN IF EXISTS published,
+    DROP COLUMN IF EXISTS position,
+    DROP COLUMN IF EXISTS link_url;
@@ -0,0 +1,21 @@
+-- Carousel settings: display order, publish state, an optional schedule and
+-- link, and titles and descriptions per locale. Existing sliders stay
+-- visible.
+ALTER TABLE sliders
+    ADD COLUMN IF NOT EXISTS link_url VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS published BOOLEAN NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS starts_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS ends_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_sliders_published_position ON sliders (published, position);
+
+CREATE TABLE IF NOT EXISTS slider_translations (
+    slider_id CHAR(36) NOT NULL REFERENCES sliders (id) ON DELETE CASCADE,
+    locale VARCHAR(16) NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    CONSTRAINT slider_translations_pkey PRIMARY KEY (slider_id, locale)
+);
@@ -0,0 +1,16 @@
+-- The users table as GORM AutoMigrate created it before migrations were
+-- introduced. IF NOT EXISTS lets such databases adopt the migration history;
+-- every later change to users comes in its own migration.
+CREATE TABLE IF NOT EXISTS users (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    name TEXT,
+    email TEXT UNIQUE,
+    password TEXT NOT NULL,
+    active NUMERIC DEFAULT 0,
+    role TEXT NOT NULL DEFAULT '1',
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
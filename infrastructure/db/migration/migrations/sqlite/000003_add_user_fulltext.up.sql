@@ -0,0 +1,18 @@
+-- External content FTS5 index over users, kept in sync by triggers and
+-- filled with the users that already exist.
+CREATE VIRTUAL TABLE IF NOT EXISTS users_fts USING fts5(name, email, content = 'users', content_rowid = 'rowid', tokenize = 'unicode61');
+
+CREATE TRIGGER IF NOT EXISTS users_fts_ai AFTER INSERT ON users BEGIN
+    INSERT INTO users_fts (rowid, name, email) VALUES (new.rowid, new.name, new.email);
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_fts_ad AFTER DELETE ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, name, email) VALUES ('delete', old.rowid, old.name, old.email);
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_fts_au AFTER UPDATE ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, name, email) VALUES ('delete', old.rowid, old.name, old.email);
+    INSERT INTO users_fts (rowid, name, email) VALUES (new.rowid, new.name, new.email);
+END;
+
+INSERT INTO users_fts (users_fts) VALUES ('rebuild');
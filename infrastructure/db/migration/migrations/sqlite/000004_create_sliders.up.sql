@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS sliders (
+    id TEXT NOT NULL PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    file TEXT NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_sliders_deleted_at ON sliders (deleted_at);
@@ -0,0 +1,17 @@
+-- Events written in the same transaction as the change they describe and
+-- delivered to the configured sinks by the outbox relay.
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id TEXT NOT NULL PRIMARY KEY,
+    topic TEXT NOT NULL,
+    message_key TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at DATETIME NOT NULL,
+    locked_until DATETIME,
+    published_at DATETIME,
+    dead_at DATETIME,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages (published_at, dead_at, available_at);
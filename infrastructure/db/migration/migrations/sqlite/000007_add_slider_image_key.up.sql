@@ -0,0 +1 @@
+ALTER TABLE sliders ADD COLUMN image_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS slider_translations;
+
+DROP INDEX IF EXISTS idx_sliders_published_position;
+
+ALTER TABLE sliders DROP COLUMN ends_at;
+ALTER TABLE sliders DROP COLUMN starts_at;
+ALTER TABLE sliders DROP COLUMN published;
+ALTER TABLE sliders DROP COLUMN position;
+ALTER TABLE sliders DROP COLUMN link_url;
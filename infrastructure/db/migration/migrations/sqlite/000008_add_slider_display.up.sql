@@ -0,0 +1,20 @@
+-- Carousel settings: display order, publish state, an optional schedule and
+-- link, and titles and descriptions per locale. Existing sliders stay
+-- visible.
+ALTER TABLE sliders ADD COLUMN link_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE sliders ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sliders ADD COLUMN published NUMERIC NOT NULL DEFAULT 1;
+ALTER TABLE sliders ADD COLUMN starts_at DATETIME;
+ALTER TABLE sliders ADD COLUMN ends_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_sliders_published_position ON sliders (published, position);
+
+CREATE TABLE IF NOT EXISTS slider_translations (
+    slider_id TEXT NOT NULL REFERENCES sliders (id) ON DELETE CASCADE,
+    locale TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    PRIMARY KEY (slider_id, locale)
+);
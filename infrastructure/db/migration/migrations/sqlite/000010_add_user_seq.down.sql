@@ -0,0 +1,43 @@
+DROP TRIGGER IF EXISTS users_fts_au;
+DROP TRIGGER IF EXISTS users_fts_ad;
+DROP TRIGGER IF EXISTS users_fts_ai;
+DROP TABLE IF EXISTS users_fts;
+
+CREATE TABLE users_rowid (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    name TEXT,
+    email TEXT UNIQUE,
+    password TEXT NOT NULL,
+    active NUMERIC DEFAULT 0,
+    role TEXT NOT NULL DEFAULT '1',
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    version INTEGER NOT NULL DEFAULT 1,
+    avatar_key TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO users_rowid (id, name, email, password, active, role, created_at, updated_at, deleted_at, version, avatar_key)
+SELECT id, name, email, password, active, role, created_at, updated_at, deleted_at, version, avatar_key FROM users ORDER BY seq;
+
+DROP TABLE users;
+ALTER TABLE users_rowid RENAME TO users;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE VIRTUAL TABLE users_fts USING fts5(name, email, content = 'users', content_rowid = 'rowid', tokenize = 'unicode61');
+
+CREATE TRIGGER users_fts_ai AFTER INSERT ON users BEGIN
+    INSERT INTO users_fts (rowid, name, email) VALUES (new.rowid, new.name, new.email);
+END;
+
+CREATE TRIGGER users_fts_ad AFTER DELETE ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, name, email) VALUES ('delete', old.rowid, old.name, old.email);
+END;
+
+CREATE TRIGGER users_fts_au AFTER UPDATE ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, name, email) VALUES ('delete', old.rowid, old.name, old.email);
+    INSERT INTO users_fts (rowid, name, email) VALUES (new.rowid, new.name, new.email);
+END;
+
+INSERT INTO users_fts (users_fts) VALUES ('rebuild');
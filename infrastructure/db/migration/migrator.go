@@ -0,0 +1,240 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultLockTimeout bounds how long a run waits for a concurrent one.
+const DefaultLockTimeout = time.Minute
+
+type Migrator struct {
+	DB          *gorm.DB
+	Dialect     string
+	Migrations  []Migration
+	LockTimeout time.Duration
+}
+
+// Record is a row of schema_migrations.
+type Record struct {
+	Version   uint64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Status describes one known migration. Modified is set when the up script
+// changed after it was applied; Missing when a version is recorded in the
+// database but has no file.
+type Status struct {
+	Version   uint64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool
+	Missing   bool
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. It refuses to run when an applied migration was modified or
+// is missing, since the schema could then differ from what the files say.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		records, err := m.records(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+
+		for _, mig := range m.Migrations {
+			if _, ok := records[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(conn, mig); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		records, err := m.records(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+
+		for i := len(m.Migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.Migrations[i]
+			if _, ok := records[mig.Version]; !ok {
+				continue
+			}
+			if err := m.revert(conn, mig); err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every migration known from files or from the database.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn := m.DB.WithContext(ctx)
+	if err := m.ensureTable(conn); err != nil {
+		return nil, err
+	}
+	records, err := m.records(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Status
+	for _, mig := range m.Migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if r, ok := records[mig.Version]; ok {
+			appliedAt := r.AppliedAt
+			s.Applied = true
+			s.AppliedAt = &appliedAt
+			s.Modified = r.Checksum != mig.Checksum
+			delete(records, mig.Version)
+		}
+		out = append(out, s)
+	}
+	for _, r := range records {
+		appliedAt := r.AppliedAt
+		out = append(out, Status{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: &appliedAt, Missing: true})
+	}
+	return out, nil
+}
+
+// locked runs fn on a single pinned connection while holding the
+// migration lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	timeout := m.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	l := lockerFor(m.Dialect)
+
+	return m.DB.WithContext(ctx).Connection(func(conn *gorm.DB) (err error) {
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		if err := l.lock(ctx, conn, timeout); err != nil {
+			return err
+		}
+		defer func() {
+			if uerr := l.unlock(conn); uerr != nil && err == nil {
+				err = fmt.Errorf("release migration lock: %w", uerr)
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+func (m *Migrator) ensureTable(conn *gorm.DB) error {
+	return conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error
+}
+
+func (m *Migrator) records(conn *gorm.DB) (map[uint64]Record, error) {
+	var rows []Record
+	if err := conn.Raw("SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint64]Record, len(rows))
+	for _, r := range rows {
+		out[r.Version] = r
+	}
+	return out, nil
+}
+
+func (m *Migrator) verify(records map[uint64]Record) error {
+	known := make(map[uint64]Migration, len(m.Migrations))
+	for _, mig := range m.Migrations {
+		known[mig.Version] = mig
+	}
+	for v, r := range records {
+		mig, ok := known[v]
+		if !ok {
+			return fmt.Errorf("migration %d_%s is applied but its files are missing", v, r.Name)
+		}
+		if mig.Checksum != r.Checksum {
+			return fmt.Errorf("migration %d_%s was modified after it was applied (checksum %s, recorded %s)", v, mig.Name, mig.Checksum, r.Checksum)
+		}
+	}
+	return nil
+}
+
+// apply runs an up script and records it in one transaction. MySQL commits
+// DDL implicitly, so keep MySQL migrations to one schema change each.
+func (m *Migrator) apply(conn *gorm.DB, mig Migration) error {
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range Split(mig.Up) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+			mig.Version, mig.Name, mig.Checksum, time.Now().UTC()).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(conn *gorm.DB, mig Migration) error {
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range Split(mig.Down) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+// New returns a migrator for the embedded migrations of db's dialect.
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, fmt.Errorf("load %s migrations: %w", dialect, err)
+	}
+	return &Migrator{DB: db, Dialect: dialect, Migrations: migrations, LockTimeout: DefaultLockTimeout}, nil
+}
+
+// Migrate applies all pending migrations; servers call it at startup.
+func Migrate(ctx context.Context, db *gorm.DB) error {
+	m, err := New(db)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(ctx)
+	return err
+}
@@ -0,0 +1,45 @@
+package migration
+
+import "strings"
+
+// Split cuts a script into statements at semicolons that end a line, so
+// drivers without multi-statement support can run it. Trigger bodies
+// (from a line ending in BEGIN up to END;) stay in one statement. Lines that
+// only hold a -- comment are dropped.
+func Split(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+		inBlock bool
+	)
+
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			stmts = append(stmts, strings.TrimSuffix(s, ";"))
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		upper := strings.ToUpper(trimmed)
+		switch {
+		case !inBlock && strings.HasSuffix(upper, "BEGIN"):
+			inBlock = true
+		case inBlock && (upper == "END;" || upper == "END"):
+			inBlock = false
+			flush()
+		case !inBlock && strings.HasSuffix(trimmed, ";"):
+			flush()
+		}
+	}
+	flush()
+
+	return stmts
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// OutboxMessage is an event waiting in the outbox for the relay. It is
+// delivered once PublishedAt is set and given up on once DeadAt is set.
+type OutboxMessage struct {
+	BaseModelUUID
+	Topic       string `gorm:"not null"`
+	MessageKey  string `gorm:"not null;default:''"`
+	Payload     string `gorm:"not null"`
+	Attempts    int    `gorm:"not null;default:0"`
+	LastError   string
+	AvailableAt time.Time `gorm:"not null"`
+	LockedUntil *time.Time
+	PublishedAt *time.Time
+	DeadAt      *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
@@ -8,10 +8,28 @@ import (
 
 type Slider struct {
 	BaseModelUUID
-	Title       string         `gorm:"not null"`
-	Description string         `gorm:"not null"`
-	File        string         `gorm:"not null"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Title       string `gorm:"not null"`
+	Description string `gorm:"not null"`
+	File        string `gorm:"not null"`
+	ImageKey    string `gorm:"not null;default:''"`
+	LinkURL     string `gorm:"not null;default:''"`
+	Position    int    `gorm:"not null;default:0"`
+	// Published has no gorm default, so false is written instead of
+	// falling back to the column default.
+	Published    bool `gorm:"not null"`
+	StartsAt     *time.Time
+	EndsAt       *time.Time
+	Translations []SliderTranslation `gorm:"foreignKey:SliderID"`
+	CreatedAt    time.Time           `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time           `gorm:"autoUpdateTime"`
+	DeletedAt    gorm.DeletedAt      `gorm:"index"`
+}
+
+type SliderTranslation struct {
+	SliderID    string `gorm:"type:char(36);primaryKey"`
+	Locale      string `gorm:"size:16;primaryKey"`
+	Title       string `gorm:"not null"`
+	Description string `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
@@ -13,6 +13,8 @@ type User struct {
 	Password  string         `gorm:"not null"`
 	Active    bool           `gorm:"default:0"`
 	Role      string         `gorm:"not null;default:1"`
+	AvatarKey string         `gorm:"not null;default:''"`
+	Version   uint           `gorm:"not null;default:1"`
 	CreatedAt time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// WebhookEndpoint is a URL an admin registered to receive signed event
+// notifications. Events is a comma separated list of event names or
+// patterns such as "user.*".
+type WebhookEndpoint struct {
+	BaseModelUUID
+	OwnerID        string `gorm:"type:char(36);not null;index"`
+	URL            string `gorm:"not null"`
+	Description    string `gorm:"not null;default:''"`
+	Secret         string `gorm:"not null"`
+	Events         string `gorm:"not null"`
+	Active         bool   `gorm:"not null;default:1"`
+	FailureCount   int    `gorm:"not null;default:0"`
+	DisabledAt     *time.Time
+	DisabledReason string
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// WebhookDelivery is one event to be sent to one endpoint. It is retried
+// until it succeeds or runs out of attempts.
+type WebhookDelivery struct {
+	BaseModelUUID
+	EndpointID     string `gorm:"type:char(36);not null"`
+	EventID        string `gorm:"type:char(36);not null"`
+	EventType      string `gorm:"not null"`
+	Payload        string `gorm:"not null"`
+	Status         string `gorm:"not null"`
+	Attempts       int    `gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time
+	LockedUntil    *time.Time
+	LastStatusCode int `gorm:"not null;default:0"`
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// WebhookAttempt logs a single HTTP request made for a delivery.
+type WebhookAttempt struct {
+	BaseModelUUID
+	DeliveryID   string `gorm:"type:char(36);not null;index"`
+	Attempt      int    `gorm:"not null"`
+	Manual       bool   `gorm:"not null;default:0"`
+	StatusCode   int    `gorm:"not null;default:0"`
+	ResponseBody string
+	Error        string
+	DurationMs   int64     `gorm:"not null;default:0"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
@@ -3,24 +3,65 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"net"
+	"strings"
+	"time"
 
-	"github.com/celpung/gocleanarch/infrastructure/db/model"
 	"github.com/celpung/gocleanarch/infrastructure/environment"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
+// DSN builds the driver connection string for dbName on host:port. The
+// driver's Config does the escaping, so credentials may contain any
+// character; an empty dbName connects to the server without selecting a
+// database.
+func DSN(host, port, dbName string) (string, error) {
+	tlsName, err := registerTLSConfig()
+	if err != nil {
+		return "", err
+	}
+
+	cfg := mysqldriver.NewConfig()
+	cfg.User = environment.Env.DB_USERNAME
+	cfg.Passwd = environment.Env.DB_PASSWORD
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, port)
+	cfg.DBName = dbName
+	cfg.ParseTime = true
+	cfg.Loc = time.Local
+	cfg.Timeout = 10 * time.Second
+	cfg.TLSConfig = tlsName
+	cfg.Params = map[string]string{"charset": "utf8mb4"}
+	return cfg.FormatDSN(), nil
+}
+
+// Dialector opens the application database on host:port; replicas use it
+// with their own address.
+func Dialector(host, port string) (gorm.Dialector, error) {
+	dsn, err := DSN(host, port, environment.Env.DB_NAME)
+	if err != nil {
+		return nil, err
+	}
+	return mysql.Open(dsn), nil
+}
+
+// QuoteIdentifier quotes a database name for use in DDL.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
 func CreateDatabaseIfNotExists() error {
-	dbUser := environment.Env.DB_USERNAME
-	dbPassword := environment.Env.DB_PASSWORD
-	dbHost := environment.Env.DB_HOST
-	dbPort := environment.Env.DB_PORT
 	dbName := environment.Env.DB_NAME
 
 	// Connect to MySQL server without specifying a database
-	dsnWithoutDB := fmt.Sprintf("%s:%s@tcp(%s:%s)/?charset=utf8mb4&parseTime=True&loc=Local", dbUser, dbPassword, dbHost, dbPort)
+	dsnWithoutDB, err := DSN(environment.Env.DB_HOST, environment.Env.DB_PORT, "")
+	if err != nil {
+		return err
+	}
 	sqlDB, err := sql.Open("mysql", dsnWithoutDB)
 	if err != nil {
 		return fmt.Errorf("failed to connect to MySQL server: %w", err)
@@ -35,7 +76,7 @@ func CreateDatabaseIfNotExists() error {
 
 	// If the database doesn't exist, create new one
 	if count == 0 {
-		if _, err := sqlDB.Exec(fmt.Sprintf("CREATE DATABASE %s", dbName)); err != nil {
+		if _, err := sqlDB.Exec("CREATE DATABASE " + QuoteIdentifier(dbName)); err != nil {
 			return fmt.Errorf("failed to create database: %w", err)
 		}
 	}
@@ -44,14 +85,12 @@ func CreateDatabaseIfNotExists() error {
 }
 
 func ConnectDatabase() error {
-	dbUser := environment.Env.DB_USERNAME
-	dbPassword := environment.Env.DB_PASSWORD
-	dbHost := environment.Env.DB_HOST
-	dbPort := environment.Env.DB_PORT
-	dbName := environment.Env.DB_NAME
+	dialector, err := Dialector(environment.Env.DB_HOST, environment.Env.DB_PORT)
+	if err != nil {
+		return err
+	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", dbUser, dbPassword, dbHost, dbPort, dbName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to connect database: %w", err)
 	}
@@ -59,16 +98,3 @@ func ConnectDatabase() error {
 	DB = db
 	return nil
 }
-
-func AutoMigrate() error {
-	if DB == nil {
-		return fmt.Errorf("database connection is not initialized")
-	}
-	if err := DB.AutoMigrate(
-		&model.User{},
-		&model.Slider{},
-	); err != nil {
-		return fmt.Errorf("auto migrate failed: %w", err)
-	}
-	return nil
-}
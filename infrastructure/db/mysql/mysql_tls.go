@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+// tlsConfigName is the name the custom TLS configuration is registered
+// under with the driver.
+const tlsConfigName = "gocleanarch"
+
+// registerTLSConfig maps DB_SSL_MODE, which uses PostgreSQL's vocabulary so
+// both servers share one setting, onto the driver's tls parameter:
+//
+//	disable      plain TCP
+//	prefer       TLS when the server offers it, unverified
+//	require      TLS, certificate not verified
+//	verify-ca    TLS, certificate chain checked against DB_SSL_ROOT_CERT
+//	verify-full  as verify-ca, and the host name must match
+//
+// DB_SSL_CERT and DB_SSL_KEY add a client certificate in the verify modes.
+func registerTLSConfig() (string, error) {
+	mode := environment.Env.DB_SSL_MODE
+	switch mode {
+	case "", "disable":
+		return "", nil
+	case "prefer", "allow":
+		return "preferred", nil
+	case "require":
+		return "skip-verify", nil
+	case "verify-ca", "verify-full":
+	default:
+		return "", fmt.Errorf("unsupported DB_SSL_MODE %q", mode)
+	}
+
+	cfg, err := tlsConfig(mode == "verify-full")
+	if err != nil {
+		return "", err
+	}
+	if err := mysqldriver.RegisterTLSConfig(tlsConfigName, cfg); err != nil {
+		return "", fmt.Errorf("failed to register TLS config: %w", err)
+	}
+	return tlsConfigName, nil
+}
+
+func tlsConfig(verifyHost bool) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if path := environment.Env.DB_SSL_ROOT_CERT; path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DB_SSL_ROOT_CERT: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("DB_SSL_ROOT_CERT %s contains no certificates", path)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile, keyFile := environment.Env.DB_SSL_CERT, environment.Env.DB_SSL_KEY; certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load DB_SSL_CERT/DB_SSL_KEY: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if !verifyHost {
+		// verify-ca: check the chain ourselves and skip only the host name.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("server sent no certificate")
+			}
+			opts := x509.VerifyOptions{Roots: cfg.RootCAs, Intermediates: x509.NewCertPool()}
+			for _, c := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var DB *gorm.DB
+
+// maintenanceDB is the database every PostgreSQL server has; it is used to
+// create the application database.
+const maintenanceDB = "postgres"
+
+// DSN builds a keyword/value connection string for dbName on host:port
+// from the environment. Values are quoted, so passwords may contain spaces, quotes
+// and backslashes. SSL settings map to libpq's sslmode, sslrootcert,
+// sslcert and sslkey.
+func DSN(host, port, dbName string) string {
+	params := []struct{ key, value string }{
+		{"host", host},
+		{"port", port},
+		{"user", environment.Env.DB_USERNAME},
+		{"password", environment.Env.DB_PASSWORD},
+		{"dbname", dbName},
+		{"sslmode", environment.Env.DB_SSL_MODE},
+		{"sslrootcert", environment.Env.DB_SSL_ROOT_CERT},
+		{"sslcert", environment.Env.DB_SSL_CERT},
+		{"sslkey", environment.Env.DB_SSL_KEY},
+		{"connect_timeout", "10"},
+		{"TimeZone", "UTC"},
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		parts = append(parts, p.key+"="+quoteValue(p.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// QuoteIdentifier quotes a database or role name for use in DDL.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func CreateDatabaseIfNotExists() error {
+	dbName := environment.Env.DB_NAME
+
+	// Connect to the maintenance database, since the target may not exist yet
+	sqlDB, err := sql.Open("pgx", DSN(environment.Env.DB_HOST, environment.Env.DB_PORT, maintenanceDB))
+	if err != nil {
+		return fmt.Errorf("failed to connect to PostgreSQL server: %w", err)
+	}
+	defer sqlDB.Close()
+
+	var count int64
+	if err := sqlDB.QueryRow("SELECT COUNT(*) FROM pg_database WHERE datname = $1", dbName).Scan(&count); err != nil {
+		return fmt.Errorf("failed to query database: %w", err)
+	}
+
+	// CREATE DATABASE can not run inside a transaction or take parameters
+	if count == 0 {
+		if _, err := sqlDB.Exec("CREATE DATABASE " + QuoteIdentifier(dbName)); err != nil {
+			return fmt.Errorf("failed to create database: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Dialector opens the application database on host:port; replicas use it
+// with their own address.
+func Dialector(host, port string) gorm.Dialector {
+	return postgres.Open(DSN(host, port, environment.Env.DB_NAME))
+}
+
+func ConnectDatabase() error {
+	db, err := gorm.Open(Dialector(environment.Env.DB_HOST, environment.Env.DB_PORT), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to connect database: %w", err)
+	}
+
+	DB = db
+	return nil
+}
@@ -1,25 +1,92 @@
 package sqlite
 
 import (
+	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
 
-	// user_entity "github.com/celpung/gocleanarch/domain/user/entity"
-	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
-// SetupDB initializes a SQLite in-memory database for testing,
-// creates the necessary table, and returns the DB connection.
+var DB *gorm.DB
+
+// BusyTimeout is how long a connection waits for another writer before
+// failing with SQLITE_BUSY.
+const BusyTimeout = 5 * time.Second
+
+// Path returns the database file for DB_NAME; a name without an extension
+// gets ".db" appended.
+func Path() string {
+	name := environment.Env.DB_NAME
+	if filepath.Ext(name) == "" {
+		name += ".db"
+	}
+	return name
+}
+
+// DSN returns the connection string for the database file at path. Every
+// pooled connection runs the pragmas: WAL so readers do not block the
+// writer, a busy timeout instead of immediate SQLITE_BUSY errors, and
+// foreign key enforcement, which SQLite leaves off by default. Transactions
+// take the write lock up front so two of them cannot deadlock upgrading.
+func DSN(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", BusyTimeout.Milliseconds()))
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "synchronous(NORMAL)")
+	q.Set("_txlock", "immediate")
+	return "file:" + path + "?" + q.Encode()
+}
+
+// CreateDatabaseIfNotExists makes sure the directory of the database file
+// exists; SQLite creates the file itself on first connect.
+func CreateDatabaseIfNotExists() error {
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+	return nil
+}
+
+func ConnectDatabase() error {
+	db, err := gorm.Open(sqlite.Open(DSN(Path())), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to connect database: %w", err)
+	}
+
+	DB = db
+	return nil
+}
+
+// SetupDB opens dbname, which may be ":memory:", applies the migrations and
+// returns the DB connection. An in-memory database exists per connection,
+// so the pool is limited to one.
 func SetupDB(dbname string) (*gorm.DB, error) {
-	// Initialize a SQLite in-memory database for testing
-	db, err := gorm.Open(sqlite.Open(dbname), &gorm.Config{})
+	dsn := dbname
+	if dbname != ":memory:" {
+		dsn = DSN(dbname)
+	}
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return nil, fmt.Errorf("error opening database connection: %v", err)
 	}
 
-	// AutoMigrate creates the table based on the User struct
-	if err := db.AutoMigrate(&model.User{}); err != nil {
+	if dbname == ":memory:" {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, fmt.Errorf("error opening database connection: %v", err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	if err := migration.Migrate(context.Background(), db); err != nil {
 		return nil, fmt.Errorf("error migrating database: %v", err)
 	}
 
@@ -8,18 +8,67 @@ import (
 )
 
 type Environment struct {
-	BASE_URL        string
-	PORT            string
-	APP_NAME        string
-	MODE            string
-	JWT_SECRET      string
-	DB_USERNAME     string
-	DB_PASSWORD     string
-	DB_NAME         string
-	DB_PORT         string
-	DB_HOST         string
-	DB_DIALECT      string
-	ALLOWED_ORIGINS string
+	BASE_URL              string
+	PORT                  string
+	APP_NAME              string
+	MODE                  string
+	JWT_SECRET            string
+	CURSOR_SECRET         string
+	DB_USERNAME           string
+	DB_PASSWORD           string
+	DB_NAME               string
+	DB_PORT               string
+	DB_HOST               string
+	DB_DIALECT            string
+	DB_SSL_MODE           string
+	DB_SSL_ROOT_CERT      string
+	DB_SSL_CERT           string
+	DB_SSL_KEY            string
+	DB_REPLICAS           string
+	DB_MAX_OPEN_CONNS     string
+	DB_MAX_IDLE_CONNS     string
+	DB_CONN_MAX_LIFETIME  string
+	DB_CONN_MAX_IDLE_TIME string
+	DB_CONNECT_ATTEMPTS   string
+	DB_CONNECT_BACKOFF    string
+	CACHE_DRIVER          string
+	CACHE_TTL             string
+	CACHE_SIZE            string
+	CACHE_PREFIX          string
+	REDIS_ADDR            string
+	REDIS_PASSWORD        string
+	REDIS_DB              string
+	OUTBOX_SINKS          string
+	OUTBOX_WEBHOOK_URL    string
+	OUTBOX_POLL_INTERVAL  string
+	OUTBOX_MAX_ATTEMPTS   string
+	NATS_URL              string
+	NATS_SUBJECT_PREFIX   string
+	WEBHOOK_MAX_ATTEMPTS  string
+	WEBHOOK_BACKOFF       string
+	WEBHOOK_MAX_BACKOFF   string
+	WEBHOOK_DISABLE_AFTER string
+	WEBHOOK_TIMEOUT       string
+	WEBHOOK_POLL_INTERVAL string
+	WEBHOOK_ALLOW_PRIVATE string
+	PUBLIC_DIR            string
+	STORAGE_DRIVER        string
+	STORAGE_LOCAL_DIR     string
+	STORAGE_BASE_URL      string
+	STORAGE_PUBLIC        string
+	STORAGE_SIGNING_KEY   string
+	S3_ENDPOINT           string
+	S3_REGION             string
+	S3_BUCKET             string
+	S3_ACCESS_KEY         string
+	S3_SECRET_KEY         string
+	S3_PATH_STYLE         string
+	S3_PUBLIC_URL         string
+	UPLOAD_MAX_SIZE       string
+	CLAMD_ADDRESS         string
+	CLAMD_TIMEOUT         string
+	ALLOWED_ORIGINS       string
+	SEARCH_INDEX_PATH     string
 }
 
 var Env Environment
@@ -32,18 +81,67 @@ func init() {
 
 	// Initialize environment variables with fallback to hardcoded defaults
 	Env = Environment{
-		BASE_URL:        getEnv("BASE_URL", "http://localhost"),
-		PORT:            getEnv("PORT", "8080"),
-		APP_NAME:        getEnv("APP_NAME", "GoCleanArch"),
-		MODE:            getEnv("MODE", "debug"),
-		JWT_SECRET:      getEnv("JWT_SECRET", "534LK786HJK7DHFG89"),
-		DB_USERNAME:     getEnv("DB_USERNAME", "root"),
-		DB_PASSWORD:     getEnv("DB_PASSWORD", ""),
-		DB_NAME:         getEnv("DB_NAME", "gocleanarch"),
-		DB_PORT:         getEnv("DB_PORT", "3306"),
-		DB_HOST:         getEnv("DB_HOST", "127.0.0.1"),
-		DB_DIALECT:      getEnv("DB_DIALECT", "mysql"),
-		ALLOWED_ORIGINS: getEnv("ALLOWED_ORIGINS", "http://localhost,http://localhost:5173,http://localhost:3000"),
+		BASE_URL:              getEnv("BASE_URL", "http://localhost"),
+		PORT:                  getEnv("PORT", "8080"),
+		APP_NAME:              getEnv("APP_NAME", "GoCleanArch"),
+		MODE:                  getEnv("MODE", "debug"),
+		JWT_SECRET:            getEnv("JWT_SECRET", "534LK786HJK7DHFG89"),
+		CURSOR_SECRET:         getEnv("CURSOR_SECRET", ""),
+		DB_USERNAME:           getEnv("DB_USERNAME", "root"),
+		DB_PASSWORD:           getEnv("DB_PASSWORD", ""),
+		DB_NAME:               getEnv("DB_NAME", "gocleanarch"),
+		DB_PORT:               getEnv("DB_PORT", "3306"),
+		DB_HOST:               getEnv("DB_HOST", "127.0.0.1"),
+		DB_DIALECT:            getEnv("DB_DIALECT", "mysql"),
+		DB_SSL_MODE:           getEnv("DB_SSL_MODE", "disable"),
+		DB_SSL_ROOT_CERT:      getEnv("DB_SSL_ROOT_CERT", ""),
+		DB_SSL_CERT:           getEnv("DB_SSL_CERT", ""),
+		DB_SSL_KEY:            getEnv("DB_SSL_KEY", ""),
+		DB_REPLICAS:           getEnv("DB_REPLICAS", ""),
+		DB_MAX_OPEN_CONNS:     getEnv("DB_MAX_OPEN_CONNS", "25"),
+		DB_MAX_IDLE_CONNS:     getEnv("DB_MAX_IDLE_CONNS", "10"),
+		DB_CONN_MAX_LIFETIME:  getEnv("DB_CONN_MAX_LIFETIME", "30m"),
+		DB_CONN_MAX_IDLE_TIME: getEnv("DB_CONN_MAX_IDLE_TIME", "5m"),
+		DB_CONNECT_ATTEMPTS:   getEnv("DB_CONNECT_ATTEMPTS", "10"),
+		DB_CONNECT_BACKOFF:    getEnv("DB_CONNECT_BACKOFF", "500ms"),
+		CACHE_DRIVER:          getEnv("CACHE_DRIVER", "memory"),
+		CACHE_TTL:             getEnv("CACHE_TTL", "1m"),
+		CACHE_SIZE:            getEnv("CACHE_SIZE", "10000"),
+		CACHE_PREFIX:          getEnv("CACHE_PREFIX", "gocleanarch:"),
+		REDIS_ADDR:            getEnv("REDIS_ADDR", "127.0.0.1:6379"),
+		REDIS_PASSWORD:        getEnv("REDIS_PASSWORD", ""),
+		REDIS_DB:              getEnv("REDIS_DB", "0"),
+		OUTBOX_SINKS:          getEnv("OUTBOX_SINKS", "bus"),
+		OUTBOX_WEBHOOK_URL:    getEnv("OUTBOX_WEBHOOK_URL", ""),
+		OUTBOX_POLL_INTERVAL:  getEnv("OUTBOX_POLL_INTERVAL", "1s"),
+		OUTBOX_MAX_ATTEMPTS:   getEnv("OUTBOX_MAX_ATTEMPTS", "10"),
+		NATS_URL:              getEnv("NATS_URL", "nats://127.0.0.1:4222"),
+		NATS_SUBJECT_PREFIX:   getEnv("NATS_SUBJECT_PREFIX", "gocleanarch."),
+		WEBHOOK_MAX_ATTEMPTS:  getEnv("WEBHOOK_MAX_ATTEMPTS", "8"),
+		WEBHOOK_BACKOFF:       getEnv("WEBHOOK_BACKOFF", "30s"),
+		WEBHOOK_MAX_BACKOFF:   getEnv("WEBHOOK_MAX_BACKOFF", "6h"),
+		WEBHOOK_DISABLE_AFTER: getEnv("WEBHOOK_DISABLE_AFTER", "20"),
+		WEBHOOK_TIMEOUT:       getEnv("WEBHOOK_TIMEOUT", "10s"),
+		WEBHOOK_POLL_INTERVAL: getEnv("WEBHOOK_POLL_INTERVAL", "1s"),
+		WEBHOOK_ALLOW_PRIVATE: getEnv("WEBHOOK_ALLOW_PRIVATE", "false"),
+		PUBLIC_DIR:            getEnv("PUBLIC_DIR", ""),
+		STORAGE_DRIVER:        getEnv("STORAGE_DRIVER", "local"),
+		STORAGE_LOCAL_DIR:     getEnv("STORAGE_LOCAL_DIR", ""),
+		STORAGE_BASE_URL:      getEnv("STORAGE_BASE_URL", "/images"),
+		STORAGE_PUBLIC:        getEnv("STORAGE_PUBLIC", "true"),
+		STORAGE_SIGNING_KEY:   getEnv("STORAGE_SIGNING_KEY", ""),
+		S3_ENDPOINT:           getEnv("S3_ENDPOINT", ""),
+		S3_REGION:             getEnv("S3_REGION", "us-east-1"),
+		S3_BUCKET:             getEnv("S3_BUCKET", ""),
+		S3_ACCESS_KEY:         getEnv("S3_ACCESS_KEY", ""),
+		S3_SECRET_KEY:         getEnv("S3_SECRET_KEY", ""),
+		S3_PATH_STYLE:         getEnv("S3_PATH_STYLE", "true"),
+		S3_PUBLIC_URL:         getEnv("S3_PUBLIC_URL", ""),
+		UPLOAD_MAX_SIZE:       getEnv("UPLOAD_MAX_SIZE", "5242880"),
+		CLAMD_ADDRESS:         getEnv("CLAMD_ADDRESS", ""),
+		CLAMD_TIMEOUT:         getEnv("CLAMD_TIMEOUT", "30s"),
+		ALLOWED_ORIGINS:       getEnv("ALLOWED_ORIGINS", "http://localhost,http://localhost:5173,http://localhost:3000"),
+		SEARCH_INDEX_PATH:     getEnv("SEARCH_INDEX_PATH", "data/users.idx"),
 	}
 }
 
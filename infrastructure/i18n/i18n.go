@@ -0,0 +1,162 @@
+// Package i18n translates API messages and validation errors. Catalogs are
+// embedded from locales/<locale>.json; each holds field labels, validation
+// rule templates, per-field rule overrides and response messages. Messages
+// are keyed by their English text, so code reads as before and anything a
+// catalog lacks falls back to the default locale and then to the key.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed locales/*.json
+var embedded embed.FS
+
+// Default is the locale used when a request asks for none we support.
+const Default = "en"
+
+// Catalog is the translations of one locale. Templates name their
+// arguments in braces: {label}, {param}, {rule} and {other}, the label of
+// the field a cross-field rule's param names.
+type Catalog struct {
+	// Labels maps JSON field names to display names.
+	Labels map[string]string `json:"labels"`
+	// Rules maps validation rules to message templates. A ".string"
+	// suffix selects the template for string fields, e.g. "min.string";
+	// "default" and "default.param" cover rules without one.
+	Rules map[string]string `json:"rules"`
+	// Fields overrides Rules for single fields, by field then rule.
+	Fields map[string]map[string]string `json:"fields"`
+	// Messages maps English response messages to this locale.
+	Messages map[string]string `json:"messages"`
+}
+
+var catalogs = mustLoad()
+
+func mustLoad() map[string]*Catalog {
+	loaded, err := load(embedded)
+	if err != nil {
+		panic(fmt.Sprintf("i18n: %v", err))
+	}
+	if _, ok := loaded[Default]; !ok {
+		panic("i18n: no catalog for the default locale " + Default)
+	}
+	return loaded
+}
+
+func load(fsys fs.FS) (map[string]*Catalog, error) {
+	files, err := fs.Glob(fsys, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]*Catalog, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var c Catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		loaded[strings.TrimSuffix(path.Base(file), ".json")] = &c
+	}
+	return loaded, nil
+}
+
+// Supported lists the locales with a catalog, sorted.
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// For returns the catalog of locale, or the default one when locale is not
+// supported.
+func For(locale string) *Catalog {
+	if c, ok := catalogs[locale]; ok {
+		return c
+	}
+	return catalogs[Default]
+}
+
+// Translate returns msg in locale.
+func Translate(locale, msg string) string {
+	if t, ok := lookup(locale, func(c *Catalog) string { return c.Messages[msg] }); ok {
+		return t
+	}
+	return msg
+}
+
+// T returns msg in the locale of ctx.
+func T(ctx context.Context, msg string) string {
+	return Translate(Locale(ctx), msg)
+}
+
+// Label returns the display name of the JSON field in locale. ok is false
+// when no catalog names it.
+func Label(locale, field string) (string, bool) {
+	return lookup(locale, func(c *Catalog) string { return c.Labels[field] })
+}
+
+// Rule returns the template for a broken rule on field in locale: the
+// field's override, else the rule's template for strings when str is set,
+// else the rule's own. ok is false when no catalog has one.
+func Rule(locale, field, rule string, str bool) (string, bool) {
+	return lookup(locale, func(c *Catalog) string {
+		if t := c.Fields[field][rule]; t != "" {
+			return t
+		}
+		if t := c.Rules[rule+".string"]; str && t != "" {
+			return t
+		}
+		return c.Rules[rule]
+	})
+}
+
+// SetRule sets the template of a validation rule in locale, for rules
+// registered at run time. Like the rules themselves, it is meant to be
+// called during start-up, before requests are served.
+func SetRule(locale, rule, tmpl string) error {
+	c, ok := catalogs[locale]
+	if !ok {
+		return fmt.Errorf("i18n: unsupported locale %q", locale)
+	}
+	if c.Rules == nil {
+		c.Rules = map[string]string{}
+	}
+	c.Rules[rule] = tmpl
+	return nil
+}
+
+// Format fills the {name} arguments of tmpl from pairs of names and values.
+func Format(tmpl string, args ...string) string {
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+args[i]+"}", args[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// lookup reads an entry from the catalog of locale, falling back to the
+// default catalog.
+func lookup(locale string, get func(*Catalog) string) (string, bool) {
+	if t := get(For(locale)); t != "" {
+		return t, true
+	}
+	if t := get(catalogs[Default]); t != "" {
+		return t, true
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package i18n_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the i18n tests from the project root:
+     go test -v ./infrastructure/i18n
+
+Notes:
+- The message scan reads the delivery sources relative to this folder, so
+  run it from the module as above rather than from a copied binary.
+===============================================================================
+*/
+
+/*
+TestI18n_NegotiatePrefersSupportedLocale verifies that Accept-Language is
+read by quality, that regional tags match their language and that anything
+unsupported falls back to English.
+*/
+func TestI18n_NegotiatePrefersSupportedLocale(t *testing.T) {
+	cases := map[string]string{
+		"":                         "en",
+		"id":                       "id",
+		"id-ID":                    "id",
+		"ID-id":                    "id",
+		"en;q=0.5, id;q=0.9":       "id",
+		"fr-CA, fr;q=0.9, id;q=.1": "id",
+		"fr, *;q=0.5, id;q=0.1":    "en",
+		"id;q=0, en":               "en",
+		"de":                       "en",
+		"id;q=abc, en":             "en",
+	}
+	for header, want := range cases {
+		require.Equal(t, want, i18n.Negotiate(header), header)
+	}
+	require.Equal(t, []string{"en", "id"}, i18n.Supported())
+}
+
+/*
+TestI18n_CatalogsAreComplete verifies that every catalog translates each
+label, rule, field override and message the English catalog defines.
+*/
+func TestI18n_CatalogsAreComplete(t *testing.T) {
+	en := i18n.For(i18n.Default)
+	for _, locale := range i18n.Supported() {
+		c := i18n.For(locale)
+		require.ElementsMatch(t, keysOf(en.Labels), keysOf(c.Labels), "labels of %s", locale)
+		require.ElementsMatch(t, keysOf(en.Rules), keysOf(c.Rules), "rules of %s", locale)
+		require.ElementsMatch(t, keysOf(en.Messages), keysOf(c.Messages), "messages of %s", locale)
+		for field, rules := range en.Fields {
+			require.ElementsMatch(t, keysOf(rules), keysOf(c.Fields[field]), "rules of %s in %s", field, locale)
+		}
+	}
+}
+
+/*
+TestI18n_DeliveryMessagesAreInTheCatalog verifies that every problem title
+and response message written by the deliveries has a catalog entry, so new
+messages are not silently left untranslated.
+*/
+func TestI18n_DeliveryMessagesAreInTheCatalog(t *testing.T) {
+	/* Titles are the string literal a problem is built with; messages are
+	the literal passed to i18n.T or to a helper that does so. */
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`problem\.(?:New|From)\([^"\n]*"([^"]+)"`),
+		regexp.MustCompile(`i18n\.T\([^,]+, "([^"]+)"\)`),
+		regexp.MustCompile(`(?:respond\w+|setPublished)\([^"\n]*"([^"]+)"`),
+	}
+
+	found := map[string]bool{}
+	err := filepath.WalkDir(filepath.Join("..", "..", "delivery"), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+			return err
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, p := range patterns {
+			for _, m := range p.FindAllStringSubmatch(string(src), -1) {
+				found[m[1]] = true
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, found)
+
+	messages := i18n.For(i18n.Default).Messages
+	for msg := range found {
+		require.Contains(t, messages, msg)
+	}
+}
+
+func keysOf[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Header is the request header listing the languages a client accepts.
+const Header = "Accept-Language"
+
+type ctxKey struct{}
+
+// WithLocale stores locale in ctx; the locale middlewares call it with the
+// result of Negotiate.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, locale)
+}
+
+// Locale returns the locale stored in ctx, or Default.
+func Locale(ctx context.Context) string {
+	if l, ok := ctx.Value(ctxKey{}).(string); ok && l != "" {
+		return l
+	}
+	return Default
+}
+
+// Negotiate picks the supported locale a client prefers from the value of
+// an Accept-Language header, e.g. "id-ID,id;q=0.9,en;q=0.8". Regional tags
+// match their language and "*" or no match yields Default.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{strings.ToLower(tag), q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return Default
+		}
+		base, _, _ := strings.Cut(c.tag, "-")
+		for _, tag := range []string{c.tag, base} {
+			if _, ok := catalogs[tag]; ok {
+				return tag
+			}
+		}
+	}
+	return Default
+}
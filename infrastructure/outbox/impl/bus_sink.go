@@ -0,0 +1,57 @@
+package outbox_impl
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+)
+
+// AllTopics subscribes a handler to every topic.
+const AllTopics = "*"
+
+// BusHandler consumes a message. It may see the same message more than
+// once and should use the message ID to ignore repeats.
+type BusHandler func(ctx context.Context, msg outbox.Message) error
+
+// Bus is an in-process sink: other modules of the application subscribe
+// to topics and get outbox messages with the same at least once guarantee
+// as external consumers.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]BusHandler
+}
+
+// DefaultBus is the bus the application's relay delivers to.
+var DefaultBus = NewBus()
+
+func (b *Bus) Subscribe(topic string, handler BusHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+func (b *Bus) Name() string { return "bus" }
+
+// Deliver runs every handler for the message's topic and fails if any of
+// them failed, so the relay retries the message.
+func (b *Bus) Deliver(ctx context.Context, msg outbox.Message) error {
+	b.mu.RLock()
+	handlers := append(append([]BusHandler(nil), b.handlers[msg.Topic]...), b.handlers[AllTopics]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]BusHandler)}
+}
+
+var _ outbox.Sink = (*Bus)(nil)
@@ -0,0 +1,76 @@
+package outbox_impl
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+	"github.com/nats-io/nats.go"
+	"gorm.io/gorm"
+)
+
+// NewRelayFromEnv builds the relay for db with the sinks listed in
+// OUTBOX_SINKS: "bus" (DefaultBus), "webhook" (OUTBOX_WEBHOOK_URL) and
+// "nats" (JetStream at NATS_URL), after the always-on sinks in builtin.
+// The returned func releases the sinks' connections.
+func NewRelayFromEnv(db *gorm.DB, builtin ...outbox.Sink) (*Relay, func(), error) {
+	sinks := append([]outbox.Sink(nil), builtin...)
+	var closers []func()
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	for _, name := range strings.Split(environment.Env.OUTBOX_SINKS, ",") {
+		switch strings.TrimSpace(strings.ToLower(name)) {
+		case "":
+		case "bus":
+			sinks = append(sinks, DefaultBus)
+		case "webhook":
+			if environment.Env.OUTBOX_WEBHOOK_URL == "" {
+				closeAll()
+				return nil, nil, fmt.Errorf("OUTBOX_WEBHOOK_URL is required for the webhook sink")
+			}
+			sinks = append(sinks, NewWebhookSink(environment.Env.OUTBOX_WEBHOOK_URL, &http.Client{Timeout: 10 * time.Second}))
+		case "nats":
+			conn, err := nats.Connect(environment.Env.NATS_URL, nats.MaxReconnects(-1))
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to connect NATS at %s: %w", environment.Env.NATS_URL, err)
+			}
+			closers = append(closers, conn.Close)
+			js, err := conn.JetStream()
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			sinks = append(sinks, NewNATSSink(js, environment.Env.NATS_SUBJECT_PREFIX))
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("unsupported outbox sink %q (use bus, webhook or nats)", name)
+		}
+	}
+
+	relay := NewRelay(NewGormStore(db), sinks...)
+
+	poll, err := time.ParseDuration(environment.Env.OUTBOX_POLL_INTERVAL)
+	if err != nil || poll <= 0 {
+		closeAll()
+		return nil, nil, fmt.Errorf("OUTBOX_POLL_INTERVAL must be a positive duration, got %q", environment.Env.OUTBOX_POLL_INTERVAL)
+	}
+	relay.PollInterval = poll
+
+	attempts, err := strconv.Atoi(environment.Env.OUTBOX_MAX_ATTEMPTS)
+	if err != nil || attempts < 1 {
+		closeAll()
+		return nil, nil, fmt.Errorf("OUTBOX_MAX_ATTEMPTS must be a positive integer, got %q", environment.Env.OUTBOX_MAX_ATTEMPTS)
+	}
+	relay.MaxAttempts = attempts
+
+	return relay, closeAll, nil
+}
@@ -0,0 +1,127 @@
+package outbox_impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+	uow_impl "github.com/celpung/gocleanarch/infrastructure/uow/impl"
+	"gorm.io/gorm"
+)
+
+// maxErrorLength keeps last_error readable and within column limits.
+const maxErrorLength = 1000
+
+// GormStore keeps the outbox in the outbox_messages table.
+type GormStore struct {
+	DB *gorm.DB
+	// Now is the clock; tests replace it to make messages due.
+	Now func() time.Time
+}
+
+func (s *GormStore) Add(ctx context.Context, msgs ...outbox.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	now := s.Now().UTC()
+	rows := make([]*model.OutboxMessage, len(msgs))
+	for i, msg := range msgs {
+		rows[i] = &model.OutboxMessage{
+			BaseModelUUID: model.BaseModelUUID{ID: msg.ID},
+			Topic:         msg.Topic,
+			MessageKey:    msg.Key,
+			Payload:       string(msg.Payload),
+			AvailableAt:   now,
+			CreatedAt:     now,
+		}
+	}
+	return uow_impl.DB(ctx, s.DB).Create(rows).Error
+}
+
+// Claim picks due messages oldest first and leases each with a conditional
+// update, which only one relay can win. That keeps it portable across
+// dialects without SELECT ... FOR UPDATE SKIP LOCKED.
+func (s *GormStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]outbox.Message, error) {
+	now := s.Now().UTC()
+	db := s.DB.WithContext(ctx)
+
+	var due []*model.OutboxMessage
+	if err := db.
+		Where("published_at IS NULL AND dead_at IS NULL AND available_at <= ?", now).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Order("created_at, id").
+		Limit(limit).
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	claimed := make([]outbox.Message, 0, len(due))
+	for _, m := range due {
+		res := db.Model(&model.OutboxMessage{}).
+			Where("id = ? AND published_at IS NULL AND dead_at IS NULL", m.ID).
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			Update("locked_until", now.Add(lease))
+		if res.Error != nil {
+			return claimed, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		claimed = append(claimed, outbox.Message{
+			ID:        m.ID,
+			Topic:     m.Topic,
+			Key:       m.MessageKey,
+			Payload:   []byte(m.Payload),
+			Attempts:  m.Attempts,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	return claimed, nil
+}
+
+func (s *GormStore) MarkPublished(ctx context.Context, id string) error {
+	return s.DB.WithContext(ctx).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"published_at": s.Now().UTC(),
+			"locked_until": nil,
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error
+}
+
+func (s *GormStore) MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error {
+	return s.DB.WithContext(ctx).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"available_at": retryAt.UTC(),
+			"locked_until": nil,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   truncate(cause.Error()),
+		}).Error
+}
+
+func (s *GormStore) MarkDead(ctx context.Context, id string, cause error) error {
+	return s.DB.WithContext(ctx).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"dead_at":      s.Now().UTC(),
+			"locked_until": nil,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   truncate(cause.Error()),
+		}).Error
+}
+
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{DB: db, Now: time.Now}
+}
+
+var _ outbox.Store = (*GormStore)(nil)
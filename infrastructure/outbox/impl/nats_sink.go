@@ -0,0 +1,39 @@
+package outbox_impl
+
+import (
+	"context"
+
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+	"github.com/nats-io/nats.go"
+)
+
+// JetStreamPublisher is the part of nats.JetStreamContext the sink uses.
+type JetStreamPublisher interface {
+	PublishMsg(m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error)
+}
+
+// NATSSink publishes messages to JetStream on SubjectPrefix + topic. The
+// stream acknowledges each publish, and the Nats-Msg-Id header lets it drop
+// redeliveries within its duplicate window.
+type NATSSink struct {
+	JetStream     JetStreamPublisher
+	SubjectPrefix string
+}
+
+func (s *NATSSink) Name() string { return "nats" }
+
+func (s *NATSSink) Deliver(ctx context.Context, msg outbox.Message) error {
+	m := nats.NewMsg(s.SubjectPrefix + msg.Topic)
+	m.Data = msg.Payload
+	m.Header.Set(nats.MsgIdHdr, msg.ID)
+	m.Header.Set(HeaderKey, msg.Key)
+
+	_, err := s.JetStream.PublishMsg(m, nats.Context(ctx))
+	return err
+}
+
+func NewNATSSink(js JetStreamPublisher, subjectPrefix string) *NATSSink {
+	return &NATSSink{JetStream: js, SubjectPrefix: subjectPrefix}
+}
+
+var _ outbox.Sink = (*NATSSink)(nil)
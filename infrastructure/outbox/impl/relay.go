@@ -0,0 +1,120 @@
+package outbox_impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+)
+
+// Relay moves messages from the outbox to the sinks. A message counts as
+// published only when every sink accepted it; if one fails, all sinks get
+// it again on the next attempt. After MaxAttempts failures the message is
+// marked dead and left in the table for inspection.
+type Relay struct {
+	Store        outbox.Store
+	Sinks        []outbox.Sink
+	BatchSize    int
+	PollInterval time.Duration
+	// Lease is how long a claimed message is hidden from other relays; it
+	// must exceed the time needed to deliver a batch.
+	Lease       time.Duration
+	MaxAttempts int
+	// Backoff is the wait before the first retry; it doubles with every
+	// attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// DeliveryTimeout bounds a single Deliver call.
+	DeliveryTimeout time.Duration
+	// Now is the clock; tests replace it.
+	Now func() time.Time
+}
+
+// Run delivers batches until ctx is cancelled, sleeping PollInterval
+// whenever the outbox is empty.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		n, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("outbox relay: %v", err)
+		}
+		if n > 0 && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.PollInterval):
+		}
+	}
+}
+
+// RunOnce claims one batch and delivers it, returning how many messages it
+// handled.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	msgs, err := r.Store.Claim(ctx, r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	for _, msg := range msgs {
+		if err := r.deliver(ctx, msg); err != nil {
+			return len(msgs), err
+		}
+	}
+	return len(msgs), nil
+}
+
+func (r *Relay) deliver(ctx context.Context, msg outbox.Message) error {
+	var errs []error
+	for _, sink := range r.Sinks {
+		dctx, cancel := context.WithTimeout(ctx, r.DeliveryTimeout)
+		err := sink.Deliver(dctx, msg)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return r.Store.MarkPublished(ctx, msg.ID)
+	}
+
+	cause := errors.Join(errs...)
+	attempt := msg.Attempts + 1
+	if attempt >= r.MaxAttempts {
+		log.Printf("outbox relay: giving up on %s %s after %d attempts: %v", msg.Topic, msg.ID, attempt, cause)
+		return r.Store.MarkDead(ctx, msg.ID, cause)
+	}
+	return r.Store.MarkFailed(ctx, msg.ID, cause, r.Now().Add(r.backoff(attempt)))
+}
+
+// backoff returns the wait after the given failed attempt, starting at 1.
+func (r *Relay) backoff(attempt int) time.Duration {
+	wait := r.Backoff
+	for i := 1; i < attempt && wait < r.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, r.MaxBackoff)
+}
+
+// NewRelay returns a relay with production defaults; callers may adjust
+// the fields before Run.
+func NewRelay(store outbox.Store, sinks ...outbox.Sink) *Relay {
+	return &Relay{
+		Store:           store,
+		Sinks:           sinks,
+		BatchSize:       100,
+		PollInterval:    time.Second,
+		Lease:           time.Minute,
+		MaxAttempts:     10,
+		Backoff:         time.Second,
+		MaxBackoff:      time.Hour,
+		DeliveryTimeout: 10 * time.Second,
+		Now:             time.Now,
+	}
+}
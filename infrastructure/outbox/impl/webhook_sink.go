@@ -0,0 +1,62 @@
+package outbox_impl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/celpung/gocleanarch/infrastructure/outbox"
+)
+
+// Headers set on every webhook request next to the JSON payload.
+const (
+	HeaderMessageID = "X-Outbox-Message-ID"
+	HeaderTopic     = "X-Outbox-Topic"
+	HeaderKey       = "X-Outbox-Key"
+	HeaderAttempt   = "X-Outbox-Attempt"
+)
+
+// WebhookSink POSTs each message's payload to URL. Any 2xx response
+// acknowledges the message; everything else is retried.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Deliver(ctx context.Context, msg outbox.Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderMessageID, msg.ID)
+	req.Header.Set(HeaderTopic, msg.Topic)
+	req.Header.Set(HeaderKey, msg.Key)
+	req.Header.Set(HeaderAttempt, strconv.Itoa(msg.Attempts+1))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookSink{URL: url, Client: client}
+}
+
+var _ outbox.Sink = (*WebhookSink)(nil)
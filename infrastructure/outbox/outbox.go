@@ -0,0 +1,40 @@
+// Package outbox defines the transactional outbox: messages are stored in
+// the same transaction as the change they announce and delivered to sinks
+// afterwards by a relay, so an event is never lost nor sent for a change
+// that was rolled back.
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Message is one event in the outbox. ID is stable across redeliveries;
+// consumers use it to drop duplicates, because delivery is at least once.
+// Key groups messages about the same entity, e.g. a user ID.
+type Message struct {
+	ID        string
+	Topic     string
+	Key       string
+	Payload   []byte
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// Store persists messages. Add joins the transaction carried by ctx.
+// Claim leases up to limit due messages to one relay so that concurrent
+// relays do not deliver the same message at the same time.
+type Store interface {
+	Add(ctx context.Context, msgs ...Message) error
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Message, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error
+	MarkDead(ctx context.Context, id string, cause error) error
+}
+
+// Sink delivers a message to the outside world. Returning an error makes
+// the relay retry the message later.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,125 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/application/apperror"
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+)
+
+// Direction tells the repository which side of a cursor should be read.
+type Direction string
+
+const (
+	Next Direction = "next"
+	Prev Direction = "prev"
+)
+
+const (
+	DefaultLimit uint = 10
+	MaxLimit     uint = 100
+)
+
+var ErrInvalidCursor = apperror.New(apperror.ErrInvalid, "invalid cursor")
+
+// Cursor points at a row in a keyset ordered by (created_at DESC, id DESC).
+// It is handed to clients as an opaque, signed token so the position can not
+// be forged or tampered with.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Direction Direction `json:"d"`
+}
+
+// CursorPage holds the tokens a client uses to move to the adjacent pages.
+// An empty token means there is nothing more to read in that direction.
+type CursorPage struct {
+	Next string `json:"next_cursor"`
+	Prev string `json:"prev_cursor"`
+}
+
+// Encode serializes the cursor and appends an HMAC-SHA256 signature.
+func Encode(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + sign(payload)
+}
+
+// Decode verifies the signature and returns the cursor. An empty string
+// decodes to nil, meaning "start from the first page".
+func Decode(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(sign(payload))) {
+		return nil, ErrInvalidCursor
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if c.Direction != Next && c.Direction != Prev {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// NormalizeLimit applies the default and maximum page size.
+func NormalizeLimit(limit uint) uint {
+	if limit == 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// BuildPage computes next/prev tokens for a page of items that was read with
+// the given cursor. hasMore reports whether the repository found at least one
+// more row beyond the page in the direction it was reading.
+func BuildPage[T any](items []T, hasMore bool, cur *Cursor, key func(T) (time.Time, string)) CursorPage {
+	var page CursorPage
+	if len(items) == 0 {
+		return page
+	}
+
+	firstAt, firstID := key(items[0])
+	lastAt, lastID := key(items[len(items)-1])
+
+	readingBackwards := cur != nil && cur.Direction == Prev
+
+	if hasMore || readingBackwards {
+		page.Next = Encode(Cursor{CreatedAt: lastAt, ID: lastID, Direction: Next})
+	}
+	if (readingBackwards && hasMore) || (cur != nil && !readingBackwards) {
+		page.Prev = Encode(Cursor{CreatedAt: firstAt, ID: firstID, Direction: Prev})
+	}
+
+	return page
+}
+
+func sign(payload string) string {
+	secret := environment.Env.CURSOR_SECRET
+	if secret == "" {
+		secret = environment.Env.JWT_SECRET
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,77 @@
+// Package requestctx carries request scoped values, the authenticated
+// principal and the request ID, through context.Context so use cases,
+// repositories and event handlers can read them without depending on a web
+// framework.
+package requestctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the HTTP header used to accept and echo request IDs.
+const Header = "X-Request-ID"
+
+// maxRequestIDLength bounds client supplied IDs so they cannot bloat logs.
+const maxRequestIDLength = 128
+
+type ctxKey int
+
+const (
+	principalKey ctxKey = iota
+	requestIDKey
+)
+
+// Principal is the authenticated caller as read from the access token.
+type Principal struct {
+	UserID string
+	Email  string
+	Role   string
+}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFrom returns the principal stored by the auth middleware. ok is
+// false for anonymous requests.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFrom returns the request ID or an empty string when none is set.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// RequestID returns incoming when it is a usable ID (printable ASCII, not too
+// long) and a freshly generated one otherwise.
+func RequestID(incoming string) string {
+	if incoming != "" && len(incoming) <= maxRequestIDLength && printable(incoming) {
+		return incoming
+	}
+	return NewRequestID()
+}
+
+// NewRequestID returns 16 random bytes, hex encoded.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,419 @@
+package searchindex
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Document is the unit stored in the index. Fields are tokenized for
+// full-text matching; Facets are matched exactly and counted per value;
+// Stored values are only returned with the hits.
+type Document struct {
+	ID     string
+	Fields map[string]string
+	Facets map[string]string
+	Stored map[string]string
+}
+
+// Query describes a search. Every term of Text must match (after typo
+// correction) for a document to be returned. Filters restrict the result to
+// documents whose facet equals the given value.
+type Query struct {
+	Text    string
+	Filters map[string]string
+	Offset  int
+	Limit   int
+}
+
+type Hit struct {
+	Document *Document
+	Score    float64
+}
+
+// Result holds one page of hits plus facet counts computed over every
+// document that matched the query, not just the page.
+type Result struct {
+	Total  int
+	Hits   []Hit
+	Facets map[string]map[string]int
+}
+
+// Index is an embedded inverted index kept in memory and persisted to a
+// single file. Mutations only change memory; Flush rewrites the file
+// atomically when something changed, either on the schedule set by
+// FlushEvery or on Close, so bursts of updates cost one write. Changes
+// made after the last flush are lost on a crash; cmd/reindex restores
+// them from the database.
+type Index struct {
+	mu       sync.RWMutex
+	path     string
+	boosts   map[string]float64
+	docs     map[string]*Document
+	postings map[string]map[string]float64 // term -> doc ID -> weight
+	changes  uint64                        // mutations since Open
+	saved    uint64                        // changes the file holds
+
+	flushMu sync.Mutex // one write of the file at a time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type snapshot struct {
+	Version int
+	Docs    []*Document
+}
+
+const snapshotVersion = 1
+
+// Open loads the index stored at path, or starts an empty one if the file
+// does not exist yet. boosts weights matches per field; fields without a
+// boost weigh 1.
+func Open(path string, boosts map[string]float64) (*Index, error) {
+	idx := &Index{
+		path:     path,
+		boosts:   boosts,
+		docs:     make(map[string]*Document),
+		postings: make(map[string]map[string]float64),
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open search index: %w", err)
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("decode search index: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("search index version %d is not supported, rebuild it", snap.Version)
+	}
+
+	for _, d := range snap.Docs {
+		idx.add(d)
+	}
+	return idx, nil
+}
+
+// Len returns the number of indexed documents.
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.docs)
+}
+
+// Put adds or replaces a document. The next flush persists it.
+func (i *Index) Put(d *Document) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(d.ID)
+	i.add(d)
+	i.changes++
+	return nil
+}
+
+// Delete removes a document. The next flush persists it. Deleting an
+// unknown ID is not an error.
+func (i *Index) Delete(id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.docs[id]; !ok {
+		return nil
+	}
+	i.remove(id)
+	i.changes++
+	return nil
+}
+
+// Replace swaps the whole content of the index for docs and persists it
+// right away.
+func (i *Index) Replace(docs []*Document) error {
+	i.mu.Lock()
+	i.docs = make(map[string]*Document, len(docs))
+	i.postings = make(map[string]map[string]float64)
+	for _, d := range docs {
+		i.add(d)
+	}
+	i.changes++
+	i.mu.Unlock()
+
+	return i.Flush()
+}
+
+// Flush writes the index to its file if it changed since the last write.
+// Documents are collected under the read lock but encoded and written
+// without it, so searches and updates go on meanwhile; updates made during
+// the write are left for the next flush.
+func (i *Index) Flush() error {
+	i.flushMu.Lock()
+	defer i.flushMu.Unlock()
+
+	i.mu.RLock()
+	changes := i.changes
+	if changes == i.saved {
+		i.mu.RUnlock()
+		return nil
+	}
+	snap := snapshot{Version: snapshotVersion, Docs: make([]*Document, 0, len(i.docs))}
+	for _, d := range i.docs {
+		snap.Docs = append(snap.Docs, d)
+	}
+	i.mu.RUnlock()
+
+	if err := i.save(&snap); err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	i.saved = changes
+	i.mu.Unlock()
+	return nil
+}
+
+// FlushEvery flushes the index every interval in the background until
+// Close. Failed flushes are logged and retried on the next tick.
+func (i *Index) FlushEvery(interval time.Duration) {
+	i.stop = make(chan struct{})
+	i.done = make(chan struct{})
+
+	go func() {
+		defer close(i.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := i.Flush(); err != nil {
+					log.Printf("searchindex: %v", err)
+				}
+			case <-i.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the background flushes and writes what is still pending.
+func (i *Index) Close() error {
+	if i.stop != nil {
+		close(i.stop)
+		<-i.done
+		i.stop = nil
+	}
+	return i.Flush()
+}
+
+// Search runs q against the index. Hits are ordered by descending score and
+// then by document ID so pagination is stable.
+func (i *Index) Search(q Query) *Result {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	scores := i.match(Tokenize(q.Text))
+
+	res := &Result{Facets: make(map[string]map[string]int)}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		d := i.docs[id]
+		if !matchesFilters(d, q.Filters) {
+			continue
+		}
+		hits = append(hits, Hit{Document: d, Score: score})
+		for k, v := range d.Facets {
+			if res.Facets[k] == nil {
+				res.Facets[k] = make(map[string]int)
+			}
+			res.Facets[k][v]++
+		}
+	}
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Document.ID < hits[b].Document.ID
+	})
+
+	res.Total = len(hits)
+	if q.Offset >= len(hits) {
+		return res
+	}
+	hits = hits[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(hits) {
+		hits = hits[:q.Limit]
+	}
+	res.Hits = hits
+	return res
+}
+
+// match scores every document containing all query tokens. Each token is
+// expanded to the indexed terms it could stand for: the exact term, terms it
+// is a prefix of, and terms within the allowed edit distance. The best
+// expansion per document counts. An empty query matches everything.
+func (i *Index) match(tokens []string) map[string]float64 {
+	scores := make(map[string]float64)
+	if len(tokens) == 0 {
+		for id := range i.docs {
+			scores[id] = 0
+		}
+		return scores
+	}
+
+	n := float64(len(i.docs))
+	for ti, tok := range tokens {
+		best := make(map[string]float64)
+		for term, docs := range i.postings {
+			factor := similarity(tok, term)
+			if factor == 0 {
+				continue
+			}
+			idf := math.Log(1 + n/float64(len(docs)))
+			for id, w := range docs {
+				if s := factor * idf * w; s > best[id] {
+					best[id] = s
+				}
+			}
+		}
+
+		if ti == 0 {
+			scores = best
+			continue
+		}
+		for id := range scores {
+			s, ok := best[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] += s
+		}
+	}
+	return scores
+}
+
+// similarity returns how strongly an indexed term answers a query token, or
+// 0 when it does not match at all.
+func similarity(tok, term string) float64 {
+	if tok == term {
+		return 1
+	}
+	if len(tok) >= 2 && strings.HasPrefix(term, tok) {
+		return 0.8
+	}
+
+	max := maxEdits(tok)
+	if max == 0 {
+		return 0
+	}
+	lt, lr := len([]rune(tok)), len([]rune(term))
+	if lt-lr > max || lr-lt > max {
+		return 0
+	}
+	if d := editDistance(tok, term, max); d <= max {
+		return 0.9 / float64(1+d)
+	}
+	return 0
+}
+
+// maxEdits scales typo tolerance with the token length so short words do not
+// match everything.
+func maxEdits(tok string) int {
+	switch n := len([]rune(tok)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (i *Index) add(d *Document) {
+	i.docs[d.ID] = d
+	for field, text := range d.Fields {
+		boost, ok := i.boosts[field]
+		if !ok {
+			boost = 1
+		}
+		for _, term := range Tokenize(text) {
+			if i.postings[term] == nil {
+				i.postings[term] = make(map[string]float64)
+			}
+			i.postings[term][d.ID] += boost
+		}
+	}
+}
+
+func (i *Index) remove(id string) {
+	d, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	for _, text := range d.Fields {
+		for _, term := range Tokenize(text) {
+			delete(i.postings[term], id)
+			if len(i.postings[term]) == 0 {
+				delete(i.postings, term)
+			}
+		}
+	}
+	delete(i.docs, id)
+}
+
+// save writes snap next to the target and renames it into place so a crash
+// never leaves a truncated index behind.
+func (i *Index) save(snap *snapshot) error {
+	if i.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(i.path), 0o755); err != nil {
+		return fmt.Errorf("create search index directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(i.path), filepath.Base(i.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create search index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encode search index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write search index: %w", err)
+	}
+	return os.Rename(tmp.Name(), i.path)
+}
+
+func matchesFilters(d *Document, filters map[string]string) bool {
+	for k, v := range filters {
+		if !strings.EqualFold(d.Facets[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tokenize lower-cases text and splits it into runs of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
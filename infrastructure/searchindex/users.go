@@ -0,0 +1,38 @@
+package searchindex
+
+import (
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+)
+
+// Users is the process wide user index, opened by OpenUsers. It is only
+// fed by the in-process user event publisher, so every instance keeps its
+// own copy: with several instances behind a load balancer, each index only
+// sees the changes made through that instance and they diverge until
+// cmd/reindex rebuilds them.
+var Users *Index
+
+// FlushInterval is how often OpenUsers has the user index written to disk.
+var FlushInterval = 5 * time.Second
+
+// OpenUsers opens the user index stored at SEARCH_INDEX_PATH and flushes it
+// every FlushInterval until CloseUsers.
+func OpenUsers(boosts map[string]float64) error {
+	idx, err := Open(environment.Env.SEARCH_INDEX_PATH, boosts)
+	if err != nil {
+		return err
+	}
+
+	idx.FlushEvery(FlushInterval)
+	Users = idx
+	return nil
+}
+
+// CloseUsers writes the pending changes of the user index, if it is open.
+func CloseUsers() error {
+	if Users == nil {
+		return nil
+	}
+	return Users.Close()
+}
@@ -0,0 +1,13 @@
+package sqlutil
+
+import "strings"
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// EscapeLike escapes the LIKE wildcards in s so it matches literally when
+// used with ESCAPE '!'. The exclamation mark is used instead of a backslash
+// because MySQL and SQLite disagree on backslashes inside string literals.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
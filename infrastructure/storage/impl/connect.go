@@ -0,0 +1,142 @@
+package storage_impl
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/celpung/gocleanarch/infrastructure/environment"
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+)
+
+// Shared is the storage uploads are written to.
+var Shared storage.Storage
+
+// MaxUploadSize is the largest upload in bytes that handlers accept, from
+// UPLOAD_MAX_SIZE.
+var MaxUploadSize int64
+
+// Handler serves the files in Shared at MountPath. It is nil when the
+// storage serves its files itself, as S3 does.
+var Handler http.Handler
+
+// MountPath is the path of STORAGE_BASE_URL, where the mains mount Handler.
+var MountPath string
+
+// PublicDir is the absolute path of the directory holding index.html.
+var PublicDir string
+
+// ConnectStorage sets the variables above from the environment.
+// STORAGE_DRIVER selects "local" (files below STORAGE_LOCAL_DIR, the
+// default), "memory" (lost on restart) or "s3" (a bucket of an
+// S3-compatible service). Local and memory files are linked under
+// STORAGE_BASE_URL and served by Handler; signed URLs use
+// STORAGE_SIGNING_KEY, or a random key that only lasts until restart.
+func ConnectStorage() error {
+	size, err := strconv.ParseInt(environment.Env.UPLOAD_MAX_SIZE, 10, 64)
+	if err != nil || size <= 0 {
+		return fmt.Errorf("UPLOAD_MAX_SIZE must be a positive number of bytes, got %q", environment.Env.UPLOAD_MAX_SIZE)
+	}
+	MaxUploadSize = size
+
+	if PublicDir, err = findPublicDir(environment.Env.PUBLIC_DIR); err != nil {
+		return err
+	}
+
+	public, err := strconv.ParseBool(environment.Env.STORAGE_PUBLIC)
+	if err != nil {
+		return fmt.Errorf("STORAGE_PUBLIC must be true or false, got %q", environment.Env.STORAGE_PUBLIC)
+	}
+
+	baseURL := environment.Env.STORAGE_BASE_URL
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("STORAGE_BASE_URL must be a path or URL, got %q", baseURL)
+	}
+	MountPath = "/" + strings.Trim(base.Path, "/")
+
+	switch strings.ToLower(environment.Env.STORAGE_DRIVER) {
+	case "", "local":
+		dir := environment.Env.STORAGE_LOCAL_DIR
+		if dir == "" {
+			dir = filepath.Join(PublicDir, "images")
+		}
+		if dir, err = filepath.Abs(dir); err != nil {
+			return err
+		}
+
+		local := NewLocalStorage(dir, baseURL)
+		local.Signer = signerFromEnv()
+		Shared, Handler = local, NewFileHandler(local, local.Signer, public)
+	case "memory":
+		memory := NewMemoryStorage(baseURL)
+		memory.Signer = signerFromEnv()
+		Shared, Handler = memory, NewFileHandler(memory, memory.Signer, public)
+	case "s3":
+		pathStyle, err := strconv.ParseBool(environment.Env.S3_PATH_STYLE)
+		if err != nil {
+			return fmt.Errorf("S3_PATH_STYLE must be true or false, got %q", environment.Env.S3_PATH_STYLE)
+		}
+		if environment.Env.S3_ENDPOINT == "" || environment.Env.S3_BUCKET == "" {
+			return fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for STORAGE_DRIVER=s3")
+		}
+
+		s3 := NewS3Storage(environment.Env.S3_ENDPOINT, environment.Env.S3_REGION, environment.Env.S3_BUCKET,
+			environment.Env.S3_ACCESS_KEY, environment.Env.S3_SECRET_KEY, pathStyle)
+		s3.PublicURL = environment.Env.S3_PUBLIC_URL
+		Shared, Handler = s3, nil
+	default:
+		return fmt.Errorf("unsupported STORAGE_DRIVER %q (use local, memory or s3)", environment.Env.STORAGE_DRIVER)
+	}
+	return nil
+}
+
+func signerFromEnv() *URLSigner {
+	if secret := environment.Env.STORAGE_SIGNING_KEY; secret != "" {
+		return NewURLSigner([]byte(secret))
+	}
+
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	log.Println("storage: STORAGE_SIGNING_KEY is empty, signed URLs stop working on restart")
+	return NewURLSigner(secret)
+}
+
+// findPublicDir returns dir made absolute. Without dir it looks for a
+// "public" directory with an index.html in the working directory and its
+// parents, then next to the executable, so the mains find it whether they
+// run from the repository root, their own folder or an installed binary.
+func findPublicDir(dir string) (string, error) {
+	if dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	var starts []string
+	if wd, err := os.Getwd(); err == nil {
+		starts = append(starts, wd)
+	}
+	if exe, err := os.Executable(); err == nil {
+		starts = append(starts, filepath.Dir(exe))
+	}
+
+	for i, start := range starts {
+		for d := start; ; d = filepath.Dir(d) {
+			candidate := filepath.Join(d, "public")
+			if _, err := os.Stat(filepath.Join(candidate, "index.html")); err == nil {
+				return candidate, nil
+			}
+			/* Only the working directory is searched upwards. */
+			if i > 0 || filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+
+	return filepath.Abs("public")
+}
@@ -0,0 +1,78 @@
+package storage_impl
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+)
+
+// FileHandler serves the files of a LocalStorage or MemoryStorage by key,
+// with the content type they were stored with. Mount it with
+// http.StripPrefix so the request path is the key. A request carrying a
+// signature must pass Signer; when Public is false every request must.
+type FileHandler struct {
+	Storage storage.Storage
+	Signer  *URLSigner
+	Public  bool
+}
+
+func (h *FileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	key, err := storage.CleanKey(strings.TrimPrefix(r.URL.Path, "/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	signed := query.Has("signature")
+	if signed || !h.Public {
+		if h.Signer == nil || h.Signer.Verify(key, query) != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+	}
+
+	body, obj, err := h.Storage.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if signed {
+		/* A signed URL is meant for one client; shared caches would outlive its expiry. */
+		w.Header().Set("Cache-Control", "private")
+	}
+
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, path.Base(key), obj.ModTime, seeker)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = io.Copy(w, body)
+}
+
+func NewFileHandler(store storage.Storage, signer *URLSigner, public bool) *FileHandler {
+	return &FileHandler{Storage: store, Signer: signer, Public: public}
+}
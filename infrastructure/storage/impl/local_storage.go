@@ -0,0 +1,200 @@
+package storage_impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+)
+
+// LocalStorage keeps files below Root on the local disk and builds URLs by
+// joining BaseURL and the key, for a FileHandler mounted there. The content
+// type of a file is kept next to it in a hidden ".<name>.meta" file.
+type LocalStorage struct {
+	Root    string
+	BaseURL string
+	// Signer signs the URLs returned by SignedURL; nil disables them.
+	Signer *URLSigner
+}
+
+type localMetadata struct {
+	ContentType string `json:"content_type"`
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, content io.Reader, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(localMetadata{ContentType: contentType})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(ctx, metadataPath(name), strings.NewReader(string(meta))); err != nil {
+		return err
+	}
+	return writeFileAtomic(ctx, name, content)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, storage.Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, storage.Object{}, err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, storage.Object{}, notFound(err)
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, storage.Object{}, storage.ErrNotFound
+	}
+
+	return file, s.object(key, name, info), nil
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (storage.Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return storage.Object{}, err
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return storage.Object{}, notFound(err)
+	}
+	if info.IsDir() {
+		return storage.Object{}, storage.ErrNotFound
+	}
+
+	return s.object(key, name, info), nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		name, err := s.path(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.Remove(metadataPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		s.pruneEmptyDirs(filepath.Dir(name))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return strings.TrimRight(s.BaseURL, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+func (s *LocalStorage) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.Signer == nil {
+		return "", storage.ErrSigningUnavailable
+	}
+
+	return s.URL(cleaned) + "?" + s.Signer.Sign(cleaned, expiry).Encode(), nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(cleaned)), nil
+}
+
+// object describes the file at name. Files written without metadata get
+// the content type of their extension.
+func (s *LocalStorage) object(key, name string, info fs.FileInfo) storage.Object {
+	var meta localMetadata
+	if raw, err := os.ReadFile(metadataPath(name)); err == nil {
+		_ = json.Unmarshal(raw, &meta)
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = "application/octet-stream"
+	}
+
+	return storage.Object{Key: key, Size: info.Size(), ContentType: meta.ContentType, ModTime: info.ModTime()}
+}
+
+// pruneEmptyDirs removes dir and its parents up to Root while they are
+// empty, so deleted uploads do not leave a trail of directories behind.
+func (s *LocalStorage) pruneEmptyDirs(dir string) {
+	root := filepath.Clean(s.Root)
+	for dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func metadataPath(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".meta")
+}
+
+// writeFileAtomic writes content to a temporary file and renames it to
+// name, so readers never see half a file.
+func writeFileAtomic(ctx context.Context, name string, content io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return storage.ErrNotFound
+	}
+	return err
+}
+
+func NewLocalStorage(root, baseURL string) *LocalStorage {
+	return &LocalStorage{Root: root, BaseURL: baseURL}
+}
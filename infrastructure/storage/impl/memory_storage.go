@@ -0,0 +1,137 @@
+package storage_impl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+)
+
+// MemoryStorage keeps files in process memory, for tests and throwaway
+// development servers. Files are lost on restart and are not shared
+// between instances.
+type MemoryStorage struct {
+	BaseURL string
+	// Signer signs the URLs returned by SignedURL; nil disables them.
+	Signer *URLSigner
+
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	content []byte
+	info    storage.Object
+}
+
+func (s *MemoryStorage) Put(ctx context.Context, key string, content io.Reader, contentType string) error {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[cleaned] = memoryObject{
+		content: body,
+		info:    storage.Object{Key: cleaned, Size: int64(len(body)), ContentType: contentType, ModTime: time.Now()},
+	}
+	return nil
+}
+
+func (s *MemoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, storage.Object, error) {
+	obj, err := s.lookup(key)
+	if err != nil {
+		return nil, storage.Object{}, err
+	}
+
+	/* Stored bytes are never modified in place, so readers can share them. */
+	return readSeekNopCloser{bytes.NewReader(obj.content)}, obj.info, nil
+}
+
+func (s *MemoryStorage) Stat(ctx context.Context, key string) (storage.Object, error) {
+	obj, err := s.lookup(key)
+	if err != nil {
+		return storage.Object{}, err
+	}
+	return obj.info, nil
+}
+
+func (s *MemoryStorage) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		cleaned, err := storage.CleanKey(key)
+		if err != nil {
+			return err
+		}
+		delete(s.objects, cleaned)
+	}
+	return nil
+}
+
+func (s *MemoryStorage) URL(key string) string {
+	return strings.TrimRight(s.BaseURL, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+func (s *MemoryStorage) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.Signer == nil {
+		return "", storage.ErrSigningUnavailable
+	}
+
+	return s.URL(cleaned) + "?" + s.Signer.Sign(cleaned, expiry).Encode(), nil
+}
+
+// Keys lists the stored keys in no particular order.
+func (s *MemoryStorage) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *MemoryStorage) lookup(key string) (memoryObject, error) {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return memoryObject{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok := s.objects[cleaned]
+	if !ok {
+		return memoryObject{}, storage.ErrNotFound
+	}
+	return obj, nil
+}
+
+type readSeekNopCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekNopCloser) Close() error { return nil }
+
+func NewMemoryStorage(baseURL string) *MemoryStorage {
+	return &MemoryStorage{BaseURL: baseURL, objects: make(map[string]memoryObject)}
+}
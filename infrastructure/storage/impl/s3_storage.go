@@ -0,0 +1,333 @@
+package storage_impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/storage"
+)
+
+// maxPresignExpiry is the longest validity S3 accepts for a presigned URL.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// S3Storage keeps files in a bucket of an S3-compatible service such as
+// AWS S3 or MinIO, signing requests with AWS Signature Version 4.
+// PathStyle addresses the bucket as "<endpoint>/<bucket>", which MinIO and
+// most local stand-ins need; otherwise it is "<bucket>.<endpoint host>".
+// URL links PublicURL (a CDN or a public bucket) when set, and the object
+// itself otherwise.
+type S3Storage struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	PublicURL string
+	Client    *http.Client
+	Now       func() time.Time
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, content io.Reader, contentType string) error {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	/* The payload hash is part of the signature, so the body is read up front; uploads are capped well below memory limits. */
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	res, err := s.do(ctx, http.MethodPut, cleaned, header, body)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, storage.Object, error) {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return nil, storage.Object{}, err
+	}
+
+	res, err := s.do(ctx, http.MethodGet, cleaned, nil, nil)
+	if err != nil {
+		return nil, storage.Object{}, err
+	}
+	return res.Body, s3Object(cleaned, res), nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (storage.Object, error) {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return storage.Object{}, err
+	}
+
+	res, err := s.do(ctx, http.MethodHead, cleaned, nil, nil)
+	if err != nil {
+		return storage.Object{}, err
+	}
+	res.Body.Close()
+	return s3Object(cleaned, res), nil
+}
+
+// Delete removes keys one request at a time. S3 answers 204 for missing
+// keys as well, which keeps Delete idempotent.
+func (s *S3Storage) Delete(ctx context.Context, keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		cleaned, err := storage.CleanKey(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		res, err := s.do(ctx, http.MethodDelete, cleaned, nil, nil)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			errs = append(errs, err)
+			continue
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.PublicURL != "" {
+		return strings.TrimRight(s.PublicURL, "/") + "/" + strings.TrimLeft(key, "/")
+	}
+	return s.objectURL(key).String()
+}
+
+// SignedURL presigns a GET for key, valid for at most seven days.
+func (s *S3Storage) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	cleaned, err := storage.CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		return "", fmt.Errorf("presigned URL expiry must be between 1s and %s, got %s", maxPresignExpiry, expiry)
+	}
+
+	now := s.Now().UTC()
+	u := s.objectURL(cleaned)
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.AccessKey + "/" + s.scope(now)},
+		"X-Amz-Date":          {now.Format("20060102T150405Z")},
+		"X-Amz-Expires":       {strconv.Itoa(int(expiry / time.Second))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	header := http.Header{}
+	header.Set("Host", u.Host)
+	signature := s.signature(http.MethodGet, u, header, "UNSIGNED-PAYLOAD", now)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// do sends a signed request for key and turns S3 error responses into
+// errors. storage.ErrNotFound is returned for missing keys.
+func (s *S3Storage) do(ctx context.Context, method, key string, header http.Header, body []byte) (*http.Response, error) {
+	u := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	now := s.Now().UTC()
+	payloadHash := sha256Hex(body)
+	req.Header.Set("Host", u.Host)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := signedHeaderNames(req.Header)
+	signature := s.signature(method, u, req.Header, payloadHash, now)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, s.scope(now), signedHeaders, signature))
+	req.Header.Del("Host")
+	req.ContentLength = int64(len(body))
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, storage.ErrNotFound
+	}
+	var e s3Error
+	_ = xml.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&e)
+	if e.Code == "" {
+		e.Code = res.Status
+	}
+	return nil, fmt.Errorf("s3 %s %s: %s %s", method, key, e.Code, e.Message)
+}
+
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Host == "" {
+		u = &url.URL{Scheme: "https", Host: s.Endpoint}
+	}
+
+	escaped := uriEncode(key, false)
+	if s.PathStyle {
+		u.Path = "/" + s.Bucket + "/" + key
+		u.RawPath = "/" + uriEncode(s.Bucket, false) + "/" + escaped
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = "/" + escaped
+	}
+	return u
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.Region + "/s3/aws4_request"
+}
+
+// signature computes the Signature Version 4 signature of a request for u
+// with the given headers, all of which are signed.
+func (s *S3Storage) signature(method string, u *url.URL, header http.Header, payloadHash string, now time.Time) string {
+	names := strings.Split(signedHeaderNames(header), ";")
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(header.Get(name)) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(names, ";"),
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func s3Object(key string, res *http.Response) storage.Object {
+	modTime, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+	return storage.Object{
+		Key:         key,
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+		ModTime:     modTime,
+	}
+}
+
+func signedHeaderNames(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ";")
+}
+
+// canonicalQuery encodes query sorted by name, escaped the way SigV4
+// expects.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and "/"
+// unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func NewS3Storage(endpoint, region, bucket, accessKey, secretKey string, pathStyle bool) *S3Storage {
+	return &S3Storage{
+		Endpoint:  endpoint,
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		PathStyle: pathStyle,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		Now:       time.Now,
+	}
+}
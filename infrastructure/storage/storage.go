@@ -0,0 +1,82 @@
+// Package storage defines where uploaded files are kept, independent of the
+// backend that holds them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute, try to
+// leave the storage root or contain a hidden segment such as ".meta".
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// ErrNotFound is returned when no file is stored under a key.
+var ErrNotFound = errors.New("storage object not found")
+
+// ErrSigningUnavailable is returned by SignedURL when the storage has no
+// key to sign URLs with.
+var ErrSigningUnavailable = errors.New("signed URLs are not configured")
+
+// Object describes a stored file.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage keeps files under slash separated keys such as
+// "sliders/<id>/original.png", together with their content type. Deleting
+// a key that does not exist is not an error, so cleanup can be retried.
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, contentType string) error
+	// Get opens the file stored under key. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	Stat(ctx context.Context, key string) (Object, error)
+	Delete(ctx context.Context, keys ...string) error
+	// URL is the address clients use to download key.
+	URL(key string) string
+	// SignedURL is an address for key that stops working after expiry,
+	// for files that are not meant to be public.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// DeleteQuietly removes keys for cleanup once the row that owned them is
+// consistent again. It goes on when ctx is cancelled and only logs a
+// failure, which leaves files behind; owner names them in the log.
+func DeleteQuietly(ctx context.Context, s Storage, owner string, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	/* Clean up even when the request that triggered it was cancelled. */
+	if err := s.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		log.Printf("%s: failed to remove files %v: %v", owner, keys, err)
+	}
+}
+
+// CleanKey validates key and returns it in canonical form.
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+
+	/* Hidden names are reserved for the metadata backends keep next to a file. */
+	for _, segment := range strings.Split(cleaned, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", ErrInvalidKey
+		}
+	}
+	return cleaned, nil
+}
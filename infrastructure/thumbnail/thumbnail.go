@@ -0,0 +1,140 @@
+// Package thumbnail decodes uploaded images and renders resized copies of
+// them for display.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders used by image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupportedImage is returned when the content is not a JPEG, PNG,
+	// GIF or WebP image.
+	ErrUnsupportedImage = errors.New("unsupported image format")
+	// ErrImageTooLarge is returned before decoding when the image has more
+	// pixels than allowed, so a small file cannot expand into gigabytes.
+	ErrImageTooLarge = errors.New("image dimensions are too large")
+)
+
+// Format is an encoding resized copies are stored in.
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	// WebP copies are lossless, see EncodeWebP, so they only pay off for
+	// small variants.
+	WebP Format = "webp"
+)
+
+// Ext is the file extension of f, dot included.
+func (f Format) Ext() string {
+	if f == WebP {
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// MIME is the content type of f.
+func (f Format) MIME() string {
+	if f == WebP {
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
+
+// Variant is a resized copy of an image, at most Width pixels wide, stored
+// once per entry in Formats; JPEG when Formats is empty.
+type Variant struct {
+	Name    string
+	Width   int
+	Formats []Format
+}
+
+// Encodings returns the formats v is stored in.
+func (v Variant) Encodings() []Format {
+	if len(v.Formats) == 0 {
+		return []Format{JPEG}
+	}
+	return v.Formats
+}
+
+// Decode reads an image, refusing ones with more than maxPixels pixels.
+// It returns the format name registered by the decoder, such as "png".
+func Decode(content []byte, maxPixels int) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+	return img, format, nil
+}
+
+// Fit scales src down to at most width pixels wide, keeping its aspect
+// ratio. Images that are already narrow enough are copied unscaled.
+func Fit(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > width {
+		h = max(1, h*width/w)
+		w = width
+	}
+
+	/* JPEG has no alpha channel, so transparent areas become white instead of black, in every encoding alike. */
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+// EncodeJPEG writes img as a JPEG with the given quality from 1 to 100.
+func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+// Encode writes img in format f, as a JPEG with the given quality or as
+// a WebP.
+func Encode(w io.Writer, img image.Image, f Format, quality int) error {
+	if f == WebP {
+		return EncodeWebP(w, img)
+	}
+	return EncodeJPEG(w, img, quality)
+}
+
+// EncodeWebP writes img as a lossless WebP. There is no pure Go lossy WebP
+// encoder, so WebP copies trade some size for exact pixels; clients that
+// prefer smaller files can use the JPEG copy.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	return nativewebp.Encode(w, img, nil)
+}
+
+// Square crops the centre of src to a square and scales it to size pixels
+// on each side. Unlike Fit it also scales small images up, so every copy
+// has exactly the requested size.
+func Square(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, image.Rect(x0, y0, x0+side, y0+side), draw.Over, nil)
+	return dst
+}
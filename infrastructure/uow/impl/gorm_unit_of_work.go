@@ -0,0 +1,69 @@
+package uow_impl
+
+import (
+	"context"
+
+	"github.com/celpung/gocleanarch/infrastructure/uow"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type hooksKey struct{}
+
+// GormUnitOfWork runs units of work in database transactions. The open
+// transaction travels in the context; repositories pick it up with DB.
+type GormUnitOfWork struct {
+	DB *gorm.DB
+}
+
+func (u *GormUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Transaction on a *gorm.DB that is already a transaction issues a
+	// SAVEPOINT / ROLLBACK TO instead of BEGIN / ROLLBACK.
+	if InTransaction(ctx) {
+		return DB(ctx, u.DB).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+	}
+
+	hooks := &[]func(){}
+	ctx = context.WithValue(ctx, hooksKey{}, hooks)
+	err := DB(ctx, u.DB).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err == nil {
+		for _, hook := range *hooks {
+			hook()
+		}
+	}
+	return err
+}
+
+// AfterCommit runs fn once the outermost transaction in ctx commits, or
+// right away when ctx carries none. fn does not run on rollback.
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(hooksKey{}).(*[]func()); ok && InTransaction(ctx) {
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn()
+}
+
+// DB returns the transaction bound to ctx by GormUnitOfWork.Do, or db when
+// ctx carries none. Either way the result is scoped to ctx.
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTransaction reports whether ctx carries a GormUnitOfWork transaction.
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+func NewGormUnitOfWork(db *gorm.DB) uow.UnitOfWork {
+	return &GormUnitOfWork{DB: db}
+}
@@ -0,0 +1,74 @@
+package uow_impl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type scopeKey struct{}
+
+// scope collects the undo actions registered while one Do call runs.
+type scope struct {
+	undo []func()
+}
+
+// MemoryUnitOfWork is a unit of work for tests that use in-memory fakes
+// instead of a database. Fakes register how to revert each change with
+// OnRollback; the actions run in reverse order when the unit fails. Nested
+// calls behave like savepoints. Commits and Rollbacks count finished units,
+// nested ones included.
+type MemoryUnitOfWork struct {
+	mu        sync.Mutex
+	Commits   int
+	Rollbacks int
+}
+
+func (u *MemoryUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	s := &scope{}
+	parent, _ := ctx.Value(scopeKey{}).(*scope)
+
+	defer func() {
+		if p := recover(); p != nil {
+			u.rollback(s)
+			panic(p)
+		}
+		if err != nil {
+			u.rollback(s)
+			return
+		}
+		if parent != nil {
+			// a released savepoint is undone with its parent
+			parent.undo = append(parent.undo, s.undo...)
+		}
+		u.mu.Lock()
+		u.Commits++
+		u.mu.Unlock()
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("unit of work: %w", err)
+	}
+	return fn(context.WithValue(ctx, scopeKey{}, s))
+}
+
+func (u *MemoryUnitOfWork) rollback(s *scope) {
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		s.undo[i]()
+	}
+	u.mu.Lock()
+	u.Rollbacks++
+	u.mu.Unlock()
+}
+
+// OnRollback registers undo to run if the innermost unit of work in ctx
+// fails. Outside a unit of work the change is final and undo is dropped.
+func OnRollback(ctx context.Context, undo func()) {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.undo = append(s.undo, undo)
+	}
+}
+
+func NewMemoryUnitOfWork() *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{}
+}
@@ -0,0 +1,15 @@
+// Package uow defines the unit of work use cases depend on to make several
+// repository calls atomic without knowing how storage implements it.
+package uow
+
+import "context"
+
+// UnitOfWork runs fn atomically. Repositories called with the ctx passed to
+// fn take part in the same transaction. fn returning an error (or panicking)
+// rolls every change back; returning nil commits.
+//
+// Do may be nested: an inner Do runs in a savepoint, so its failure only
+// undoes the inner changes when the outer fn handles the error.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
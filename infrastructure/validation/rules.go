@@ -0,0 +1,123 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/go-playground/validator/v10"
+)
+
+// Custom rules extend the validate tags with domain constraints. Register
+// and RegisterCtx add field rules, the latter receiving the context given to
+// ValidateStructCtx so it can query the database; RegisterStruct adds rules
+// over a whole struct. Their messages are catalog templates, see i18n.Catalog.
+// Like validator's own registrations they must happen during start-up,
+// before any validation runs.
+
+// Register adds the field rule fn under tag, e.g. "role" for
+// `validate:"required,role"`. messages maps locales to its message
+// template; it may be nil when the catalogs already word the rule.
+func Register(tag string, fn validator.Func, messages map[string]string) error {
+	return RegisterCtx(tag, func(_ context.Context, fl validator.FieldLevel) bool {
+		return fn(fl)
+	}, messages)
+}
+
+// RegisterCtx adds the field rule fn under tag; fn receives the context of
+// the validation, which carries the request's deadline and values.
+func RegisterCtx(tag string, fn validator.FuncCtx, messages map[string]string) error {
+	if err := setMessages(tag, messages); err != nil {
+		return err
+	}
+	return validate.RegisterValidationCtx(tag, fn)
+}
+
+// RegisterStruct adds fn as a rule over the struct types of values, for
+// constraints spanning several fields. fn reports each violation with
+// sl.ReportError(value, jsonName, fieldName, tag, param); messages maps the
+// tags it reports to their templates by locale.
+func RegisterStruct(fn validator.StructLevelFuncCtx, messages map[string]map[string]string, values ...any) error {
+	for tag, byLocale := range messages {
+		if err := setMessages(tag, byLocale); err != nil {
+			return err
+		}
+	}
+	validate.RegisterStructValidationCtx(fn, values...)
+	return nil
+}
+
+// setMessages adds the templates of tag to the catalogs, all or none. The
+// default locale must word every rule; other locales fall back to it.
+func setMessages(tag string, messages map[string]string) error {
+	if _, ok := messages[i18n.Default]; !ok {
+		if _, ok := i18n.Rule(i18n.Default, "", tag, false); !ok {
+			return fmt.Errorf("validation: rule %q has no %s message", tag, i18n.Default)
+		}
+	}
+	for locale := range messages {
+		if !slices.Contains(i18n.Supported(), locale) {
+			return fmt.Errorf("validation: rule %q has a message for unsupported locale %q", tag, locale)
+		}
+	}
+	for locale, tmpl := range messages {
+		if err := i18n.SetRule(locale, tag, tmpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sibling returns the field of fl's struct named name in JSON, following
+// pointers; ok is false when there is none or it is nil.
+func sibling(fl validator.FieldLevel, name string) (reflect.Value, bool) {
+	parent := fl.Parent()
+	for parent.Kind() == reflect.Pointer {
+		if parent.IsNil() {
+			return reflect.Value{}, false
+		}
+		parent = parent.Elem()
+	}
+	if parent.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	for i := 0; i < parent.NumField(); i++ {
+		tag, _, _ := strings.Cut(parent.Type().Field(i).Tag.Get("json"), ",")
+		if tag != name {
+			continue
+		}
+		v := parent.Field(i)
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		return v, true
+	}
+	return reflect.Value{}, false
+}
+
+// after checks that an RFC 3339 time is later than the one in the field
+// its param names, e.g. `validate:"omitempty,after=starts_at"`. Unset or
+// malformed times pass; the datetime rule reports the latter.
+func after(fl validator.FieldLevel) bool {
+	other, ok := sibling(fl, fl.Param())
+	if !ok || other.Kind() != reflect.String || other.String() == "" {
+		return true
+	}
+	end, err := time.Parse(time.RFC3339, fl.Field().String())
+	if err != nil {
+		return true
+	}
+	start, err := time.Parse(time.RFC3339, other.String())
+	if err != nil {
+		return true
+	}
+	return end.After(start)
+}
@@ -1,23 +1,17 @@
 package validation
 
 import (
-	"fmt"
+	"context"
 	"reflect"
 	"strings"
 
+	"github.com/celpung/gocleanarch/application/apperror"
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
 	"github.com/go-playground/validator/v10"
 )
 
 var validate *validator.Validate
 
-// displayNames provides a mapping between JSON field names and user-friendly labels.
-// For example, the struct field with tag `json:"password"` will be displayed as "Password".
-// If a field is not included in this map, the code will default to using Title Case of the field name.
-var displayNames = map[string]string{
-	"email":    "Email",
-	"password": "Password",
-}
-
 // init initializes the validator instance and configures how field names are resolved
 // in validation error messages. By default, validator uses struct field names;
 // here, we override it to prefer JSON tag names for consistency with API payloads.
@@ -36,19 +30,14 @@ func init() {
 		}
 		return name
 	})
-}
 
-// fieldRuleOverrides allows custom error messages for specific fields and rules.
-// Example: "password" with "required" and "min" rules.
-var fieldRuleOverrides = map[string]map[string]string{
-	"password": {
-		"required": "Password is required",
-		"min":      "Password must be at least {param} characters long",
-	},
+	// Built-in rules, worded by the catalogs. RegisterValidation only fails
+	// for empty or reserved tags.
+	_ = validate.RegisterValidation("after", after)
 }
 
 // titleCase converts the first character of a string to uppercase.
-// Used to generate fallback labels when no displayNames mapping exists.
+// Used to generate fallback labels for fields no catalog names.
 func titleCase(s string) string {
 	if s == "" {
 		return s
@@ -56,64 +45,52 @@ func titleCase(s string) string {
 	return strings.ToUpper(s[:1]) + s[1:]
 }
 
-// translateError converts a validator.FieldError into a human-readable message.
-// It first checks for field-specific overrides, then falls back to standard
-// messages for common validation rules.
-func translateError(fe validator.FieldError) string {
+// translateError converts a validator.FieldError into a human-readable
+// message in locale, from the field's override in the i18n catalogs or the
+// template of its rule.
+func translateError(locale string, fe validator.FieldError) string {
 	field := fe.Field()
 
-	// Resolve human-friendly label
-	label, ok := displayNames[field]
+	label, ok := i18n.Label(locale, field)
 	if !ok {
 		label = titleCase(field)
 	}
 
-	// 1. Check for field-specific overrides
-	if byRule, ok := fieldRuleOverrides[field]; ok {
-		if tmpl, ok := byRule[fe.Tag()]; ok {
-			return strings.ReplaceAll(tmpl, "{param}", fe.Param())
+	tmpl, ok := i18n.Rule(locale, field, fe.Tag(), fe.Kind() == reflect.String)
+	if !ok {
+		rule := "default"
+		if fe.Param() != "" {
+			rule = "default.param"
 		}
+		tmpl, _ = i18n.Rule(locale, field, rule, false)
 	}
+	other, ok := i18n.Label(locale, fe.Param())
+	if !ok {
+		other = titleCase(fe.Param())
+	}
+	return i18n.Format(tmpl, "label", label, "param", fe.Param(), "rule", fe.Tag(), "other", other)
+}
 
-	// 2. Provide generic messages per rule
-	switch fe.Tag() {
-	case "required":
-		return fmt.Sprintf("%s is required", label)
-	case "email":
-		return fmt.Sprintf("%s must be a valid email address", label)
-	case "min":
-		if fe.Kind() == reflect.String {
-			return fmt.Sprintf("%s must be at least %s characters long", label, fe.Param())
-		}
-		return fmt.Sprintf("%s must be at least %s", label, fe.Param())
-	case "max":
-		if fe.Kind() == reflect.String {
-			return fmt.Sprintf("%s must be at most %s characters long", label, fe.Param())
-		}
-		return fmt.Sprintf("%s must be at most %s", label, fe.Param())
-	case "len":
-		if fe.Kind() == reflect.String {
-			return fmt.Sprintf("%s must be exactly %s characters long", label, fe.Param())
-		}
-		return fmt.Sprintf("%s must equal %s", label, fe.Param())
-	case "gte":
-		return fmt.Sprintf("%s must be greater than or equal to %s", label, fe.Param())
-	case "lte":
-		return fmt.Sprintf("%s must be less than or equal to %s", label, fe.Param())
-	case "oneof":
-		return fmt.Sprintf("%s must be one of: %s", label, fe.Param())
-	case "uuid4":
-		return fmt.Sprintf("%s must be a valid UUID v4", label)
-	default:
-		if fe.Param() != "" {
-			return fmt.Sprintf("%s is invalid (%s=%s)", label, fe.Tag(), fe.Param())
-		}
-		return fmt.Sprintf("%s is invalid (%s)", label, fe.Tag())
+// fieldPath names the field of fe by its JSON path from the validated
+// struct, e.g. "translations[id].title"; the struct's own name is dropped.
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
 	}
+	return fe.Field()
 }
 
-// ValidateStruct validates a struct against its defined validation rules.
-// It returns an error with user-friendly messages if validation fails.
+// ValidateStruct validates a struct against its defined validation rules,
+// with messages in the default locale. See ValidateStructCtx.
+func ValidateStruct(s any) error {
+	return ValidateStructCtx(context.Background(), s)
+}
+
+// ValidateStructCtx validates a struct against its defined validation rules.
+// When validation fails it returns an *apperror.ValidationError listing
+// each violation with its field path, rule, parameter and a user-friendly
+// message in the locale of ctx.
 // Example:
 //
 //	type LoginRequest struct {
@@ -121,18 +98,24 @@ func translateError(fe validator.FieldError) string {
 //	    Password string `json:"password" validate:"required,min=8"`
 //	}
 //
-//	if err := validation.ValidateStruct(req); err != nil {
+//	if err := validation.ValidateStructCtx(ctx, req); err != nil {
 //	    fmt.Println(err.Error())
 //	}
-func ValidateStruct(s any) error {
-	if err := validate.Struct(s); err != nil {
-		// Collect and aggregate all validation errors
+func ValidateStructCtx(ctx context.Context, s any) error {
+	if err := validate.StructCtx(ctx, s); err != nil {
+		// Collect all validation errors
 		if verrs, ok := err.(validator.ValidationErrors); ok {
-			msgs := make([]string, 0, len(verrs))
+			locale := i18n.Locale(ctx)
+			fields := make([]apperror.FieldError, 0, len(verrs))
 			for _, fe := range verrs {
-				msgs = append(msgs, translateError(fe))
+				fields = append(fields, apperror.FieldError{
+					Field:   fieldPath(fe),
+					Rule:    fe.Tag(),
+					Param:   fe.Param(),
+					Message: translateError(locale, fe),
+				})
 			}
-			return fmt.Errorf("validation failed: %s", strings.Join(msgs, ", "))
+			return &apperror.ValidationError{Fields: fields}
 		}
 		return err
 	}
@@ -0,0 +1,272 @@
+package validation_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/celpung/gocleanarch/application/apperror"
+	"github.com/celpung/gocleanarch/delivery/dto"
+	"github.com/celpung/gocleanarch/delivery/httpcore"
+	"github.com/celpung/gocleanarch/infrastructure/db/migration"
+	"github.com/celpung/gocleanarch/infrastructure/db/model"
+	"github.com/celpung/gocleanarch/infrastructure/i18n"
+	"github.com/celpung/gocleanarch/infrastructure/validation"
+	"github.com/glebarez/sqlite"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+/*
+===============================================================================
+Test Execution Guide
+
+Run only the validation tests from the project root:
+     go test -v ./infrastructure/validation
+
+Notes:
+- The requests are plain DTO values; only the context-aware rule test opens
+  a database.
+- Custom rules are registered globally, so every test registers its own
+  tags with messages for each supported locale.
+===============================================================================
+*/
+
+/* TestMain registers the rules the request DTOs use, as the servers do on start-up. */
+func TestMain(m *testing.M) {
+	if err := httpcore.RegisterRules(); err != nil {
+		log.Fatalf("failed to register validation rules: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// setupTestDB opens a migrated in-memory SQLite database.
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err, "failed to open in-memory SQLite database")
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, migration.Migrate(context.Background(), db), "failed to migrate schema")
+	return db
+}
+
+/*
+TestValidation_ListsEveryViolation verifies that ValidateStruct reports each
+broken rule with its JSON field, rule and parameter, and still sums them up
+in its message.
+*/
+func TestValidation_ListsEveryViolation(t *testing.T) {
+	err := validation.ValidateStruct(dto.UserCreateRequest{Name: "Eve", Email: "nope", Password: "short"})
+	require.ErrorIs(t, err, apperror.ErrInvalid)
+
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, []apperror.FieldError{
+		{Field: "email", Rule: "email", Message: "Email must be a valid email address"},
+		{Field: "password", Rule: "min", Param: "8", Message: "Password must be at least 8 characters long"},
+		{Field: "role", Rule: "required", Message: "Role is required"},
+	}, invalid.Fields)
+	require.Equal(t, "validation failed: Email must be a valid email address, "+
+		"Password must be at least 8 characters long, Role is required", err.Error())
+
+	require.NoError(t, validation.ValidateStruct(dto.UserLoginRequest{Email: "eve@ex.com", Password: "secret123"}))
+}
+
+/*
+TestValidation_NamesNestedFieldsByPath verifies that violations inside maps
+and slices are keyed by their full JSON path.
+*/
+func TestValidation_NamesNestedFieldsByPath(t *testing.T) {
+	err := validation.ValidateStruct(dto.SliderCreateRequest{
+		Title:        "Spring sale",
+		Description:  "Everything must go",
+		Translations: map[string]dto.SliderTranslationRequest{"id": {}},
+	})
+
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Len(t, invalid.Fields, 1)
+	require.Equal(t, "translations[id].title", invalid.Fields[0].Field)
+	require.Equal(t, "required", invalid.Fields[0].Rule)
+
+	err = validation.ValidateStruct(dto.SliderReorderRequest{IDs: []string{"a", ""}})
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, "ids[1]", invalid.Fields[0].Field)
+}
+
+/*
+TestValidation_MessagesFollowTheLocale verifies that ValidateStructCtx words
+its messages and labels in the locale of the context, and in English when
+the context names none.
+*/
+func TestValidation_MessagesFollowTheLocale(t *testing.T) {
+	req := dto.UserCreateRequest{Email: "eve@ex.com", Password: "short", Role: "USER"}
+
+	err := validation.ValidateStructCtx(i18n.WithLocale(context.Background(), "id"), req)
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, []string{"Nama wajib diisi", "Kata sandi minimal 8 karakter"}, messagesOf(invalid))
+
+	err = validation.ValidateStructCtx(context.Background(), req)
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, []string{"Name is required", "Password must be at least 8 characters long"}, messagesOf(invalid))
+}
+
+func messagesOf(err *apperror.ValidationError) []string {
+	msgs := make([]string, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return msgs
+}
+
+/*
+TestValidation_RegisteredRulesUseTheirMessages verifies that a custom rule
+registered with its own messages is usable from a validate tag and words
+its violations in the request's locale.
+*/
+func TestValidation_RegisteredRulesUseTheirMessages(t *testing.T) {
+	err := validation.Register("even", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	}, map[string]string{
+		"en": "{label} must be even",
+		"id": "{label} harus genap",
+	})
+	require.NoError(t, err)
+
+	type batch struct {
+		Size int `json:"size" validate:"even"`
+	}
+
+	require.NoError(t, validation.ValidateStruct(batch{Size: 4}))
+
+	err = validation.ValidateStructCtx(i18n.WithLocale(context.Background(), "id"), batch{Size: 3})
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, []apperror.FieldError{{Field: "size", Rule: "even", Message: "Size harus genap"}}, invalid.Fields)
+
+	noop := func(validator.FieldLevel) bool { return true }
+	require.Error(t, validation.Register("unworded", noop, map[string]string{"id": "{label} salah"}), "the default locale is required")
+	require.Error(t, validation.Register("unworded", noop, map[string]string{"en": "{label} is wrong", "xx": "?"}), "locales must be supported")
+}
+
+/*
+TestValidation_ContextAwareRulesCanQueryTheDatabase verifies that a rule
+registered with RegisterCtx receives the context of the validation, here
+to check that an email is not taken yet.
+*/
+func TestValidation_ContextAwareRulesCanQueryTheDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&model.User{Name: "Dana", Email: "dana@ex.com", Password: "hash", Role: "USER"}).Error)
+
+	type ctxKey struct{}
+	err := validation.RegisterCtx("unique_email", func(ctx context.Context, fl validator.FieldLevel) bool {
+		require.Equal(t, "marker", ctx.Value(ctxKey{}), "the rule sees the caller's context")
+		var taken int64
+		require.NoError(t, db.WithContext(ctx).Model(&model.User{}).Where("email = ?", fl.Field().String()).Count(&taken).Error)
+		return taken == 0
+	}, map[string]string{
+		"en": "{label} is already registered",
+		"id": "{label} sudah terdaftar",
+	})
+	require.NoError(t, err)
+
+	type signup struct {
+		Email string `json:"email" validate:"required,email,unique_email"`
+	}
+	ctx = context.WithValue(ctx, ctxKey{}, "marker")
+
+	require.NoError(t, validation.ValidateStructCtx(ctx, signup{Email: "eve@ex.com"}))
+
+	err = validation.ValidateStructCtx(ctx, signup{Email: "dana@ex.com"})
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, "unique_email", invalid.Fields[0].Rule)
+	require.Equal(t, "Email is already registered", invalid.Fields[0].Message)
+}
+
+/*
+TestValidation_CrossFieldRuleComparesWithItsSibling verifies the after rule
+on slider schedules: the end must follow the start named by its param, and
+either end may be left out.
+*/
+func TestValidation_CrossFieldRuleComparesWithItsSibling(t *testing.T) {
+	start, end := "2026-03-01T00:00:00Z", "2026-02-01T00:00:00Z"
+	req := dto.SliderCreateRequest{Title: "Spring sale", Description: "Everything must go", StartsAt: &start, EndsAt: &end}
+
+	err := validation.ValidateStruct(req)
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, []apperror.FieldError{{
+		Field: "ends_at", Rule: "after", Param: "starts_at", Message: "End time must be after Start time",
+	}}, invalid.Fields)
+
+	err = validation.ValidateStructCtx(i18n.WithLocale(context.Background(), "id"), req)
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, "Waktu selesai harus setelah Waktu mulai", invalid.Fields[0].Message)
+
+	req.StartsAt, req.EndsAt = &end, &start
+	require.NoError(t, validation.ValidateStruct(req))
+
+	req.StartsAt = nil
+	require.NoError(t, validation.ValidateStruct(req))
+	require.NoError(t, validation.ValidateStruct(dto.SliderUpdateRequest{EndsAt: &end}))
+}
+
+/*
+TestValidation_StructRulesReportTheirOwnTags verifies that a struct-level
+rule can check several fields together and report a violation on one of
+them with a tag worded by its own messages.
+*/
+func TestValidation_StructRulesReportTheirOwnTags(t *testing.T) {
+	type passwordChange struct {
+		Password     string `json:"password" validate:"required"`
+		Confirmation string `json:"confirmation"`
+	}
+
+	err := validation.RegisterStruct(func(_ context.Context, sl validator.StructLevel) {
+		change := sl.Current().Interface().(passwordChange)
+		if change.Confirmation != change.Password {
+			sl.ReportError(change.Confirmation, "confirmation", "Confirmation", "confirmed", "")
+		}
+	}, map[string]map[string]string{
+		"confirmed": {"en": "{label} does not match the password", "id": "{label} tidak cocok dengan kata sandi"},
+	}, passwordChange{})
+	require.NoError(t, err)
+
+	require.NoError(t, validation.ValidateStruct(passwordChange{Password: "secret123", Confirmation: "secret123"}))
+
+	err = validation.ValidateStruct(passwordChange{Password: "secret123", Confirmation: "secret321"})
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, []apperror.FieldError{{
+		Field: "confirmation", Rule: "confirmed", Message: "Confirmation does not match the password",
+	}}, invalid.Fields)
+}
+
+/*
+TestValidation_RoleMustExist verifies the role rule the user requests use:
+known roles pass in any case and anything else is reported.
+*/
+func TestValidation_RoleMustExist(t *testing.T) {
+	req := dto.UserCreateRequest{Name: "Eve", Email: "eve@ex.com", Password: "secret123", Role: "admin"}
+	require.NoError(t, validation.ValidateStruct(req))
+
+	req.Role = "OWNER"
+	err := validation.ValidateStruct(req)
+	var invalid *apperror.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, []apperror.FieldError{{Field: "role", Rule: "role", Message: "Role must be an existing role"}}, invalid.Fields)
+
+	role := "NOBODY"
+	err = validation.ValidateStruct(dto.UserUpdateRequest{ID: "2b1f0c52-3d0b-4c8e-9a53-0d4f3c4b7e21", Role: &role})
+	require.True(t, errors.As(err, &invalid))
+	require.Equal(t, "role", invalid.Fields[0].Rule)
+}